@@ -0,0 +1,34 @@
+package fastcache
+
+// Number is a constraint that permits any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Add atomically adds delta to the value for k and returns the new value.
+//
+// A missing key is treated as zero, so the first Add stores delta and
+// inserts the key into the eviction order like [Cache.Set]. Adding to an
+// existing key keeps its position under [PolicyFIFO], promotes it under
+// [PolicyLRU] and keeps its expiration. Each call counts as a Set call in
+// [Stats].
+//
+// The read-modify-write happens under the shard lock, so concurrent Adds on
+// the same key never lose updates. However, the counter is still an ordinary
+// cache entry: if it is evicted or expires between two calls, the next Add
+// starts again from zero. Size the cache so that counters are not evicted if
+// they must not reset.
+//
+// Integer overflow wraps around as in Go arithmetic.
+//
+// Add returns an error if the cache cannot evict an existing entry while full.
+func Add[K comparable, V Number](c *Cache[K, V], k K, delta V) (V, error) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].update(c, idx, h, k, func(v V) V {
+		return v + delta
+	})
+}
@@ -0,0 +1,94 @@
+package fastcache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdd(t *testing.T) {
+	c, err := New[string, int64](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Missing keys start from zero
+	v, err := Add(c, "counter", 5)
+	if err != nil {
+		t.Fatalf("Add error: %s", err)
+	}
+	if v != 5 {
+		t.Fatalf("unexpected value after first Add; got %d; want 5", v)
+	}
+
+	if v, err = Add(c, "counter", -2); err != nil {
+		t.Fatalf("Add error: %s", err)
+	}
+	if v != 3 {
+		t.Fatalf("unexpected value after second Add; got %d; want 3", v)
+	}
+	if got, ok := c.Get("counter"); !ok || got != 3 {
+		t.Fatalf("unexpected stored value; got (%d, %t); want (3, true)", got, ok)
+	}
+
+	if s := c.Stats(); s.SetCalls != 2 {
+		t.Fatalf("unexpected SetCalls; got %d; want 2", s.SetCalls)
+	}
+
+	// Add keeps the expiration of an existing entry
+	if err := c.SetWithTTL("ttl", 1, time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if _, err := Add(c, "ttl", 1); err != nil {
+		t.Fatalf("Add error: %s", err)
+	}
+	expireKey(c, "ttl")
+	if v, err = Add(c, "ttl", 1); err != nil {
+		t.Fatalf("Add error: %s", err)
+	}
+	if v != 1 {
+		t.Fatalf("unexpected value after Add on an expired key; got %d; want 1", v)
+	}
+}
+
+func TestAddConcurrent(t *testing.T) {
+	for _, policy := range []Policy{PolicyFIFO, PolicyLRU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			c, err := New[string, float64](100, WithPolicy(policy))
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			const (
+				workers    = 8
+				iterations = 1000
+			)
+
+			var wg sync.WaitGroup
+			for range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range iterations {
+						if _, err := Add(c, "counter", 0.5); err != nil {
+							t.Errorf("Add error: %s", err)
+
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			want := float64(workers*iterations) * 0.5
+			if v, _ := c.Get("counter"); v != want {
+				t.Fatalf("lost updates; got %v; want %v", v, want)
+			}
+			if c.Len() != 1 {
+				t.Fatalf("unexpected len; got %d; want 1", c.Len())
+			}
+		})
+	}
+}
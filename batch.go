@@ -0,0 +1,254 @@
+package fastcache
+
+import (
+	"fmt"
+	"iter"
+	"time"
+)
+
+// batchItem is a single key-value pair routed to a shard by a batch operation.
+type batchItem[K comparable, V any] struct {
+	hash  uint64
+	key   K
+	value V
+	exp   expiry // zero for no expiration
+}
+
+// batch groups items by shard index, preserving the input order within each
+// shard.
+type batch[K comparable, V any] struct {
+	groups map[int][]batchItem[K, V]
+	shards []int // shard indexes in order of first appearance
+}
+
+func (c *Cache[K, V]) newBatch() *batch[K, V] {
+	return &batch[K, V]{groups: make(map[int][]batchItem[K, V])}
+}
+
+func (b *batch[K, V]) add(c *Cache[K, V], k K, v V) {
+	b.addWithExpiry(c, k, v, expiry{})
+}
+
+func (b *batch[K, V]) addWithExpiry(c *Cache[K, V], k K, v V, exp expiry) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	group, ok := b.groups[idx]
+	if !ok {
+		b.shards = append(b.shards, idx)
+	}
+	b.groups[idx] = append(group, batchItem[K, V]{hash: h, key: k, value: v, exp: exp})
+}
+
+// SetMany stores all pairs yielded by pairs in the cache.
+//
+// Pairs are grouped by shard, so each shard lock is taken once for updating
+// existing keys and the eviction lock is taken once per shard for inserting
+// new keys. Within a shard, pairs are applied in the order they are yielded.
+//
+// SetMany stops and returns an error if the cache cannot evict an existing
+// entry while full. Pairs applied before the error remain stored.
+func (c *Cache[K, V]) SetMany(pairs iter.Seq2[K, V]) error {
+	b := c.newBatch()
+	for k, v := range pairs {
+		b.add(c, k, v)
+	}
+
+	for _, idx := range b.shards {
+		if err := c.shards[idx].setMany(c, idx, b.groups[idx], true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WarmFrom stores all pairs yielded by pairs in the cache like
+// [Cache.SetMany], but without counting them as Set calls in [Stats], so
+// that preloading a cache at startup does not skew its stats.
+//
+// Warming still respects the capacity of the cache: if pairs hold more
+// entries than fit, older entries are evicted and counted in the eviction
+// stats, and with [WithStrictCapacity] WarmFrom stops and returns an error
+// wrapping [ErrCacheFull]. Pairs applied before an error remain stored.
+func (c *Cache[K, V]) WarmFrom(pairs iter.Seq2[K, V]) error {
+	b := c.newBatch()
+	for k, v := range pairs {
+		b.add(c, k, v)
+	}
+
+	for _, idx := range b.shards {
+		if err := c.shards[idx].setMany(c, idx, b.groups[idx], false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TTLEntry is a key-value pair stored by [Cache.SetMultiWithTTL] for its own
+// TTL.
+type TTLEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	TTL   time.Duration
+}
+
+// SetMultiWithTTL stores all entries in the cache, each for its own TTL like
+// [Cache.SetWithTTL].
+//
+// Entries are grouped by shard like in [Cache.SetMany], so storing many
+// entries with different expirations takes each lock once per shard. The
+// expirations are computed from a single reading of the clock.
+//
+// SetMultiWithTTL returns an error wrapping [ErrInvalidTTL] without storing
+// anything if a TTL is not positive. It stops and returns an error if the
+// cache cannot evict an existing entry while full; entries applied before
+// the error remain stored.
+func (c *Cache[K, V]) SetMultiWithTTL(entries []TTLEntry[K, V]) error {
+	for _, e := range entries {
+		if e.TTL <= 0 {
+			return fmt.Errorf("%w: got %s", ErrInvalidTTL, e.TTL)
+		}
+	}
+
+	now := c.now()
+	b := c.newBatch()
+	for _, e := range entries {
+		b.addWithExpiry(c, e.Key, e.Value, expiry{at: now + int64(e.TTL), ttl: int64(e.TTL)})
+	}
+
+	for _, idx := range b.shards {
+		if err := c.shards[idx].setMany(c, idx, b.groups[idx], true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMany returns the values for the given keys.
+//
+// Only keys found in the cache are present in the returned map. Each key
+// counts as a Get call in [Stats].
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	var zero V
+
+	b := c.newBatch()
+	for _, k := range keys {
+		b.add(c, k, zero)
+	}
+
+	found := make(map[K]V, len(keys))
+	for _, idx := range b.shards {
+		c.shards[idx].getMany(c, b.groups[idx], found)
+	}
+
+	return found
+}
+
+// GetMulti is like [Cache.GetMany], but additionally returns the keys that
+// were not found, so that only those need to be fetched from the origin.
+//
+// missing preserves the order of keys, including duplicates.
+func (c *Cache[K, V]) GetMulti(keys []K) (found map[K]V, missing []K) {
+	found = c.GetMany(keys)
+	for _, k := range keys {
+		if _, ok := found[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+
+	return found, missing
+}
+
+// DeleteMany removes the values for the given keys.
+//
+// Keys are grouped by shard, so each shard lock is taken once.
+func (c *Cache[K, V]) DeleteMany(keys []K) {
+	var zero V
+
+	b := c.newBatch()
+	for _, k := range keys {
+		b.add(c, k, zero)
+	}
+
+	for _, idx := range b.shards {
+		c.shards[idx].deleteMany(c, b.groups[idx])
+	}
+}
+
+// setMany stores items in s. countSets reports whether each item counts as
+// a Set call.
+func (s *shard[K, V]) setMany(c *Cache[K, V], idx int, items []batchItem[K, V], countSets bool) error {
+	pending := items[:0:0]
+
+	c.lockShard(s)
+	for _, item := range items {
+		if countSets && !c.noStats {
+			s.setCalls++
+		}
+
+		bucket, pos := s.lookupLocked(c, item.hash, item.key)
+		if pos >= 0 {
+			if err := s.replaceLocked(c, &bucket[pos], item.value, item.exp, 1); err != nil {
+				c.unlockShard(s)
+
+				return err
+			}
+			c.touchLocked(bucket[pos].node)
+
+			continue
+		}
+		pending = append(pending, item)
+	}
+	c.unlockShard(s)
+
+	if len(pending) == 0 {
+		c.enforceLimits()
+
+		return nil
+	}
+
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	c.evictOverLimitsLocked()
+	for _, item := range pending {
+		if _, err := c.insertLocked(opSet, idx, item.hash, item.key, item.value, item.exp, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *shard[K, V]) getMany(c *Cache[K, V], items []batchItem[K, V], found map[K]V) {
+	c.lockShard(s)
+	for _, item := range items {
+		if !c.noStats {
+			s.getCalls++
+		}
+		s.sampleLocked(c, item.key)
+
+		bucket, pos := s.lookupLocked(c, item.hash, item.key)
+		if pos >= 0 {
+			found[item.key] = bucket[pos].Value
+			c.touchLocked(bucket[pos].node)
+
+			continue
+		}
+		if !c.noStats {
+			s.misses++
+		}
+	}
+	c.unlockShard(s)
+}
+
+func (s *shard[K, V]) deleteMany(c *Cache[K, V], items []batchItem[K, V]) {
+	s.mu.Lock()
+	for _, item := range items {
+		s.deleteLocked(c, item.hash, item.key)
+	}
+	s.mu.Unlock()
+}
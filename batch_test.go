@@ -0,0 +1,239 @@
+package fastcache
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCacheSetGetDeleteMany(t *testing.T) {
+	c, err := New[string, string](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const itemsCount = 100
+
+	pairs := make(map[string]string, itemsCount)
+	keys := make([]string, 0, itemsCount)
+	for i := range itemsCount {
+		k := fmt.Sprintf("key %d", i)
+		pairs[k] = fmt.Sprintf("value %d", i)
+		keys = append(keys, k)
+	}
+
+	if err := c.SetMany(maps.All(pairs)); err != nil {
+		t.Fatalf("SetMany error: %s", err)
+	}
+	if c.Len() != itemsCount {
+		t.Fatalf("unexpected len after SetMany; got %d; want %d", c.Len(), itemsCount)
+	}
+
+	found := c.GetMany(append(keys, "missing"))
+	if !maps.Equal(found, pairs) {
+		t.Fatalf("unexpected GetMany result; got %d entries; want %d", len(found), len(pairs))
+	}
+
+	// Overwrite existing keys in a second batch
+	if err := c.SetMany(func(yield func(string, string) bool) {
+		yield("key 0", "updated")
+	}); err != nil {
+		t.Fatalf("SetMany error: %s", err)
+	}
+	if v, ok := c.Get("key 0"); !ok || v != "updated" {
+		t.Fatalf("unexpected value after SetMany update; got (%q, %t); want (%q, true)", v, ok, "updated")
+	}
+
+	c.DeleteMany(keys[:itemsCount/2])
+	if c.Len() != itemsCount/2 {
+		t.Fatalf("unexpected len after DeleteMany; got %d; want %d", c.Len(), itemsCount/2)
+	}
+	for _, k := range keys[:itemsCount/2] {
+		if c.Has(k) {
+			t.Fatalf("unexpected key %q after DeleteMany", k)
+		}
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.SetCalls != itemsCount+1 {
+		t.Fatalf("unexpected SetCalls; got %d; want %d", s.SetCalls, itemsCount+1)
+	}
+	// Has does not count as a Get.
+	if s.Misses != 1 {
+		t.Fatalf("unexpected Misses; got %d; want 1", s.Misses)
+	}
+	if s.Deletes != itemsCount/2 {
+		t.Fatalf("unexpected Deletes; got %d; want %d", s.Deletes, itemsCount/2)
+	}
+}
+
+func TestCacheGetMulti(t *testing.T) {
+	c, err := New[int, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := 0; i < 100; i += 2 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	keys := []int{7, 4, 3, 0, 99, 3, 50}
+	found, missing := c.GetMulti(keys)
+
+	if want := map[int]int{4: 4, 0: 0, 50: 50}; !maps.Equal(found, want) {
+		t.Fatalf("unexpected found; got %v; want %v", found, want)
+	}
+	if want := []int{7, 3, 99, 3}; !slices.Equal(missing, want) {
+		t.Fatalf("unexpected missing; got %v; want %v", missing, want)
+	}
+
+	if s := c.Stats(); s.GetCalls != uint64(len(keys)) || s.Misses != 4 {
+		t.Fatalf("unexpected stats; got GetCalls=%d, Misses=%d; want %d, 4", s.GetCalls, s.Misses, len(keys))
+	}
+}
+
+func TestCacheSetManyPreservesOrderWithinShard(t *testing.T) {
+	c, err := New[string, int](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	c.hasher = func(string) uint64 { return 1 }
+	defer c.Reset()
+
+	if err := c.SetMany(func(yield func(string, int) bool) {
+		for i, k := range []string{"a", "b", "c"} {
+			if !yield(k, i) {
+				return
+			}
+		}
+	}); err != nil {
+		t.Fatalf("SetMany error: %s", err)
+	}
+
+	if c.Has("a") {
+		t.Fatal("oldest key a was not evicted by SetMany")
+	}
+	if !c.Has("b") || !c.Has("c") {
+		t.Fatal("SetMany evicted newer keys")
+	}
+}
+
+func TestCacheSetManyReturnsErrorWhenEvictionFails(t *testing.T) {
+	c, err := New[string, string](1)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	c.hasher = func(string) uint64 { return 1 }
+	c.shards[1].entries[1] = []entry[string, string]{{Key: "stale", Value: "value"}}
+	c.shards[1].entryCount = 1
+	c.entryCount.Store(1)
+
+	err = c.SetMany(func(yield func(string, string) bool) {
+		yield("fresh", "value")
+	})
+	if !errors.Is(err, ErrEvictionFailed) {
+		t.Fatalf("SetMany returned error %v; want %v", err, ErrEvictionFailed)
+	}
+}
+
+func TestCacheWarmFrom(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.WarmFrom(func(yield func(int, int) bool) {
+		for i := range 5 {
+			if !yield(i, i*10) {
+				return
+			}
+		}
+	}); err != nil {
+		t.Fatalf("WarmFrom error: %s", err)
+	}
+
+	for i := range 5 {
+		if v, ok := c.Peek(i); !ok || v != i*10 {
+			t.Fatalf("unexpected value for key %d; got (%d, %t); want (%d, true)", i, v, ok, i*10)
+		}
+	}
+	if s := c.Stats(); s.SetCalls != 0 || s.Evictions != 0 {
+		t.Fatalf("unexpected stats after warming; got SetCalls=%d, Evictions=%d; want 0, 0", s.SetCalls, s.Evictions)
+	}
+
+	// Warming past capacity evicts, and the evictions are counted.
+	if err := c.WarmFrom(func(yield func(int, int) bool) {
+		for i := 5; i < 15; i++ {
+			if !yield(i, i*10) {
+				return
+			}
+		}
+	}); err != nil {
+		t.Fatalf("WarmFrom error: %s", err)
+	}
+	if n := c.Len(); n != 10 {
+		t.Fatalf("unexpected len; got %d; want 10", n)
+	}
+	if s := c.Stats(); s.SetCalls != 0 || s.Evictions != 5 {
+		t.Fatalf("unexpected stats after overflowing; got SetCalls=%d, Evictions=%d; want 0, 5", s.SetCalls, s.Evictions)
+	}
+}
+
+func TestCacheSetMultiWithTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[int, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set(0, -1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	// Key i expires after i seconds; key 0 already exists and gains a TTL.
+	entries := make([]TTLEntry[int, int], 10)
+	for i := range entries {
+		entries[i] = TTLEntry[int, int]{Key: i, Value: i * 10, TTL: time.Duration(i+1) * time.Second}
+	}
+	if err := c.SetMultiWithTTL(entries); err != nil {
+		t.Fatalf("SetMultiWithTTL error: %s", err)
+	}
+	if s := c.Stats(); s.SetCalls != 11 {
+		t.Fatalf("unexpected SetCalls; got %d; want 11", s.SetCalls)
+	}
+	for _, e := range entries {
+		want := clock.Now().Add(e.TTL)
+		if v, exp, ok := c.GetWithExpiry(e.Key); !ok || v != e.Value || !exp.Equal(want) {
+			t.Fatalf("unexpected entry for key %d; got (%d, %s, %t); want (%d, %s, true)", e.Key, v, exp, ok, e.Value, want)
+		}
+	}
+
+	clock.Advance(5 * time.Second)
+	for _, e := range entries {
+		_, ok := c.Peek(e.Key)
+		if want := e.TTL > 5*time.Second; ok != want {
+			t.Fatalf("unexpected presence of key %d after 5s; got %t; want %t", e.Key, ok, want)
+		}
+	}
+
+	// A non-positive TTL rejects the whole batch.
+	err = c.SetMultiWithTTL([]TTLEntry[int, int]{{Key: 100, Value: 1, TTL: time.Second}, {Key: 101, Value: 2}})
+	if !errors.Is(err, ErrInvalidTTL) {
+		t.Fatalf("unexpected error; got %v; want %v", err, ErrInvalidTTL)
+	}
+	if c.Has(100) {
+		t.Fatal("SetMultiWithTTL stored an entry of a rejected batch")
+	}
+}
@@ -0,0 +1,54 @@
+package fastcache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// MarshalBinary implements [encoding.BinaryMarshaler].
+//
+// The data has the same format as written by [Cache.SaveTo]. Keys and values
+// are serialized with [gob], so concrete types stored in interface-typed keys
+// or values must be registered with [RegisterTypes].
+func (c *Cache[K, V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.save(context.Background(), &buf, MinLZGobCodec{}, 1, false); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler].
+//
+// It replaces the contents of c with the data written by
+// [Cache.MarshalBinary] or [Cache.SaveTo], and resets its stats. The
+// capacity is taken from the data, while the other options of c, such as its
+// eviction policy, are kept. UnmarshalBinary may be called on a zero Cache,
+// as done by decoders of enclosing messages, which is then usable with the
+// default options.
+//
+// UnmarshalBinary must not be called concurrently with other methods of c.
+// If it fails, c may hold part of the entries.
+func (c *Cache[K, V]) UnmarshalBinary(data []byte) error {
+	d, err := openDump(bytes.NewReader(data), nil)
+	if err != nil {
+		return err
+	}
+	if d.capacity() <= 0 {
+		return fmt.Errorf("%w: got %d", ErrInvalidMaxEntries, d.capacity())
+	}
+
+	if c.hasher == nil {
+		c.hasher = newHasher[K](0)
+	}
+	if c.stripes == nil {
+		c.initShards(0)
+	}
+	c.clear(false)
+	c.maxEntries = d.capacity()
+	c.initShards(len(c.stripes))
+
+	return decodeEntries(d, c)
+}
@@ -0,0 +1,102 @@
+package fastcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCacheMarshalUnmarshalBinary(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const itemsCount = 50
+	for i := range itemsCount {
+		if err := c.Set(fmt.Sprintf("key %d", i), fmt.Sprintf("value %d", i)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary error: %s", err)
+	}
+
+	// The receiver's contents are replaced, while its policy is kept
+	dst, err := New[string, string](10, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer dst.Reset()
+	if err := dst.Set("stale", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	if err := dst.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary error: %s", err)
+	}
+	if dst.Has("stale") {
+		t.Fatal("UnmarshalBinary kept a previous entry")
+	}
+	if dst.Len() != itemsCount || dst.Capacity() != 100 {
+		t.Fatalf("unexpected cache after UnmarshalBinary; got Len=%d, Capacity=%d; want %d, 100", dst.Len(), dst.Capacity(), itemsCount)
+	}
+	if dst.policy != PolicyLRU {
+		t.Fatalf("UnmarshalBinary changed the policy to %s", dst.policy)
+	}
+	for i := range itemsCount {
+		k, v := fmt.Sprintf("key %d", i), fmt.Sprintf("value %d", i)
+		if vv, ok := dst.Get(k); !ok || vv != v {
+			t.Fatalf("unexpected cache value for k=%q; got %q; want %q", k, vv, v)
+		}
+	}
+
+	if err := dst.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrCorruptedData) {
+		t.Fatalf("UnmarshalBinary returned error %v for truncated data; want %v", err, ErrCorruptedData)
+	}
+}
+
+func TestCacheBinaryInGobMessage(t *testing.T) {
+	type message struct {
+		Name  string
+		Cache *Cache[int, string]
+	}
+
+	c, err := New[int, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+	if err := c.Set(1, "one"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(message{Name: "snapshot", Cache: c}); err != nil {
+		t.Fatalf("Encode error: %s", err)
+	}
+
+	var got message
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatalf("Decode error: %s", err)
+	}
+	if got.Name != "snapshot" {
+		t.Fatalf("unexpected name; got %q; want %q", got.Name, "snapshot")
+	}
+	if v, ok := got.Cache.Get(1); !ok || v != "one" {
+		t.Fatalf("unexpected decoded value; got (%q, %t); want (%q, true)", v, ok, "one")
+	}
+
+	// The decoded cache is fully usable
+	if err := got.Cache.Set(2, "two"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if got.Cache.Len() != 2 {
+		t.Fatalf("unexpected len; got %d; want 2", got.Cache.Len())
+	}
+}
@@ -3,30 +3,51 @@ package fastcache
 import (
 	"fmt"
 	"iter"
+	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.dw1.io/rapidhash"
 )
 
-// Cache is a fast thread-safe in-memory cache with FIFO eviction.
+// Cache is a fast thread-safe in-memory cache with FIFO or LRU eviction.
+//
+// Capacity is enforced globally rather than per shard: when the cache is full,
+// the next eviction candidate is taken from the cache-wide eviction list
+// regardless of which shard it lives in. A skewed key distribution that maps
+// most keys to a few shards therefore still holds up to maxEntries live
+// entries, and [Cache.Len] never exceeds maxEntries, however small it is.
 //
 // Call [Cache.Reset] when the cache is no longer needed. This reclaims the allocated
 // memory.
 type Cache[K comparable, V any] struct {
-	shards     [shardsCount]shard[K, V]
-	hasher     func(K) uint64
-	maxEntries int
-	orderMu    sync.Mutex
-	order      []slot[K]
-	head       int
-	entryCount atomic.Int64 // global entry count for accurate capacity enforcement
-}
-
-type slot[K comparable] struct {
-	shard int
-	hash  uint64
-	key   K
+	shards          [shardsCount]shard[K, V]
+	stripes         []sync.Mutex // shard locks; shard i uses stripes[i%len(stripes)]
+	hasher          func(K) uint64
+	maxEntries      int
+	initialCapacity int              // 0 to size the shard maps for maxEntries; see WithInitialCapacity
+	maxBytes        int64            // 0 if unlimited
+	sizeOf          func(K, V) int64 // nil if byte usage is not tracked
+	copyValue       func(V) V        // nil if values are stored as is
+	bytes           atomic.Int64     // estimated size of all entries
+	maxCost         int64            // 0 if unlimited
+	cost            atomic.Int64     // total cost of all entries
+	policy          Policy
+	noStats         bool             // counters are not updated; see WithStatsDisabled
+	serveStale      bool             // see WithStaleWhileRevalidate
+	strict          bool             // reject inserts instead of evicting; see WithStrictCapacity
+	evictBatch      int              // 0 if unbounded; see WithEvictionBatch
+	sampleRate      float64          // fraction of Gets sampled; see WithAccessSampling
+	janitor         *janitor         // nil unless WithJanitor is used
+	leak            *leakCheck       // nil unless WithLeakCheck is used
+	sink            *evictSink[K, V] // nil unless WithEvictionChannel is used
+	clock           Clock            // nil for the real-time clock; see WithClock
+	orderMu         sync.Mutex       // guards order; acquired before any shard lock
+	order           evictionList[K]
+	staleNodes      atomic.Int64               // nodes in order whose entries were deleted
+	entryCount      atomic.Int64               // global entry count for accurate capacity enforcement
+	restored        atomic.Pointer[savedStats] // nil unless loaded with saved stats
 }
 
 type op uint8
@@ -35,12 +56,15 @@ const (
 	opSet op = iota
 	opGetOrSet
 	opSetIfAbsent
+	opSwap
+	opSetNegative
 )
 
 type result[V any] struct {
-	value  V
-	loaded bool
-	stored bool
+	value   V
+	loaded  bool
+	stored  bool
+	evicted bool // an entry was evicted to make room for the insert
 }
 
 const shardMask = uint64(shardsCount - 1)
@@ -48,30 +72,119 @@ const shardMask = uint64(shardsCount - 1)
 // New returns a new cache with the given maxEntries capacity.
 //
 // maxEntries is the maximum number of entries the cache can hold.
-// When the cache is full, the oldest entries are evicted (FIFO).
+// When the cache is full, the oldest entries are evicted (FIFO) unless
+// another policy is selected with [WithPolicy].
 //
-// New returns an error if maxEntries is not positive.
-func New[K comparable, V any](maxEntries int) (*Cache[K, V], error) {
+// Use [WithMaxBytes] to additionally cap the estimated memory usage.
+//
+// New returns an error if maxEntries is not positive or if an option is
+// invalid.
+func New[K comparable, V any](maxEntries int, opts ...Option) (*Cache[K, V], error) {
 	if maxEntries <= 0 {
 		return nil, fmt.Errorf("%w: got %d", ErrInvalidMaxEntries, maxEntries)
 	}
 
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if o.hasInitialCapacity && (o.initialCapacity <= 0 || o.initialCapacity > maxEntries) {
+		return nil, fmt.Errorf("%w: got %d with maxEntries %d", ErrInvalidInitialCapacity, o.initialCapacity, maxEntries)
+	}
+
+	sizeOf, err := sizeOfFromOptions[K, V](&o)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := evictSinkFromOptions[K, V](&o)
+	if err != nil {
+		return nil, err
+	}
+	copyValue, err := valueCopyFromOptions[V](&o)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Cache[K, V]{
-		maxEntries: maxEntries,
-		hasher:     newHasher[K](),
-		order:      make([]slot[K], 0, maxEntries),
+		maxEntries:      maxEntries,
+		initialCapacity: o.initialCapacity,
+		maxBytes:        o.maxBytes,
+		maxCost:         o.maxCost,
+		sizeOf:          sizeOf,
+		copyValue:       copyValue,
+		policy:          o.policy,
+		noStats:         o.statsDisabled,
+		serveStale:      o.serveStale,
+		strict:          o.strictCapacity,
+		evictBatch:      max(o.evictionBatch, 0),
+		sampleRate:      o.sampleRate,
+		sink:            sink,
+		clock:           o.clock,
+		hasher:          newHasher[K](o.hashSeed),
 	}
+	c.initShards(o.lockStripes)
 
-	entriesPerShard := (maxEntries + shardsCount - 1) / shardsCount
+	if o.janitorInterval > 0 {
+		c.startJanitor(o.janitorInterval)
+	}
+	if o.leakCheck {
+		c.startLeakCheck(1)
+	}
+
+	return c, nil
+}
+
+// initShards allocates the shard maps and the given number of lock stripes,
+// or one lock per shard if stripes is 0.
+//
+// entriesPerShard is only a size hint for the maps and does not bound the
+// number of entries: a shard may hold any number of entries, while the
+// cache-wide entry count is checked against maxEntries on every insert. The
+// hint is rounded down, so that small caches do not preallocate a slot in
+// every shard. The hint is computed by division, so it cannot overflow even
+// for a maxEntries near [math.MaxInt], but it is capped at
+// maxShardSizeHint, so that a huge capacity does not preallocate memory for
+// entries that may never be stored. [WithInitialCapacity] replaces
+// maxEntries in the hint and is not capped.
+func (c *Cache[K, V]) initShards(stripes int) {
+	if stripes == 0 {
+		stripes = shardsCount
+	}
+	c.stripes = make([]sync.Mutex, stripes)
+
+	entriesPerShard := min(c.maxEntries/shardsCount, maxShardSizeHint)
+	if c.initialCapacity > 0 {
+		entriesPerShard = min(c.initialCapacity, c.maxEntries) / shardsCount
+	}
 	for i := range c.shards {
+		c.shards[i].mu = &c.stripes[i%stripes]
 		c.shards[i].entries = make(map[uint64][]entry[K, V], entriesPerShard)
 	}
+}
 
-	return c, nil
+// presize replaces the maps of the empty shards of c with maps sized for n
+// entries, like [WithInitialCapacity] does at creation, so that loading n
+// entries into a cleared cache does not allocate the maps again as they
+// grow.
+func (c *Cache[K, V]) presize(n int) {
+	entriesPerShard := min(n, c.maxEntries) / shardsCount
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		if s.entryCount == 0 {
+			s.entries = make(map[uint64][]entry[K, V], entriesPerShard)
+		}
+		s.mu.Unlock()
+	}
 }
 
 // Set stores (k, v) in the cache.
 //
+// The value is stored as is, so a []byte, map or pointer value shares its
+// data with the caller, unless the cache copies values; see [WithValueCopy].
 // The stored entry may be evicted at any time due to cache overflow.
 //
 // Set returns an error if the cache cannot evict an existing entry while full.
@@ -79,7 +192,37 @@ func (c *Cache[K, V]) Set(k K, v V) error {
 	h := c.hasher(k)
 	idx := c.shardIndexFromHash(h)
 
-	return c.shards[idx].set(c, idx, h, k, v)
+	return c.shards[idx].set(c, idx, h, k, v, expiry{}, 1)
+}
+
+// TrySet stores (k, v) in the cache and reports whether it was stored.
+//
+// It is meant for caches created with [WithStrictCapacity], where it returns
+// false instead of evicting an entry when the cache is full and k is new.
+// It also returns false where [Cache.Set] would return an error.
+func (c *Cache[K, V]) TrySet(k K, v V) bool {
+	return c.Set(k, v) == nil
+}
+
+// SetWithTTL stores (k, v) in the cache for the given ttl.
+//
+// After ttl has elapsed the entry is treated as missing and removed on the
+// next access, or earlier by the janitor started with [WithJanitor]. Setting
+// an existing key replaces its expiration; [Cache.Set] clears it.
+//
+// A saved entry keeps the TTL it has left; see [Cache.SaveTo].
+//
+// SetWithTTL returns an error if ttl is not positive or if the cache cannot
+// evict an existing entry while full.
+func (c *Cache[K, V]) SetWithTTL(k K, v V, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("%w: got %s", ErrInvalidTTL, ttl)
+	}
+
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].set(c, idx, h, k, v, c.expiryAfter(ttl), 1)
 }
 
 // Get returns the value for the given key.
@@ -89,12 +232,46 @@ func (c *Cache[K, V]) Get(k K) (V, bool) {
 	h := c.hasher(k)
 	idx := c.shardIndexFromHash(h)
 
-	return c.shards[idx].get(h, k)
+	v, _, ok := c.shards[idx].get(c, h, k)
+
+	return v, ok
+}
+
+// GetOr returns the value for the given key, or def if the key is not
+// found. It does not store def.
+//
+// Like [Cache.Get], GetOr counts as a Get call in [Stats], and a miss counts
+// as a miss.
+func (c *Cache[K, V]) GetOr(k K, def V) V {
+	if v, ok := c.Get(k); ok {
+		return v
+	}
+
+	return def
+}
+
+// Peek returns the value for the given key without side effects.
+//
+// Unlike [Cache.Get], Peek is stats-neutral: it does not count as a Get call,
+// hit or miss in [Stats], and it does not promote the key under [PolicyLRU].
+// This makes it suitable for monitoring and debugging tools that sample the
+// cache.
+//
+// Returns the zero value and false if the key is not found.
+func (c *Cache[K, V]) Peek(k K) (V, bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].peek(c, h, k)
 }
 
 // Has returns true if entry for the given key exists in the cache.
+//
+// Like [Cache.Peek], Has is stats-neutral: an existence check does not count
+// as a Get call, hit or miss in [Stats], and it does not promote the key
+// under [PolicyLRU].
 func (c *Cache[K, V]) Has(k K) bool {
-	_, ok := c.Get(k)
+	_, ok := c.Peek(k)
 
 	return ok
 }
@@ -105,6 +282,11 @@ func (c *Cache[K, V]) Has(k K) bool {
 // The loaded result is true if the value was loaded, false if stored.
 //
 // GetOrSet returns an error if the cache cannot evict an existing entry while full.
+//
+// With [WithStrictCapacity], GetOrSet of a new key in a full cache neither
+// stores v nor evicts an entry: it returns the zero value, false and an
+// error wrapping [ErrCacheFull], so that callers can apply back-pressure.
+// Existing keys are still loaded.
 func (c *Cache[K, V]) GetOrSet(k K, v V) (actual V, loaded bool, err error) {
 	h := c.hasher(k)
 	idx := c.shardIndexFromHash(h)
@@ -112,6 +294,54 @@ func (c *Cache[K, V]) GetOrSet(k K, v V) (actual V, loaded bool, err error) {
 	return c.shards[idx].getOrSet(c, idx, h, k, v)
 }
 
+// GetOrCompute returns the existing value for the key if present.
+// Otherwise, it calls fn, stores the returned value, and returns it.
+//
+// The loaded result is true if the value was loaded, false if computed.
+// If fn returns an error, nothing is stored and the error is returned.
+//
+// Concurrent callers for the same key never compute it more than once: the
+// first caller runs fn and the others wait for it and receive the stored
+// value. If fn fails, a waiting caller computes the value itself. Callers for
+// other keys, and all other operations, are not blocked while fn runs, so fn
+// may use the cache, but it must not compute the same key with GetOrCompute
+// or [Cache.GetOrSetFunc], which would wait for itself forever.
+//
+// GetOrCompute returns an error if the cache cannot evict an existing entry
+// while full.
+func (c *Cache[K, V]) GetOrCompute(k K, fn func() (V, error)) (actual V, loaded bool, err error) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].getOrCompute(c, idx, h, k, fn)
+}
+
+// GetOrSetFunc is like [Cache.GetOrCompute] for a fn that cannot fail.
+//
+// The loaded result is true if the value was loaded, false if computed. Like
+// GetOrCompute, concurrent callers for the same key never compute it more
+// than once, and fn must not compute the same key again.
+//
+// If the computed value cannot be stored, e.g. because it exceeds the byte
+// limit set with [WithMaxBytes], GetOrSetFunc still returns it without
+// storing it.
+func (c *Cache[K, V]) GetOrSetFunc(k K, fn func() V) (actual V, loaded bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	var computed V
+	actual, loaded, err := c.shards[idx].getOrCompute(c, idx, h, k, func() (V, error) {
+		computed = fn()
+
+		return computed, nil
+	})
+	if err != nil {
+		return computed, false
+	}
+
+	return actual, loaded
+}
+
 // SetIfAbsent stores the value for a key only if the key is not already present.
 //
 // Returns true if the value was stored, false if the key already existed.
@@ -121,14 +351,85 @@ func (c *Cache[K, V]) SetIfAbsent(k K, v V) (stored bool, err error) {
 	h := c.hasher(k)
 	idx := c.shardIndexFromHash(h)
 
+	stored, _, err = c.shards[idx].setIfAbsent(c, idx, h, k, v)
+
+	return stored, err
+}
+
+// SetIfAbsentReport is like [Cache.SetIfAbsent], but also reports whether
+// storing the value evicted another entry to make room for it.
+//
+// A producer can use evicted as a back-pressure signal: it is true only if
+// the cache was full, so that the insert displaced an entry that was still
+// stored. evicted is always false if the value was not stored.
+func (c *Cache[K, V]) SetIfAbsentReport(k K, v V) (stored, evicted bool, err error) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
 	return c.shards[idx].setIfAbsent(c, idx, h, k, v)
 }
 
+// Replace stores the value for a key only if the key is already present.
+//
+// Returns true if the value was replaced, false if the key did not exist.
+// Unlike [Cache.Set], Replace never inserts a missing key, so it never
+// evicts to make room for one. Like [Cache.Set], it clears the expiration of
+// the entry. This is the complement to [Cache.SetIfAbsent].
+//
+// Replace also returns false, leaving the entry unchanged, if v alone exceeds
+// the byte limit set with [WithMaxBytes], or if it does not fit under
+// [WithStrictCapacity].
+func (c *Cache[K, V]) Replace(k K, v V) (replaced bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	_, replaced = c.shards[idx].replace(c, h, k, v)
+
+	return replaced
+}
+
+// GetAndSet stores v for k only if the key is already present, and returns
+// the previous value.
+//
+// The ok result reports whether the key was present. Unlike [Cache.Swap],
+// GetAndSet never inserts a missing key, so a mistyped key cannot create a
+// new entry. Like [Cache.Replace], it clears the expiration of the entry and
+// keeps its position under [PolicyFIFO]. The lookup and the store happen
+// under a single shard lock.
+//
+// Like [Cache.Replace], GetAndSet returns false and leaves the entry unchanged
+// if v cannot be stored.
+func (c *Cache[K, V]) GetAndSet(k K, v V) (old V, ok bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].replace(c, h, k, v)
+}
+
+// Swap stores v for k and returns the previous value, if any.
+//
+// The loaded result reports whether the key was present. Like [Cache.Set],
+// Swap clears the expiration of an existing entry and only inserts the key
+// into the eviction order if it is new. It is the storing counterpart to
+// [Cache.GetAndDelete].
+//
+// Swap returns an error if the cache cannot evict an existing entry while full.
+func (c *Cache[K, V]) Swap(k K, v V) (previous V, loaded bool, err error) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].swap(c, idx, h, k, v)
+}
+
 // Delete removes the value for the given key.
-func (c *Cache[K, V]) Delete(k K) {
+//
+// Returns true if the key was present. Every call is counted in
+// [Stats.Deletes], whether or not the key was present.
+func (c *Cache[K, V]) Delete(k K) (deleted bool) {
 	h := c.hasher(k)
 	idx := c.shardIndexFromHash(h)
-	c.shards[idx].delete(c, h, k)
+
+	return c.shards[idx].delete(c, h, k)
 }
 
 // GetAndDelete deletes the value for a key, returning the previous value if any.
@@ -141,17 +442,205 @@ func (c *Cache[K, V]) GetAndDelete(k K) (v V, loaded bool) {
 	return c.shards[idx].getAndDelete(c, h, k)
 }
 
+// CompareAndDelete deletes the entry for k from c if its value is equal to
+// old.
+//
+// Returns true if the entry was deleted. Only an actual delete is counted in
+// [Stats.Deletes].
+//
+// CompareAndDelete is a function rather than a method, so that it is only
+// available for caches of comparable values.
+func CompareAndDelete[K, V comparable](c *Cache[K, V], k K, old V) (deleted bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].compareAndDelete(c, h, k, func(v V) bool {
+		return v == old
+	})
+}
+
+// Clone returns an independent copy of the cache.
+//
+// The copy has the same capacity and options, holds the same entries and
+// preserves their eviction order. Its stats start from zero.
+//
+// Values are copied by assignment, so for reference types such as slices,
+// maps and pointers the copy shares the referenced data with c, unless c
+// copies values with [WithValueCopy]. Mutations of either cache itself, such
+// as sets, deletes and evictions, do not affect the other.
+func (c *Cache[K, V]) Clone() *Cache[K, V] {
+	clone := &Cache[K, V]{
+		maxEntries:      c.maxEntries,
+		initialCapacity: c.initialCapacity,
+		maxBytes:        c.maxBytes,
+		maxCost:         c.maxCost,
+		sizeOf:          c.sizeOf,
+		copyValue:       c.copyValue,
+		policy:          c.policy,
+		noStats:         c.noStats,
+		serveStale:      c.serveStale,
+		strict:          c.strict,
+		evictBatch:      c.evictBatch,
+		sampleRate:      c.sampleRate,
+		clock:           c.clock,
+		hasher:          c.hasher,
+	}
+	clone.initShards(len(c.stripes))
+	if c.sink != nil {
+		// The clone sends its evictions to the same channel, but counts its
+		// own drops.
+		clone.sink = &evictSink[K, V]{ch: c.sink.ch, blocking: c.sink.blocking}
+	}
+	if c.leak != nil {
+		clone.startLeakCheck(1)
+	}
+
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	for n := c.order.front(); n != nil; n = c.order.next(n) {
+		shard := &c.shards[n.shard]
+		shard.mu.Lock()
+		bucket := shard.entries[n.hash]
+		if pos := findNode(bucket, n); pos >= 0 && !c.expired(&bucket[pos]) {
+			e := bucket[pos]
+			dst := &clone.shards[n.shard]
+			cn := &node[K]{shard: n.shard, hash: n.hash, key: e.Key}
+			dst.entries[n.hash] = append(dst.entries[n.hash], entry[K, V]{Key: e.Key, Value: clone.ownValue(e.Value), node: cn, size: e.size, cost: e.cost, expireAt: e.expireAt, ttl: e.ttl, negative: e.negative})
+			dst.entryCount++
+			if e.expireAt != 0 {
+				dst.expiring++
+			}
+			clone.order.pushBack(cn)
+			clone.entryCount.Add(1)
+			clone.bytes.Add(e.size)
+			clone.cost.Add(e.cost)
+		}
+		shard.mu.Unlock()
+	}
+
+	return clone
+}
+
 // Reset removes all the items from the cache.
+//
+// Reset also stops the janitor started by [WithJanitor] and marks the cache
+// as released for [WithLeakCheck].
 func (c *Cache[K, V]) Reset() {
+	if c.leak != nil {
+		c.leak.reset.Store(true)
+	}
+	c.Stop()
+	c.clear(false)
+}
+
+// Clear removes all the items from the cache and resets its stats, like
+// [Cache.Reset], but keeps the memory allocated for the shard maps, so that
+// refilling the cache to its previous size does not grow them again. It is
+// meant for caches emptied often, e.g. between request batches.
+//
+// Unlike Reset, Clear leaves the janitor started by [WithJanitor] running
+// and does not mark the cache as released for [WithLeakCheck]. Use Reset to
+// release the memory of a cache that is no longer needed.
+func (c *Cache[K, V]) Clear() {
+	c.clear(true)
+}
+
+// Trim evicts the oldest entries until the cache holds at most target
+// entries. A negative target is treated as zero.
+//
+// Entries are evicted in eviction order, as if the cache had overflowed, and
+// are counted as evictions in [Stats]. Unlike [Cache.Reset], Trim keeps the
+// remaining entries and stats, and unlike a smaller capacity it does not
+// change [Cache.Capacity], so the cache may refill afterwards.
+func (c *Cache[K, V]) Trim(target int) {
+	target = max(target, 0)
+
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	for c.Len() > target {
+		if !c.evictOldestLocked(evictCapacity) {
+			return
+		}
+	}
+}
+
+// EvictOldest removes the oldest entry, the one that would be evicted next
+// if the cache overflowed, and returns it. Under [PolicyLRU], that is the
+// least recently used entry. It returns false if the cache is empty.
+//
+// The eviction order is cache-wide, so the entry is exactly the oldest, not
+// just the oldest of a shard. Expired and negative entries found on the way
+// are removed without being returned. Like [Cache.Trim], the entry is
+// counted as an eviction in [Stats].
+func (c *Cache[K, V]) EvictOldest() (K, V, bool) {
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	for n := c.order.front(); n != nil; n = c.order.front() {
+		c.order.remove(n)
+
+		shard := &c.shards[n.shard]
+		shard.mu.Lock()
+		bucket := shard.entries[n.hash]
+		pos := findNode(bucket, n)
+		if pos < 0 {
+			// The entry was deleted in the meantime.
+			shard.mu.Unlock()
+			c.staleNodes.Add(-1)
+
+			continue
+		}
+
+		e := bucket[pos]
+		shard.removeLocked(c, n.hash, bucket, pos)
+		if !c.expired(&e) {
+			c.addEvictedLocked(&e)
+		}
+		switch {
+		case c.expired(&e):
+			if !c.noStats {
+				shard.expirations++
+			}
+		case e.negative:
+		default:
+			if !c.noStats {
+				shard.evictions[evictCapacity]++
+			}
+			shard.mu.Unlock()
+
+			return e.Key, e.Value, true
+		}
+		shard.mu.Unlock()
+	}
+
+	var (
+		zeroK K
+		zeroV V
+	)
+
+	return zeroK, zeroV, false
+}
+
+// clear removes all the items from the cache and resets its stats. If
+// keepMaps is true, the shard maps are emptied in place and keep their
+// allocated capacity; otherwise they are replaced with new, empty maps.
+func (c *Cache[K, V]) clear(keepMaps bool) {
 	c.orderMu.Lock()
 	for i := range c.shards {
-		c.shards[i].reset()
+		c.shards[i].reset(keepMaps)
 	}
-	clear(c.order)
-	c.order = c.order[:0]
-	c.head = 0
+	c.order.reset()
+	c.staleNodes.Store(0)
 	c.entryCount.Store(0)
-	c.orderMu.Unlock()
+	c.bytes.Store(0)
+	c.cost.Store(0)
+	if c.sink != nil {
+		c.sink.drops.Store(0)
+	}
+	c.restored.Store(nil)
+	c.unlockOrder()
 }
 
 // Len returns the number of entries in the cache.
@@ -159,6 +648,20 @@ func (c *Cache[K, V]) Len() int {
 	return int(c.entryCount.Load())
 }
 
+// Capacity returns the maximum number of entries the cache can hold.
+func (c *Cache[K, V]) Capacity() int {
+	return c.maxEntries
+}
+
+// Available returns the number of entries that can be added before the cache
+// starts evicting due to its entry limit.
+//
+// The byte and cost limits set with [WithMaxBytes] and [WithMaxCost] may cause
+// evictions earlier.
+func (c *Cache[K, V]) Available() int {
+	return max(0, c.maxEntries-c.Len())
+}
+
 // All returns an iterator over all key-value pairs in the cache.
 //
 // Note: It's safe to call other cache methods during iteration,
@@ -166,7 +669,27 @@ func (c *Cache[K, V]) Len() int {
 func (c *Cache[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
 		for i := range c.shards {
-			if !c.shards[i].rangeEntries(yield) {
+			if !c.shards[i].rangeEntries(c, nil, yield) {
+				return
+			}
+		}
+	}
+}
+
+// AllFunc returns an iterator over the key-value pairs for which pred
+// returns true.
+//
+// pred is applied while the shard lock is held, so only matching entries are
+// copied out of each shard and yielded. Like [Cache.ForEach], pred must not
+// call methods of the same cache, which would deadlock. Breaking out of the
+// loop stops the iteration before the next shard is locked.
+//
+// Note: It's safe to call other cache methods in the loop body,
+// but the iteration may not reflect concurrent modifications.
+func (c *Cache[K, V]) AllFunc(pred func(K, V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range c.shards {
+			if !c.shards[i].rangeEntries(c, pred, yield) {
 				return
 			}
 		}
@@ -180,7 +703,7 @@ func (c *Cache[K, V]) All() iter.Seq2[K, V] {
 func (c *Cache[K, V]) Keys() iter.Seq[K] {
 	return func(yield func(K) bool) {
 		for i := range c.shards {
-			if !c.shards[i].rangeKeys(yield) {
+			if !c.shards[i].rangeKeys(c, yield) {
 				return
 			}
 		}
@@ -194,138 +717,615 @@ func (c *Cache[K, V]) Keys() iter.Seq[K] {
 func (c *Cache[K, V]) Values() iter.Seq[V] {
 	return func(yield func(V) bool) {
 		for i := range c.shards {
-			if !c.shards[i].rangeValues(yield) {
+			if !c.shards[i].rangeValues(c, yield) {
+				return
+			}
+		}
+	}
+}
+
+// KeysSlice returns a snapshot of all keys in the cache.
+//
+// Note: Shards are collected one at a time, so the snapshot may be slightly
+// inconsistent with concurrent writers; for example, a key may be evicted
+// from a shard that was already collected.
+func (c *Cache[K, V]) KeysSlice() []K {
+	keys := make([]K, 0, c.Len())
+	for i := range c.shards {
+		c.shards[i].rangeKeys(c, func(k K) bool {
+			keys = append(keys, k)
+
+			return true
+		})
+	}
+
+	return keys
+}
+
+// ValuesSlice returns a snapshot of all values in the cache.
+//
+// Note: Like [Cache.KeysSlice], the snapshot may be slightly inconsistent
+// with concurrent writers.
+func (c *Cache[K, V]) ValuesSlice() []V {
+	values := make([]V, 0, c.Len())
+	for i := range c.shards {
+		c.shards[i].rangeValues(c, func(v V) bool {
+			values = append(values, v)
+
+			return true
+		})
+	}
+
+	return values
+}
+
+// Entries returns a snapshot of all key-value pairs in the cache as a map.
+//
+// The map is preallocated for [Cache.Len] entries and holds a copy of every
+// entry, so it can be large; Entries is meant for interop with code that
+// expects a map, not for hot paths. Like [Cache.KeysSlice], it collects one
+// shard at a time, so the snapshot may be slightly inconsistent with
+// concurrent writers.
+func (c *Cache[K, V]) Entries() map[K]V {
+	entries := make(map[K]V, c.Len())
+	for i := range c.shards {
+		c.shards[i].rangeEntries(c, nil, func(k K, v V) bool {
+			entries[k] = v
+
+			return true
+		})
+	}
+
+	return entries
+}
+
+// ForEach calls fn for every key-value pair in the cache, shard by shard.
+//
+// ForEach stops at the first non-nil error returned by fn and returns it.
+//
+// Note: Unlike [Cache.All], ForEach holds each shard's lock while calling fn
+// for the entries of that shard. A slow fn therefore blocks writers of that
+// shard, and fn must not call methods of the same cache, which would
+// deadlock. The iteration may not reflect modifications to shards that were
+// already visited.
+func (c *Cache[K, V]) ForEach(fn func(K, V) error) error {
+	for i := range c.shards {
+		if err := c.shards[i].forEach(c, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateAction tells [Cache.UpdateEach] what to do with an entry.
+type UpdateAction uint8
+
+const (
+	// UpdateKeep leaves the entry unchanged.
+	UpdateKeep UpdateAction = iota
+
+	// UpdateStore stores the value returned with it in place of the entry's
+	// value.
+	UpdateStore
+
+	// UpdateDelete removes the entry.
+	UpdateDelete
+)
+
+// UpdateEach calls fn for every key-value pair in the cache, shard by shard,
+// and applies the returned [UpdateAction] to the entry: it keeps it, stores
+// the returned value, or deletes it.
+//
+// Each shard is locked once for all its entries, which makes bulk
+// transformations, e.g. decrementing all counters, much cheaper than a Get
+// and a Set per key. Stored values keep their position under [PolicyFIFO],
+// their expiration and their cost, are not promoted under [PolicyLRU] and
+// count as Set calls in [Stats]; deleted ones count as Delete calls. If
+// stored values grow past the byte limit, the oldest entries are evicted
+// once the shard is unlocked.
+//
+// Note: Like [Cache.ForEach], UpdateEach holds each shard's lock while
+// calling fn for all the entries of that shard, so a slow fn blocks readers
+// and writers of that shard for the whole shard, and fn must not call
+// methods of the same cache, which would deadlock.
+func (c *Cache[K, V]) UpdateEach(fn func(K, V) (V, UpdateAction)) {
+	for i := range c.shards {
+		c.shards[i].updateEach(c, fn)
+		c.enforceLimits()
+	}
+}
+
+// ShardCount returns the number of shards in the cache. Shard indexes passed
+// to [Cache.RangeShard] range from 0 to ShardCount()-1.
+func (c *Cache[K, V]) ShardCount() int {
+	return shardsCount
+}
+
+// ShardIndex returns the index of the shard that holds k.
+func (c *Cache[K, V]) ShardIndex(k K) int {
+	return c.shardIndexFromHash(c.hasher(k))
+}
+
+// RangeShard calls fn for every key-value pair in the shard at shardIdx and
+// reports whether fn returned true for all of them.
+//
+// RangeShard stops when fn returns false. Unlike [Cache.All], it locks the
+// shard exactly once and holds the lock while calling fn, so fn sees a
+// consistent view of the shard. The same caveats as for [Cache.ForEach]
+// apply: a slow fn blocks writers of that shard, and fn must not call
+// methods of the same cache, which would deadlock.
+//
+// RangeShard panics if shardIdx is not in [0, [Cache.ShardCount]).
+func (c *Cache[K, V]) RangeShard(shardIdx int, fn func(K, V) bool) bool {
+	if shardIdx < 0 || shardIdx >= shardsCount {
+		panic(fmt.Sprintf("fastcache: shard index %d out of range [0, %d)", shardIdx, shardsCount))
+	}
+
+	return c.shards[shardIdx].walk(c, fn)
+}
+
+// AllParallel calls fn for every key-value pair in the cache, splitting the
+// shards into contiguous ranges among workers goroutines.
+//
+// fn is called concurrently from several goroutines, so it must be safe for
+// concurrent use. Each worker holds the lock of the shard it visits while
+// calling fn, so the same caveats as for [Cache.RangeShard] apply. A full
+// scan therefore scales with the number of cores, unlike [Cache.All]. If
+// workers is not positive or exceeds GOMAXPROCS, GOMAXPROCS workers are used.
+// AllParallel returns after fn has been called for every pair.
+func (c *Cache[K, V]) AllParallel(workers int, fn func(K, V)) {
+	if procs := runtime.GOMAXPROCS(0); workers <= 0 || workers > procs {
+		workers = procs
+	}
+	workers = min(workers, shardsCount)
+
+	visit := func(k K, v V) bool {
+		fn(k, v)
+
+		return true
+	}
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := w * shardsCount / workers; i < (w+1)*shardsCount/workers; i++ {
+				c.shards[i].walk(c, visit)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Drain returns an iterator that removes each entry from the cache as it
+// yields it.
+//
+// Each entry is removed under its shard lock before it is yielded, so if
+// iteration stops early, or the process crashes, the entries yielded so far
+// are gone and the rest are intact. The lock is not held while yielding, so
+// it's safe to call other cache methods during iteration. Drained entries are
+// not counted as deletes in [Stats].
+//
+// Note: Entries set concurrently may be added to shards that were already
+// drained, in which case Drain does not observe them.
+func (c *Cache[K, V]) Drain() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range c.shards {
+			for {
+				k, v, ok := c.shards[i].take(c)
+				if !ok {
+					break
+				}
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// AllOrdered returns an iterator over all key-value pairs in eviction order,
+// starting with the next entry to be evicted.
+//
+// Under [PolicyFIFO] this is insertion order across all shards; under
+// [PolicyLRU] it is from least to most recently used.
+//
+// Note: Unlike [Cache.All], AllOrdered takes a snapshot of the whole cache
+// before yielding and blocks inserts while the snapshot is taken. It's safe
+// to call other cache methods during iteration, but the iteration does not
+// reflect concurrent modifications.
+func (c *Cache[K, V]) AllOrdered() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range c.orderedEntries() {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// EntryMeta is a value with the metadata of its entry, as yielded by
+// [Cache.AllWithSeq].
+type EntryMeta[V any] struct {
+	// Value is the stored value.
+	Value V
+
+	// Seq is the position of the entry in eviction order: 0 for the entry
+	// that would be evicted next, that is the oldest under [PolicyFIFO] or
+	// the least recently used under [PolicyLRU].
+	Seq uint64
+
+	// ExpiresAt is the time the entry expires, or zero if it has no TTL.
+	ExpiresAt time.Time
+}
+
+// AllWithSeq is like [Cache.AllOrdered], but yields each value with its
+// position in eviction order and its expiration, e.g. to see why entries
+// survive or get evicted.
+//
+// Seq is derived from the eviction order when the snapshot is taken, so it
+// needs no per-entry bookkeeping. The same caveats as for AllOrdered apply.
+func (c *Cache[K, V]) AllWithSeq() iter.Seq2[K, EntryMeta[V]] {
+	return func(yield func(K, EntryMeta[V]) bool) {
+		for i, e := range c.orderedEntries() {
+			meta := EntryMeta[V]{Value: e.Value, Seq: uint64(i)}
+			if e.expireAt != 0 {
+				meta.ExpiresAt = time.Unix(0, e.expireAt)
+			}
+			if !yield(e.Key, meta) {
 				return
 			}
 		}
 	}
 }
 
+func (c *Cache[K, V]) orderedEntries() []entry[K, V] {
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	entries := make([]entry[K, V], 0, c.order.len)
+	for n := c.order.front(); n != nil; n = c.order.next(n) {
+		shard := &c.shards[n.shard]
+		shard.mu.Lock()
+		bucket := shard.entries[n.hash]
+		if pos := findNode(bucket, n); pos >= 0 && c.visible(&bucket[pos]) {
+			e := &bucket[pos]
+			entries = append(entries, entry[K, V]{Key: e.Key, Value: e.Value, cost: e.cost, expireAt: e.expireAt, ttl: e.ttl})
+		}
+		shard.mu.Unlock()
+	}
+
+	return entries
+}
+
 func (c *Cache[K, V]) shardIndexFromHash(h uint64) int {
 	return int(h & shardMask)
 }
 
-func newHasher[K comparable]() func(K) uint64 {
+// lockShard locks s for an access that may promote an entry.
+//
+// Under [PolicyLRU] the eviction lock is taken first, so that hits can be
+// moved to the back of the eviction list.
+func (c *Cache[K, V]) lockShard(s *shard[K, V]) {
+	if c.policy == PolicyLRU {
+		c.orderMu.Lock()
+	}
+	s.mu.Lock()
+}
+
+func (c *Cache[K, V]) unlockShard(s *shard[K, V]) {
+	s.mu.Unlock()
+	if c.policy == PolicyLRU {
+		c.unlockOrder()
+	}
+}
+
+// touchLocked marks n as most recently used. c.orderMu must be held.
+func (c *Cache[K, V]) touchLocked(n *node[K]) {
+	if c.policy == PolicyLRU && n != nil {
+		c.order.moveToBack(n)
+	}
+}
+
+// newHasher returns the key hash function for the given seed; see
+// [WithHashSeed].
+func newHasher[K comparable](seed uint64) func(K) uint64 {
 	var zero K
-	if _, ok := any(zero).(string); ok {
+	_, isString := any(zero).(string)
+
+	switch {
+	case seed == 0 && isString:
 		return hashStringKey[K]
+	case seed == 0:
+		return rapidhash.HashComparable[K]
+	case isString:
+		return func(k K) uint64 {
+			return rapidhash.HashStringWithSeed(any(k).(string), seed)
+		}
+	default:
+		return func(k K) uint64 {
+			return rapidhash.HashComparableWithSeed(k, seed)
+		}
 	}
-
-	return rapidhash.HashComparable[K]
 }
 
 func hashStringKey[K comparable](k K) uint64 {
 	return rapidhash.HashString(any(k).(string))
 }
 
-func (c *Cache[K, V]) runInsert(op op, idx int, hash uint64, k K, v V) (result[V], error) {
+func (c *Cache[K, V]) runInsert(op op, idx int, hash uint64, k K, v V, exp expiry, cost int64) (result[V], error) {
 	c.orderMu.Lock()
-	defer c.orderMu.Unlock()
+	defer c.unlockOrder()
+
+	return c.insertLocked(op, idx, hash, k, v, exp, cost)
+}
+
+// insertLocked is runInsert for callers that already hold c.orderMu.
+func (c *Cache[K, V]) insertLocked(op op, idx int, hash uint64, k K, v V, exp expiry, cost int64) (result[V], error) {
+	c.compactOrderLocked()
 
+	size := c.entrySize(k, v)
+	if err := c.checkEntry(size, cost); err != nil {
+		return result[V]{}, err
+	}
+
+	var evicted bool
+	skipped := 0 // stale nodes unlinked, bounded by c.evictBatch
 	for {
 		shard := &c.shards[idx]
 		shard.mu.Lock()
 
-		bucket := shard.entries[hash]
-		if pos := findEntry(bucket, k); pos >= 0 {
-			result, err := c.handleExisting(op, shard, bucket, pos, v)
+		bucket, pos := shard.lookupLocked(c, hash, k)
+		if pos >= 0 {
+			result, err := c.handleExisting(op, shard, bucket, pos, v, exp, cost)
 			shard.mu.Unlock()
+			c.evictOverLimitsLocked()
 
 			return result, err
 		}
+		bucket = shard.dropNegativeLocked(c, hash, bucket, k)
 
-		if c.entryCount.Load() < int64(c.maxEntries) {
-			result, err := c.handleInsert(op, idx, hash, k, v, shard, bucket)
+		// Once the eviction batch is used up, insert even if the cache is
+		// full; later inserts continue the cleanup.
+		reason, full := c.limitReachedBy(size, cost)
+		if !full || c.evictBatch > 0 && skipped >= c.evictBatch {
+			result, err := c.handleInsert(op, idx, hash, k, v, size, exp, cost, shard, bucket)
 			shard.mu.Unlock()
+			result.evicted = evicted
 
 			return result, err
 		}
 		shard.mu.Unlock()
 
-		if !c.evictOldestLocked() {
-			return result[V]{}, fmt.Errorf("%w: entry count=%d, max entries=%d", ErrEvictionFailed, c.entryCount.Load(), c.maxEntries)
+		if c.strict {
+			return result[V]{}, c.errCacheFull()
+		}
+
+		ok, stale := c.evictOldestWithinLocked(reason, c.evictBatch-skipped)
+		skipped += stale
+		if ok {
+			evicted = true
+
+			continue
+		}
+		if c.evictBatch == 0 || skipped < c.evictBatch {
+			return result[V]{}, fmt.Errorf("%w: entry count=%d, max entries=%d, bytes=%d, max bytes=%d", ErrEvictionFailed, c.entryCount.Load(), c.maxEntries, c.bytes.Load(), c.maxBytes)
 		}
 	}
 }
 
-func (c *Cache[K, V]) handleExisting(op op, shard *shard[K, V], bucket []entry[K, V], pos int, v V) (result[V], error) {
+// limitReachedBy reports whether inserting an entry of the given size and
+// cost would exceed a limit of the cache, and which one.
+func (c *Cache[K, V]) limitReachedBy(size, cost int64) (evictReason, bool) {
+	if c.entryCount.Load() >= int64(c.maxEntries) {
+		return evictCapacity, true
+	}
+	// Compare against the remaining room rather than summing, so that limits
+	// near math.MaxInt64 cannot overflow.
+	if c.maxCost > 0 && cost > c.maxCost-c.cost.Load() {
+		return evictCost, true
+	}
+	if c.maxBytes > 0 && size > c.maxBytes-c.bytes.Load() {
+		return evictBytes, true
+	}
+
+	return 0, false
+}
+
+func (c *Cache[K, V]) handleExisting(op op, shard *shard[K, V], bucket []entry[K, V], pos int, v V, exp expiry, cost int64) (result[V], error) {
 	switch op {
 	case opSet:
-		bucket[pos].Value = v
+		if err := shard.replaceLocked(c, &bucket[pos], v, exp, cost); err != nil {
+			return result[V]{}, err
+		}
+		c.touchLocked(bucket[pos].node)
 
 		return result[V]{}, nil
+	case opSwap:
+		prev := bucket[pos].Value
+		if err := shard.replaceLocked(c, &bucket[pos], v, exp, cost); err != nil {
+			return result[V]{}, err
+		}
+		c.touchLocked(bucket[pos].node)
+
+		return result[V]{value: prev, loaded: true}, nil
 	case opGetOrSet:
-		shard.getCalls++
+		if !c.noStats {
+			shard.getCalls++
+		}
+		c.touchLocked(bucket[pos].node)
 
 		return result[V]{value: bucket[pos].Value, loaded: true}, nil
 	case opSetIfAbsent:
+		return result[V]{}, nil
+	case opSetNegative:
+		if !c.noStats {
+			shard.setCalls++
+		}
+		if err := shard.replaceLocked(c, &bucket[pos], v, exp, cost); err != nil {
+			return result[V]{}, err
+		}
+		bucket[pos].negative = true
+		c.touchLocked(bucket[pos].node)
+
 		return result[V]{}, nil
 	default:
 		return result[V]{}, fmt.Errorf("%w: %d", errUnknownOp, op)
 	}
 }
 
-func (c *Cache[K, V]) handleInsert(op op, idx int, hash uint64, k K, v V, shard *shard[K, V], bucket []entry[K, V]) (result[V], error) {
+func (c *Cache[K, V]) handleInsert(op op, idx int, hash uint64, k K, v V, size int64, exp expiry, cost int64, shard *shard[K, V], bucket []entry[K, V]) (result[V], error) {
 	var res result[V]
 
 	switch op {
-	case opSet:
+	case opSet, opSwap:
 		res = result[V]{}
 	case opGetOrSet:
-		shard.setCalls++
+		if !c.noStats {
+			shard.setCalls++
+		}
 		res = result[V]{value: v}
 	case opSetIfAbsent:
-		shard.setCalls++
+		if !c.noStats {
+			shard.setCalls++
+		}
 		res = result[V]{stored: true}
+	case opSetNegative:
+		if !c.noStats {
+			shard.setCalls++
+		}
 	default:
 		return result[V]{}, fmt.Errorf("%w: %d", errUnknownOp, op)
 	}
 
-	shard.entries[hash] = append(bucket, entry[K, V]{Key: k, Value: v})
+	n := &node[K]{shard: idx, hash: hash, key: k}
+	if op != opSetNegative {
+		v = c.ownValue(v)
+	}
+	shard.entries[hash] = append(bucket, entry[K, V]{Key: k, Value: v, node: n, size: size, cost: cost, expireAt: exp.at, ttl: exp.ttl, negative: op == opSetNegative})
 	shard.entryCount++
-	c.order = append(c.order, slot[K]{shard: idx, hash: hash, key: k})
+	if exp.at != 0 {
+		shard.expiring++
+	}
+	if len(shard.waiters) != 0 && op != opSetNegative {
+		shard.notifyLocked(k, v)
+	}
+	c.order.pushBack(n)
 	c.entryCount.Add(1)
+	c.bytes.Add(size)
+	c.cost.Add(cost)
 
 	return res, nil
 }
 
-func (c *Cache[K, V]) evictOldestLocked() bool {
-	for c.head < len(c.order) {
-		slot := c.order[c.head]
-		c.head++
+// evictReason is the limit that caused an eviction.
+type evictReason uint8
+
+const (
+	evictCapacity evictReason = iota
+	evictBytes
+	evictCost
+
+	evictReasons // number of reasons
+)
+
+// evictOldestLocked evicts the oldest entry, counting it as an eviction for
+// reason. It returns false if there is no entry to evict. c.orderMu must be
+// held.
+func (c *Cache[K, V]) evictOldestLocked(reason evictReason) bool {
+	evicted, _ := c.evictOldestWithinLocked(reason, 0)
+
+	return evicted
+}
+
+// evictOldestWithinLocked is evictOldestLocked, but if maxStale is positive
+// it gives up after unlinking maxStale nodes of deleted entries. It returns
+// the number of such nodes unlinked.
+func (c *Cache[K, V]) evictOldestWithinLocked(reason evictReason, maxStale int) (evicted bool, stale int) {
+	for n := c.order.front(); n != nil; n = c.order.front() {
+		if maxStale > 0 && stale >= maxStale {
+			break
+		}
+
+		c.order.remove(n)
 
-		shard := &c.shards[slot.shard]
+		// The node may be stale if its entry was deleted in the meantime.
+		shard := &c.shards[n.shard]
 		shard.mu.Lock()
-		bucket := shard.entries[slot.hash]
-		if pos := findEntry(bucket, slot.key); pos >= 0 {
-			bucket = deleteEntry(bucket, pos)
-			if len(bucket) == 0 {
-				delete(shard.entries, slot.hash)
-			} else {
-				shard.entries[slot.hash] = bucket
+		bucket := shard.entries[n.hash]
+		if pos := findNode(bucket, n); pos >= 0 {
+			c.addEvictedLocked(&bucket[pos])
+			shard.removeLocked(c, n.hash, bucket, pos)
+			if !c.noStats {
+				shard.evictions[reason]++
 			}
-			shard.entryCount--
-			shard.evictions++
 			shard.mu.Unlock()
-			c.entryCount.Add(-1)
-			c.compactOrderLocked()
 
-			return true
+			return true, stale
 		}
 		shard.mu.Unlock()
+		c.staleNodes.Add(-1)
+		stale++
 	}
 
-	return false
+	return false, stale
 }
 
+// compactOrderLocked unlinks the nodes of deleted entries from the eviction
+// list once they make up at least half of it.
+//
+// Deletes leave their nodes in the list, so that Delete does not need the
+// eviction lock. Without compaction a delete-heavy workload that stays below
+// capacity would grow the list without bound. c.orderMu must be held.
 func (c *Cache[K, V]) compactOrderLocked() {
-	if c.head < 1024 && c.head*2 < len(c.order) {
+	stale := c.staleNodes.Load()
+	if stale < 1024 || stale*2 < int64(c.order.len) {
 		return
 	}
 
-	remaining := len(c.order) - c.head
-	copy(c.order, c.order[c.head:])
-	clear(c.order[remaining:])
-	c.order = c.order[:remaining]
-	c.head = 0
+	c.unlinkStaleLocked()
+}
+
+// CompactNow unlinks the nodes of deleted entries from the eviction list and
+// returns how many were reclaimed.
+//
+// Deletes leave their nodes in the eviction list, which is compacted
+// automatically by the next insert once they make up half of it. CompactNow
+// lets latency-sensitive callers pay that cost at a time of their choosing,
+// e.g. during a maintenance window, and keeps evictions from skipping stale
+// nodes. It blocks inserts while it walks the list.
+func (c *Cache[K, V]) CompactNow() int {
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	if c.staleNodes.Load() == 0 {
+		return 0
+	}
+
+	return c.unlinkStaleLocked()
+}
+
+// unlinkStaleLocked unlinks the nodes of deleted entries from the eviction
+// list and returns their number. c.orderMu must be held.
+func (c *Cache[K, V]) unlinkStaleLocked() int {
+	reclaimed := 0
+	for n := c.order.front(); n != nil; {
+		next := c.order.next(n)
+
+		shard := &c.shards[n.shard]
+		shard.mu.Lock()
+		live := findNode(shard.entries[n.hash], n) >= 0
+		shard.mu.Unlock()
+
+		if !live {
+			c.order.remove(n)
+			c.staleNodes.Add(-1)
+			reclaimed++
+		}
+		n = next
+	}
+
+	return reclaimed
 }
@@ -63,6 +63,40 @@ func ExampleCache_GetOrSet() {
 	// Second call - Value: 1, Loaded: true
 }
 
+// ExampleCache_GetOrCompute demonstrates the GetOrCompute method.
+func ExampleCache_GetOrCompute() {
+	cache, err := fastcache.New[string, string](10)
+	if err != nil {
+		return
+	}
+	defer cache.Reset()
+
+	load := func() (string, error) {
+		fmt.Println("Computing value")
+
+		return "expensive", nil
+	}
+
+	// Key doesn't exist, so the value is computed and stored
+	value, loaded, err := cache.GetOrCompute("key", load)
+	if err != nil {
+		return
+	}
+	fmt.Printf("First call - Value: %s, Loaded: %t\n", value, loaded)
+
+	// Key exists, so the function is not called
+	value, loaded, err = cache.GetOrCompute("key", load)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Second call - Value: %s, Loaded: %t\n", value, loaded)
+
+	// Output:
+	// Computing value
+	// First call - Value: expensive, Loaded: false
+	// Second call - Value: expensive, Loaded: true
+}
+
 // ExampleCache_SetIfAbsent demonstrates the SetIfAbsent method.
 func ExampleCache_SetIfAbsent() {
 	cache, err := fastcache.New[string, string](10)
@@ -343,6 +377,36 @@ func ExampleNew() {
 	// Initial length: 0
 }
 
+// ExampleWithPolicy demonstrates LRU eviction.
+func ExampleWithPolicy() {
+	cache, err := fastcache.New[string, int](2, fastcache.WithPolicy(fastcache.PolicyLRU))
+	if err != nil {
+		return
+	}
+	defer cache.Reset()
+
+	if err := cache.Set("a", 1); err != nil {
+		return
+	}
+	if err := cache.Set("b", 2); err != nil {
+		return
+	}
+
+	// Reading "a" makes "b" the least recently used entry
+	cache.Get("a")
+
+	if err := cache.Set("c", 3); err != nil {
+		return
+	}
+
+	fmt.Println("Has a:", cache.Has("a"))
+	fmt.Println("Has b:", cache.Has("b"))
+
+	// Output:
+	// Has a: true
+	// Has b: false
+}
+
 // ExampleCache_Get demonstrates getting values from the cache.
 func ExampleCache_Get() {
 	cache, err := fastcache.New[string, string](10)
@@ -494,3 +558,28 @@ func ExampleCache_UpdateStats() {
 	// Current entries: 1
 	// Max entries: 10
 }
+
+// ExampleCache_Stats demonstrates taking a stats snapshot.
+func ExampleCache_Stats() {
+	cache, err := fastcache.New[string, string](10)
+	if err != nil {
+		return
+	}
+	defer cache.Reset()
+
+	if err := cache.Set("key1", "value1"); err != nil {
+		return
+	}
+	cache.Get("key1") // hit
+	cache.Get("key1") // hit
+	cache.Get("key2") // miss
+	cache.Get("key3") // miss
+
+	stats := cache.Stats()
+	fmt.Printf("Hit ratio: %.2f\n", stats.HitRatio())
+	fmt.Printf("Eviction rate: %.2f\n", stats.EvictionRate())
+
+	// Output:
+	// Hit ratio: 0.50
+	// Eviction rate: 0.00
+}
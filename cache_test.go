@@ -1,10 +1,16 @@
 package fastcache
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -123,6 +129,293 @@ func TestCacheWrap(t *testing.T) {
 	}
 }
 
+func TestCacheCapacityAvailable(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.Capacity() != 10 || c.Available() != 10 {
+		t.Fatalf("unexpected headroom of empty cache; got Capacity=%d, Available=%d; want 10, 10", c.Capacity(), c.Available())
+	}
+
+	for i := range 15 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		if want := max(0, 10-(i+1)); c.Available() != want {
+			t.Fatalf("unexpected Available after %d sets; got %d; want %d", i+1, c.Available(), want)
+		}
+	}
+	if c.Capacity() != 10 {
+		t.Fatalf("unexpected Capacity of full cache; got %d; want 10", c.Capacity())
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	c, err := New[string, string](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if s := c.Stats(); s.HitRatio() != 0 || s.EvictionRate() != 0 {
+		t.Fatalf("unexpected ratios for empty stats; got hit ratio %v, eviction rate %v", s.HitRatio(), s.EvictionRate())
+	}
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Get("c")
+	c.Get("d")
+	c.Get("c")
+	c.Get("a")
+
+	s := c.Stats()
+	if s.GetCalls != 4 || s.Hits != 3 || s.SetCalls != 4 || s.Evictions != 2 || s.EvictionsCapacity != 2 {
+		t.Fatalf("unexpected stats snapshot: %+v", s)
+	}
+	if got := s.HitRatio(); got != 0.75 {
+		t.Fatalf("unexpected hit ratio; got %v; want 0.75", got)
+	}
+	if got := s.EvictionRate(); got != 0.5 {
+		t.Fatalf("unexpected eviction rate; got %v; want 0.5", got)
+	}
+
+	// Snapshots must not accumulate across calls
+	if again := c.Stats(); again != s {
+		t.Fatalf("unexpected second snapshot; got %+v; want %+v", again, s)
+	}
+}
+
+func TestStatsSub(t *testing.T) {
+	c, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 3 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	prev := c.Stats()
+
+	c.Get(2)
+	c.Get(5)
+	if err := c.Set(3, 3); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	d := c.Stats().Sub(prev)
+	want := Stats{GetCalls: 2, SetCalls: 1, Misses: 1, Hits: 1, Evictions: 1, EvictionsCapacity: 1, EntriesCount: 2, MaxEntries: 2, TotalCost: 2, AtCapacity: true}
+	d.EvictionPressure = 0
+	if d != want {
+		t.Fatalf("unexpected delta;\ngot  %+v\nwant %+v", d, want)
+	}
+
+	// Counters that went back after a reset are clamped to zero.
+	cur := c.Stats()
+	c.Reset()
+	if err := c.Set(1, 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	d = c.Stats().Sub(cur)
+	if d.SetCalls != 0 || d.GetCalls != 0 || d.Evictions != 0 {
+		t.Fatalf("unexpected delta after reset; got %+v", d)
+	}
+	if d.EntriesCount != 1 {
+		t.Fatalf("unexpected EntriesCount; got %d; want 1", d.EntriesCount)
+	}
+}
+
+func TestStatsMarshalJSON(t *testing.T) {
+	s := Stats{GetCalls: 4, Hits: 3, Misses: 1, SetCalls: 4, Evictions: 2, EvictionsCapacity: 2, MaxEntries: 2, AtCapacity: true}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	want := map[string]any{
+		"get_calls":          4.0,
+		"hits":               3.0,
+		"misses":             1.0,
+		"set_calls":          4.0,
+		"evictions":          2.0,
+		"evictions_capacity": 2.0,
+		"max_entries":        2.0,
+		"at_capacity":        true,
+		"hit_ratio":          0.75,
+		"eviction_rate":      0.5,
+	}
+	for name, v := range want {
+		if fields[name] != v {
+			t.Fatalf("unexpected %s; got %v; want %v", name, fields[name], v)
+		}
+	}
+	if _, ok := fields["GetCalls"]; ok {
+		t.Fatalf("field names are not snake_case: %s", data)
+	}
+
+	// Derived metrics are ignored when decoding
+	var decoded Stats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if decoded != s {
+		t.Fatalf("unexpected decoded stats; got %+v; want %+v", decoded, s)
+	}
+}
+
+func TestCacheEvictionPressure(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[int, int](10, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	set := func(from, to int) {
+		t.Helper()
+
+		for i := from; i < to; i++ {
+			if err := c.Set(i, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+	}
+	check := func(atCapacity bool, pressure float64) {
+		t.Helper()
+
+		s := c.Stats()
+		if s.AtCapacity != atCapacity || s.EvictionPressure != pressure {
+			t.Fatalf("unexpected stats; got AtCapacity=%t, EvictionPressure=%v; want %t, %v", s.AtCapacity, s.EvictionPressure, atCapacity, pressure)
+		}
+	}
+
+	check(false, 0)
+	set(0, 5)
+	check(false, 0)
+	set(5, 10)
+	check(true, 0)
+
+	// Without older snapshots, the pressure covers the time since creation.
+	set(10, 20)
+	check(true, 0.5)
+
+	// Once a snapshot is old enough, only the Sets after it count.
+	clock.Advance(pressureWindow)
+	check(true, 0.5)
+	set(20, 30)
+	check(true, 20.0/30)
+	clock.Advance(pressureWindow)
+	check(true, 1)
+
+	// Overwriting existing keys does not evict.
+	set(20, 30)
+	clock.Advance(pressureWindow)
+	check(true, 0)
+
+	c.Reset()
+	check(false, 0)
+}
+
+func TestCacheShardStats(t *testing.T) {
+	c, err := New[int, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const itemsCount = 100
+	for i := range itemsCount {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		c.Get(i)
+	}
+	c.Get(-1)
+
+	stats := c.ShardStats()
+	if len(stats) != shardsCount {
+		t.Fatalf("unexpected number of shard stats; got %d; want %d", len(stats), shardsCount)
+	}
+
+	var sum ShardStat
+	for _, ss := range stats {
+		sum.EntriesCount += ss.EntriesCount
+		sum.GetCalls += ss.GetCalls
+		sum.SetCalls += ss.SetCalls
+		sum.Misses += ss.Misses
+	}
+	want := ShardStat{EntriesCount: itemsCount, GetCalls: itemsCount + 1, SetCalls: itemsCount, Misses: 1}
+	if sum != want {
+		t.Fatalf("unexpected sum of shard stats; got %+v; want %+v", sum, want)
+	}
+
+	h := c.hasher(-1)
+	if ss := stats[c.shardIndexFromHash(h)]; ss.Misses != 1 {
+		t.Fatalf("miss was not attributed to the owning shard; got %+v", ss)
+	}
+}
+
+func TestCacheLoadFactor(t *testing.T) {
+	c, err := New[int, int](shardsCount * 10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if lo, hi, avg := c.LoadFactor(); lo != 0 || hi != 0 || avg != 0 {
+		t.Fatalf("unexpected load factor of an empty cache; got (%v, %v, %v); want (0, 0, 0)", lo, hi, avg)
+	}
+
+	// Concentrate 20 keys in shard 0, twice its even share of 10.
+	for i, n := 0, 0; n < 20; i++ {
+		if c.shardIndexFromHash(c.hasher(i)) != 0 {
+			continue
+		}
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		n++
+	}
+	lo, hi, avg := c.LoadFactor()
+	if lo != 0 || hi != 2 || avg != 20.0/(shardsCount*10) {
+		t.Fatalf("unexpected load factor; got (%v, %v, %v); want (0, 2, %v)", lo, hi, avg, 20.0/(shardsCount*10))
+	}
+}
+
+func TestCacheStatsDisabled(t *testing.T) {
+	c, err := New[string, string](2, WithStatsDisabled())
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Get("a")
+	c.Get("c")
+	c.Delete("c")
+
+	want := Stats{EntriesCount: 1, MaxEntries: 2, TotalCost: 1}
+	if s := c.Stats(); s != want {
+		t.Fatalf("unexpected stats with stats disabled; got %+v; want %+v", s, want)
+	}
+}
+
 func TestCacheDel(t *testing.T) {
 	c, err := New[string, string](1024)
 	if err != nil {
@@ -140,10 +433,110 @@ func TestCacheDel(t *testing.T) {
 		if !ok || vv != v {
 			t.Fatalf("unexpected value for key %q; got %q; want %q", k, vv, v)
 		}
-		c.Delete(k)
+		if !c.Delete(k) {
+			t.Fatalf("Delete reported a missing entry for key %q", k)
+		}
 		if _, ok := c.Get(k); ok {
 			t.Fatalf("unexpected value found for deleted key %q", k)
 		}
+		if c.Delete(k) {
+			t.Fatalf("Delete reported a deleted entry for missing key %q", k)
+		}
+	}
+
+	if s := c.Stats(); s.Deletes != 200 {
+		t.Fatalf("unexpected Deletes; got %d; want 200", s.Deletes)
+	}
+}
+
+func TestCacheGetOr(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	if v := c.GetOr("a", 42); v != 1 {
+		t.Fatalf("unexpected value for a stored key; got %d; want 1", v)
+	}
+	if v := c.GetOr("b", 42); v != 42 {
+		t.Fatalf("unexpected value for a missing key; got %d; want 42", v)
+	}
+
+	s := c.Stats()
+	if s.GetCalls != 2 || s.Misses != 1 {
+		t.Fatalf("unexpected stats; got GetCalls=%d, Misses=%d; want 2, 1", s.GetCalls, s.Misses)
+	}
+	if _, ok := c.Peek("b"); ok {
+		t.Fatal("GetOr stored the default value")
+	}
+}
+
+func TestCacheHasStatsNeutral(t *testing.T) {
+	c, err := New[int, int](2, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 2 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if !c.Has(0) || c.Has(5) {
+		t.Fatal("unexpected Has result")
+	}
+	if s := c.Stats(); s.GetCalls != 0 || s.Misses != 0 {
+		t.Fatalf("Has changed stats; got GetCalls=%d, Misses=%d; want 0, 0", s.GetCalls, s.Misses)
+	}
+
+	// Has does not promote key 0, so it is still evicted first.
+	if err := c.Set(2, 2); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if _, ok := c.Peek(0); ok {
+		t.Fatal("Has promoted the key under LRU")
+	}
+}
+
+func TestCachePeek(t *testing.T) {
+	c, err := New[string, string](2, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if v, ok := c.Peek("a"); !ok || v != "a" {
+		t.Fatalf("unexpected Peek result; got (%q, %t); want (%q, true)", v, ok, "a")
+	}
+	if _, ok := c.Peek("missing"); ok {
+		t.Fatal("Peek found a non-existent key")
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.GetCalls != 0 || s.Misses != 0 || s.Hits != 0 {
+		t.Fatalf("Peek changed stats: %+v", s)
+	}
+
+	// Peek must not promote "a" under LRU
+	if err := c.Set("c", "c"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if _, ok := c.Peek("a"); ok {
+		t.Fatal("Peek promoted key a under LRU")
 	}
 }
 
@@ -197,88 +590,436 @@ func TestCacheGetOrSet(t *testing.T) {
 	}
 }
 
-func TestCacheGetAndDelete(t *testing.T) {
+func TestCacheGetOrCompute(t *testing.T) {
 	c, err := New[string, string](100)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
 	defer c.Reset()
 
-	// GetAndDelete on non-existent key
-	v, loaded := c.GetAndDelete("nokey")
+	// Failing compute must not store anything
+	errCompute := errors.New("compute failed")
+	_, loaded, err := c.GetOrCompute("key1", func() (string, error) {
+		return "", errCompute
+	})
+	if !errors.Is(err, errCompute) {
+		t.Fatalf("GetOrCompute returned error %v; want %v", err, errCompute)
+	}
 	if loaded {
-		t.Fatal("expected loaded=false for non-existent key")
+		t.Fatal("expected loaded=false on compute error")
 	}
-	if v != "" {
-		t.Fatalf("expected zero value; got %q", v)
+	if c.Has("key1") {
+		t.Fatal("GetOrCompute stored a value despite compute error")
 	}
 
-	// Set a key, then GetAndDelete
-	if err := c.Set("key1", "value1"); err != nil {
-		t.Fatalf("Set error: %s", err)
-	}
-	v, loaded = c.GetAndDelete("key1")
-	if !loaded {
-		t.Fatal("expected loaded=true for existing key")
+	actual, loaded, err := c.GetOrCompute("key1", func() (string, error) {
+		return "value1", nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrCompute error: %s", err)
 	}
-	if v != "value1" {
-		t.Fatalf("unexpected value; got %q; want %q", v, "value1")
+	if loaded || actual != "value1" {
+		t.Fatalf("GetOrCompute returned (%q, %t); want (%q, false)", actual, loaded, "value1")
 	}
 
-	// Verify key is gone
-	if _, ok := c.Get("key1"); ok {
-		t.Fatal("key should be deleted after GetAndDelete")
-	}
+	actual, loaded, err = c.GetOrCompute("key1", func() (string, error) {
+		t.Fatal("compute called for existing key")
 
-	// GetAndDelete again should return loaded=false
-	_, loaded = c.GetAndDelete("key1")
-	if loaded {
-		t.Fatal("expected loaded=false after key was deleted")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatalf("GetOrCompute error: %s", err)
+	}
+	if !loaded || actual != "value1" {
+		t.Fatalf("GetOrCompute returned (%q, %t); want (%q, true)", actual, loaded, "value1")
 	}
 }
 
-func TestCacheSetIfAbsent(t *testing.T) {
-	c, err := New[string, string](100)
+func TestCacheGetOrComputeConcurrent(t *testing.T) {
+	c, err := New[string, int](100)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
 	defer c.Reset()
 
-	// First SetIfAbsent should succeed
-	stored, err := c.SetIfAbsent("key1", "value1")
-	if err != nil {
-		t.Fatalf("SetIfAbsent error: %s", err)
-	}
-	if !stored {
-		t.Fatal("expected stored=true for new key")
+	const workers = 16
+
+	var (
+		calls atomic.Int64
+		wg    sync.WaitGroup
+	)
+	results := make([]int, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, err := c.GetOrCompute("key", func() (int, error) {
+				time.Sleep(10 * time.Millisecond)
+
+				return int(calls.Add(1)), nil
+			})
+			if err != nil {
+				t.Errorf("GetOrCompute error: %s", err)
+			}
+			results[i] = v
+		}()
 	}
+	wg.Wait()
 
-	// Verify the value was stored
-	v, ok := c.Get("key1")
-	if !ok || v != "value1" {
-		t.Fatalf("unexpected value; got %q; want %q", v, "value1")
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("unexpected number of compute calls; got %d; want 1", n)
 	}
+	for i, v := range results {
+		if v != 1 {
+			t.Fatalf("unexpected value for worker %d; got %d; want 1", i, v)
+		}
+	}
+}
 
-	// Second SetIfAbsent should fail (key exists)
-	stored, err = c.SetIfAbsent("key1", "value2")
+func TestCacheGetOrComputeNested(t *testing.T) {
+	c, err := New[int, int](100)
 	if err != nil {
-		t.Fatalf("SetIfAbsent error: %s", err)
+		t.Fatalf("New error: %s", err)
 	}
-	if stored {
-		t.Fatal("expected stored=false for existing key")
+	defer c.Reset()
+
+	// fn may compute another key of the same shard.
+	other := 1
+	for c.ShardIndex(other) != c.ShardIndex(0) {
+		other++
 	}
 
-	// Value should remain unchanged
-	v, ok = c.Get("key1")
-	if !ok || v != "value1" {
-		t.Fatalf("value was overwritten; got %q; want %q", v, "value1")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, _, err := c.GetOrCompute(0, func() (int, error) {
+			v, _, err := c.GetOrCompute(other, func() (int, error) {
+				return 2, nil
+			})
+
+			return v + 1, err
+		})
+		if err != nil {
+			t.Errorf("GetOrCompute error: %s", err)
+		}
+		if v != 3 {
+			t.Errorf("unexpected value; got %d; want 3", v)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("nested GetOrCompute for a key of the same shard deadlocked")
 	}
+}
 
-	// After delete, SetIfAbsent should succeed again
-	c.Delete("key1")
-	stored, err = c.SetIfAbsent("key1", "value3")
+func TestCacheGetOrSetFunc(t *testing.T) {
+	c, err := New[string, []byte](100, WithMaxBytes(10), WithSizeOf(valueLen))
 	if err != nil {
-		t.Fatalf("SetIfAbsent error: %s", err)
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	actual, loaded := c.GetOrSetFunc("key", func() []byte {
+		return []byte("value")
+	})
+	if loaded || string(actual) != "value" {
+		t.Fatalf("GetOrSetFunc returned (%q, %t); want (%q, false)", actual, loaded, "value")
+	}
+
+	actual, loaded = c.GetOrSetFunc("key", func() []byte {
+		t.Fatal("fn called for existing key")
+
+		return nil
+	})
+	if !loaded || string(actual) != "value" {
+		t.Fatalf("GetOrSetFunc returned (%q, %t); want (%q, true)", actual, loaded, "value")
+	}
+
+	// A value that cannot be stored is still returned
+	actual, loaded = c.GetOrSetFunc("huge", func() []byte {
+		return []byte("too large value")
+	})
+	if loaded || string(actual) != "too large value" {
+		t.Fatalf("GetOrSetFunc returned (%q, %t); want (%q, false)", actual, loaded, "too large value")
+	}
+	if c.Has("huge") {
+		t.Fatal("GetOrSetFunc stored a value larger than maxBytes")
+	}
+}
+
+func TestCacheGetOrSetFuncConcurrent(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const workers = 16
+
+	var (
+		calls atomic.Int64
+		wg    sync.WaitGroup
+	)
+	results := make([]int, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.GetOrSetFunc("key", func() int {
+				time.Sleep(10 * time.Millisecond)
+
+				return int(calls.Add(1))
+			})
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("unexpected number of fn calls; got %d; want 1", n)
+	}
+	for i, v := range results {
+		if v != 1 {
+			t.Fatalf("unexpected value for worker %d; got %d; want 1", i, v)
+		}
+	}
+}
+
+func TestCacheGetAndDelete(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// GetAndDelete on non-existent key
+	v, loaded := c.GetAndDelete("nokey")
+	if loaded {
+		t.Fatal("expected loaded=false for non-existent key")
+	}
+	if v != "" {
+		t.Fatalf("expected zero value; got %q", v)
+	}
+
+	// Set a key, then GetAndDelete
+	if err := c.Set("key1", "value1"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	v, loaded = c.GetAndDelete("key1")
+	if !loaded {
+		t.Fatal("expected loaded=true for existing key")
+	}
+	if v != "value1" {
+		t.Fatalf("unexpected value; got %q; want %q", v, "value1")
+	}
+
+	// Verify key is gone
+	if _, ok := c.Get("key1"); ok {
+		t.Fatal("key should be deleted after GetAndDelete")
+	}
+
+	// GetAndDelete again should return loaded=false
+	_, loaded = c.GetAndDelete("key1")
+	if loaded {
+		t.Fatal("expected loaded=false after key was deleted")
+	}
+}
+
+func TestCacheReplace(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.Replace("key1", "value1") {
+		t.Fatal("Replace reported success for a non-existent key")
+	}
+	if c.Has("key1") || c.Len() != 0 {
+		t.Fatal("Replace inserted a non-existent key")
+	}
+
+	if err := c.Set("key1", "value1"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if !c.Replace("key1", "value2") {
+		t.Fatal("Replace reported failure for an existing key")
+	}
+	if v, ok := c.Get("key1"); !ok || v != "value2" {
+		t.Fatalf("unexpected value after Replace; got (%q, %t); want (%q, true)", v, ok, "value2")
+	}
+
+	// Only actual replacements count as Set calls
+	if s := c.Stats(); s.SetCalls != 2 {
+		t.Fatalf("unexpected SetCalls; got %d; want 2", s.SetCalls)
+	}
+}
+
+func TestCacheGetAndSet(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if old, ok := c.GetAndSet("key1", "value1"); ok || old != "" {
+		t.Fatalf("unexpected GetAndSet result for missing key; got (%q, %t); want (%q, false)", old, ok, "")
+	}
+	if c.Has("key1") || c.Len() != 0 {
+		t.Fatal("GetAndSet inserted a missing key")
+	}
+
+	if err := c.SetWithTTL("key1", "value1", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if old, ok := c.GetAndSet("key1", "value2"); !ok || old != "value1" {
+		t.Fatalf("unexpected GetAndSet result for existing key; got (%q, %t); want (%q, true)", old, ok, "value1")
+	}
+	v, expiresAt, ok := c.GetWithExpiry("key1")
+	if !ok || v != "value2" {
+		t.Fatalf("unexpected value after GetAndSet; got (%q, %t); want (%q, true)", v, ok, "value2")
+	}
+	if !expiresAt.IsZero() {
+		t.Fatalf("GetAndSet kept the expiration; got %s; want zero", expiresAt)
+	}
+
+	// Expired keys count as missing
+	if err := c.SetWithTTL("key2", "value1", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	expireKey(c, "key2")
+	if _, ok := c.GetAndSet("key2", "value2"); ok {
+		t.Fatal("GetAndSet reported success for an expired key")
+	}
+	if c.Has("key2") {
+		t.Fatal("GetAndSet stored a value for an expired key")
+	}
+}
+
+func TestCacheSwap(t *testing.T) {
+	c, err := New[string, string](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Swap on non-existent key inserts it
+	prev, loaded, err := c.Swap("key1", "value1")
+	if err != nil {
+		t.Fatalf("Swap error: %s", err)
+	}
+	if loaded || prev != "" {
+		t.Fatalf("unexpected Swap result for new key; got (%q, %t); want (%q, false)", prev, loaded, "")
+	}
+	if v, ok := c.Get("key1"); !ok || v != "value1" {
+		t.Fatalf("unexpected value after Swap; got (%q, %t); want (%q, true)", v, ok, "value1")
+	}
+
+	if err := c.Set("key2", "value2"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	// Swap on existing key returns the previous value and keeps its position
+	prev, loaded, err = c.Swap("key1", "value1b")
+	if err != nil {
+		t.Fatalf("Swap error: %s", err)
+	}
+	if !loaded || prev != "value1" {
+		t.Fatalf("unexpected Swap result for existing key; got (%q, %t); want (%q, true)", prev, loaded, "value1")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("unexpected len after Swap; got %d; want 2", c.Len())
+	}
+
+	if _, _, err := c.Swap("key3", "value3"); err != nil {
+		t.Fatalf("Swap error: %s", err)
+	}
+	if c.Has("key1") {
+		t.Fatal("key1 should have been evicted first under FIFO")
+	}
+	if v, ok := c.Get("key3"); !ok || v != "value3" {
+		t.Fatalf("unexpected value after Swap; got (%q, %t); want (%q, true)", v, ok, "value3")
+	}
+}
+
+func TestCacheCompareAndDelete(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if CompareAndDelete(c, "key1", "value1") {
+		t.Fatal("CompareAndDelete deleted a non-existent key")
+	}
+
+	if err := c.Set("key1", "value1"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if CompareAndDelete(c, "key1", "stale") {
+		t.Fatal("CompareAndDelete deleted a key holding a different value")
+	}
+	if v, ok := c.Get("key1"); !ok || v != "value1" {
+		t.Fatalf("unexpected value after failed CompareAndDelete; got (%q, %t); want (%q, true)", v, ok, "value1")
+	}
+
+	if !CompareAndDelete(c, "key1", "value1") {
+		t.Fatal("CompareAndDelete did not delete a key holding the expected value")
+	}
+	if c.Has("key1") {
+		t.Fatal("key still present after CompareAndDelete")
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.Deletes != 1 {
+		t.Fatalf("unexpected Deletes; got %d; want 1", s.Deletes)
+	}
+}
+
+func TestCacheSetIfAbsent(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// First SetIfAbsent should succeed
+	stored, err := c.SetIfAbsent("key1", "value1")
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	if !stored {
+		t.Fatal("expected stored=true for new key")
+	}
+
+	// Verify the value was stored
+	v, ok := c.Get("key1")
+	if !ok || v != "value1" {
+		t.Fatalf("unexpected value; got %q; want %q", v, "value1")
+	}
+
+	// Second SetIfAbsent should fail (key exists)
+	stored, err = c.SetIfAbsent("key1", "value2")
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	if stored {
+		t.Fatal("expected stored=false for existing key")
+	}
+
+	// Value should remain unchanged
+	v, ok = c.Get("key1")
+	if !ok || v != "value1" {
+		t.Fatalf("value was overwritten; got %q; want %q", v, "value1")
+	}
+
+	// After delete, SetIfAbsent should succeed again
+	c.Delete("key1")
+	stored, err = c.SetIfAbsent("key1", "value3")
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
 	}
 	if !stored {
 		t.Fatal("expected stored=true after key was deleted")
@@ -290,6 +1031,54 @@ func TestCacheSetIfAbsent(t *testing.T) {
 	}
 }
 
+func TestCacheSetIfAbsentReport(t *testing.T) {
+	c, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 2 {
+		stored, evicted, err := c.SetIfAbsentReport(i, i)
+		if err != nil {
+			t.Fatalf("SetIfAbsentReport error: %s", err)
+		}
+		if !stored || evicted {
+			t.Fatalf("unexpected result below capacity; got (%t, %t); want (true, false)", stored, evicted)
+		}
+	}
+
+	// An existing key is neither stored nor evicts anything.
+	stored, evicted, err := c.SetIfAbsentReport(1, 10)
+	if err != nil {
+		t.Fatalf("SetIfAbsentReport error: %s", err)
+	}
+	if stored || evicted {
+		t.Fatalf("unexpected result for an existing key; got (%t, %t); want (false, false)", stored, evicted)
+	}
+
+	stored, evicted, err = c.SetIfAbsentReport(2, 2)
+	if err != nil {
+		t.Fatalf("SetIfAbsentReport error: %s", err)
+	}
+	if !stored || !evicted {
+		t.Fatalf("unexpected result for a full cache; got (%t, %t); want (true, true)", stored, evicted)
+	}
+	if c.Has(0) {
+		t.Fatal("oldest key 0 was not evicted")
+	}
+
+	// A deleted key leaves room, so the next insert does not evict.
+	c.Delete(1)
+	stored, evicted, err = c.SetIfAbsentReport(3, 3)
+	if err != nil {
+		t.Fatalf("SetIfAbsentReport error: %s", err)
+	}
+	if !stored || evicted {
+		t.Fatalf("unexpected result after a delete; got (%t, %t); want (true, false)", stored, evicted)
+	}
+}
+
 func TestCacheSetGetSerial(t *testing.T) {
 	itemsCount := 10000
 	c, err := New[string, string](itemsCount * 2)
@@ -360,6 +1149,44 @@ func testCacheGetSet(c *Cache[string, string], itemsCount int) error {
 	return nil
 }
 
+func TestCacheClear(t *testing.T) {
+	c, err := New[int, int](10000, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for round := range 3 {
+		for i := range 5000 {
+			if err := c.Set(i, i+round); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+		if v, ok := c.Get(42); !ok || v != 42+round {
+			t.Fatalf("unexpected value in round %d; got (%d, %t); want (%d, true)", round, v, ok, 42+round)
+		}
+
+		c.Clear()
+		if n := c.Len(); n != 0 {
+			t.Fatalf("unexpected len after Clear; got %d; want 0", n)
+		}
+		if s := c.Stats(); s.SetCalls != 0 || s.GetCalls != 0 {
+			t.Fatalf("unexpected stats after Clear; got SetCalls=%d, GetCalls=%d; want 0, 0", s.SetCalls, s.GetCalls)
+		}
+		if _, ok := c.Peek(42); ok {
+			t.Fatal("entry found after Clear")
+		}
+		if k, _, ok := c.EvictOldest(); ok {
+			t.Fatalf("eviction order not emptied by Clear; got key %d", k)
+		}
+	}
+
+	// Clearing reuses the shard maps instead of allocating new ones.
+	if allocs := testing.AllocsPerRun(10, c.Clear); allocs != 0 {
+		t.Fatalf("unexpected allocations by Clear; got %v; want 0", allocs)
+	}
+}
+
 func TestCacheResetUpdateStatsSetConcurrent(t *testing.T) {
 	c, err := New[string, string](12334)
 	if err != nil {
@@ -430,45 +1257,61 @@ func TestCacheResetUpdateStatsSetConcurrent(t *testing.T) {
 	resettersWG.Wait()
 }
 
-func TestCacheRange(t *testing.T) {
-	c, err := New[string, string](100)
+func TestCacheResetUpdateStatsGetConcurrent(t *testing.T) {
+	c, err := New[string, string](1000)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
 	defer c.Reset()
 
-	// Add entries
-	for i := range 50 {
-		if err := c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
-			t.Fatalf("Set error: %s", err)
-		}
-	}
-
-	// Count entries via All
-	count := 0
-	for range c.All() {
-		count++
-	}
-
-	if count != 50 {
-		t.Fatalf("unexpected count from All; got %d; want 50", count)
-	}
+	stopCh := make(chan struct{})
+	var wg sync.WaitGroup
 
-	// Test early exit
-	count = 0
-	for range c.All() {
-		count++
-		if count >= 10 {
-			break
-		}
+	// run workers for cache reset and reads with both hits and misses
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stopCh:
+					return
+				default:
+					c.Reset()
+					runtime.Gosched()
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stopCh:
+					return
+				default:
+					key := fmt.Sprintf("key_%d", j%200)
+					if j%2 == 0 {
+						_ = c.Set(key, "value")
+					}
+					c.Get(key)
+				}
+			}
+		}()
 	}
 
-	if count != 10 {
-		t.Fatalf("unexpected count with early exit; got %d; want 10", count)
+	for range 2000 {
+		s := c.Stats()
+		if s.Misses > s.GetCalls || s.Hits > s.GetCalls {
+			close(stopCh)
+			wg.Wait()
+			t.Fatalf("inconsistent stats; GetCalls=%d, Misses=%d, Hits=%d", s.GetCalls, s.Misses, s.Hits)
+		}
 	}
+	close(stopCh)
+	wg.Wait()
 }
 
-func TestCacheKeys(t *testing.T) {
+func TestCacheRange(t *testing.T) {
 	c, err := New[string, string](100)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
@@ -482,26 +1325,19 @@ func TestCacheKeys(t *testing.T) {
 		}
 	}
 
-	// Collect all keys
-	keys := make(map[string]bool)
-	for k := range c.Keys() {
-		keys[k] = true
-	}
-
-	if len(keys) != 50 {
-		t.Fatalf("unexpected key count; got %d; want 50", len(keys))
+	// Count entries via All
+	count := 0
+	for range c.All() {
+		count++
 	}
 
-	// Verify expected keys exist
-	for i := range 50 {
-		if !keys[fmt.Sprintf("key%d", i)] {
-			t.Fatalf("missing key: key%d", i)
-		}
+	if count != 50 {
+		t.Fatalf("unexpected count from All; got %d; want 50", count)
 	}
 
 	// Test early exit
-	count := 0
-	for range c.Keys() {
+	count = 0
+	for range c.All() {
 		count++
 		if count >= 10 {
 			break
@@ -513,226 +1349,1680 @@ func TestCacheKeys(t *testing.T) {
 	}
 }
 
-func TestCacheValues(t *testing.T) {
-	c, err := New[string, string](100)
+func TestCacheAllWithSeq(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[int, int](100, WithClock(clock))
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
 	defer c.Reset()
 
-	// Add entries
-	for i := range 50 {
-		if err := c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+	for i := range 5 {
+		if err := c.Set(i, i*10); err != nil {
 			t.Fatalf("Set error: %s", err)
 		}
 	}
+	if err := c.SetWithTTL(5, 50, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	c.Delete(2)
 
-	// Collect all values
-	values := make(map[string]bool)
-	for v := range c.Values() {
-		values[v] = true
+	var keys []int
+	for k, meta := range c.AllWithSeq() {
+		if meta.Seq != uint64(len(keys)) {
+			t.Fatalf("unexpected seq for key %d; got %d; want %d", k, meta.Seq, len(keys))
+		}
+		if meta.Value != k*10 {
+			t.Fatalf("unexpected value for key %d; got %d; want %d", k, meta.Value, k*10)
+		}
+		wantExp := time.Time{}
+		if k == 5 {
+			wantExp = time.Unix(1060, 0)
+		}
+		if !meta.ExpiresAt.Equal(wantExp) {
+			t.Fatalf("unexpected expiry for key %d; got %s; want %s", k, meta.ExpiresAt, wantExp)
+		}
+		keys = append(keys, k)
+	}
+	if want := []int{0, 1, 3, 4, 5}; !slices.Equal(keys, want) {
+		t.Fatalf("unexpected keys in eviction order; got %v; want %v", keys, want)
 	}
+}
 
-	if len(values) != 50 {
-		t.Fatalf("unexpected value count; got %d; want 50", len(values))
+func TestCacheAllFunc(t *testing.T) {
+	c, err := New[int, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
 	}
+	defer c.Reset()
 
-	// Verify expected values exist
-	for i := range 50 {
-		if !values[fmt.Sprintf("value%d", i)] {
-			t.Fatalf("missing value: value%d", i)
+	for i := range 100 {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.SetWithTTL(1000, 0, time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	expireKey(c, 1000)
+
+	even := func(k, _ int) bool { return k%2 == 0 }
+
+	seen := make(map[int]int)
+	for k, v := range c.AllFunc(even) {
+		seen[k] = v
+	}
+	if len(seen) != 50 {
+		t.Fatalf("unexpected count from AllFunc; got %d; want 50", len(seen))
+	}
+	for k, v := range seen {
+		if k%2 != 0 || v != k*10 {
+			t.Fatalf("unexpected entry from AllFunc; got (%d, %d)", k, v)
 		}
 	}
 
 	// Test early exit
 	count := 0
-	for range c.Values() {
+	for range c.AllFunc(even) {
 		count++
 		if count >= 10 {
 			break
 		}
 	}
-
 	if count != 10 {
 		t.Fatalf("unexpected count with early exit; got %d; want 10", count)
 	}
+
+	for k := range c.AllFunc(func(int, int) bool { return false }) {
+		t.Fatalf("AllFunc yielded key %d for a predicate matching nothing", k)
+	}
 }
 
-func TestCacheLen(t *testing.T) {
-	c, err := New[string, string](100)
+func TestCacheKeysValuesSlice(t *testing.T) {
+	c, err := New[int, int](100)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
 	defer c.Reset()
 
-	if c.Len() != 0 {
-		t.Fatalf("unexpected len for empty cache; got %d; want 0", c.Len())
+	if keys := c.KeysSlice(); len(keys) != 0 {
+		t.Fatalf("unexpected keys in empty cache; got %v", keys)
 	}
 
 	for i := range 50 {
-		if err := c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+		if err := c.Set(i, i*10); err != nil {
 			t.Fatalf("Set error: %s", err)
 		}
 	}
 
-	if c.Len() != 50 {
-		t.Fatalf("unexpected len; got %d; want 50", c.Len())
+	keys := c.KeysSlice()
+	slices.Sort(keys)
+	for i, k := range keys {
+		if k != i {
+			t.Fatalf("unexpected key at %d; got %d; want %d", i, k, i)
+		}
 	}
-
-	c.Reset()
-	if c.Len() != 0 {
-		t.Fatalf("unexpected len after reset; got %d; want 0", c.Len())
+	if len(keys) != 50 {
+		t.Fatalf("unexpected number of keys; got %d; want 50", len(keys))
 	}
-}
 
-func TestCacheStruct(t *testing.T) {
-	type User struct {
-		ID   int
-		Name string
+	values := c.ValuesSlice()
+	slices.Sort(values)
+	for i, v := range values {
+		if v != i*10 {
+			t.Fatalf("unexpected value at %d; got %d; want %d", i, v, i*10)
+		}
+	}
+	if len(values) != 50 {
+		t.Fatalf("unexpected number of values; got %d; want 50", len(values))
 	}
+}
 
-	c, err := New[int, User](100)
+func TestCacheEntries(t *testing.T) {
+	c, err := New[int, int](100)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
 	defer c.Reset()
 
-	u := User{ID: 1, Name: "Alice"}
-	if err := c.Set(1, u); err != nil {
-		t.Fatalf("Set error: %s", err)
+	if entries := c.Entries(); len(entries) != 0 {
+		t.Fatalf("unexpected entries for empty cache; got %v", entries)
 	}
 
-	got, ok := c.Get(1)
-	if !ok {
-		t.Fatal("user not found")
+	want := make(map[int]int)
+	for i := range 50 {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		want[i] = i * 10
 	}
-	if got.ID != u.ID || got.Name != u.Name {
-		t.Fatalf("unexpected user; got %+v; want %+v", got, u)
+	if err := c.SetWithTTL(100, 1, time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
 	}
-}
+	expireKey(c, 100)
 
-func TestCacheHandlesForcedShardCollisions(t *testing.T) {
-	const maxEntries = 8
+	entries := c.Entries()
+	if !maps.Equal(entries, want) {
+		t.Fatalf("unexpected entries; got %v; want %v", entries, want)
+	}
 
-	c, err := New[string, string](maxEntries)
+	// The snapshot is detached from the cache
+	entries[0] = -1
+	if v, _ := c.Get(0); v != 0 {
+		t.Fatalf("modifying the snapshot changed the cache; got %d; want 0", v)
+	}
+}
+
+func TestCacheForEach(t *testing.T) {
+	c, err := New[int, int](100)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
-	c.hasher = func(string) uint64 { return 1 }
 	defer c.Reset()
 
-	for i := range maxEntries * 2 {
-		key := fmt.Sprintf("key-%d", i)
-		value := fmt.Sprintf("value-%d", i)
-		if err := c.Set(key, value); err != nil {
+	for i := range 50 {
+		if err := c.Set(i, i*10); err != nil {
 			t.Fatalf("Set error: %s", err)
 		}
 	}
 
-	if c.Len() != maxEntries {
-		t.Fatalf("unexpected len after forced collisions; got %d; want %d", c.Len(), maxEntries)
-	}
+	seen := make(map[int]int)
+	err = c.ForEach(func(k, v int) error {
+		seen[k] = v
 
-	for i := range maxEntries {
-		key := fmt.Sprintf("key-%d", i)
-		if _, ok := c.Get(key); ok {
-			t.Fatalf("unexpected stale key after fifo eviction under forced collisions: %q", key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach error: %s", err)
+	}
+	if len(seen) != 50 {
+		t.Fatalf("unexpected count from ForEach; got %d; want 50", len(seen))
+	}
+	for k, v := range seen {
+		if v != k*10 {
+			t.Fatalf("unexpected value for key %d; got %d; want %d", k, v, k*10)
 		}
 	}
 
-	for i := range maxEntries {
-		idx := i + maxEntries
-		key := fmt.Sprintf("key-%d", idx)
-		want := fmt.Sprintf("value-%d", idx)
-		got, ok := c.Get(key)
-		if !ok || got != want {
-			t.Fatalf("unexpected value for forced-collision key %q; got (%q, %t); want (%q, true)", key, got, ok, want)
+	// Stop at the first error
+	errStop := errors.New("stop")
+	count := 0
+	err = c.ForEach(func(int, int) error {
+		count++
+		if count == 10 {
+			return errStop
 		}
-	}
 
-	stored, err := c.SetIfAbsent("key-12", "newer")
-	if err != nil {
-		t.Fatalf("SetIfAbsent error: %s", err)
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("ForEach returned error %v; want %v", err, errStop)
 	}
-	if stored {
-		t.Fatal("SetIfAbsent unexpectedly overwrote an existing forced-collision key")
+	if count != 10 {
+		t.Fatalf("unexpected count with early error; got %d; want 10", count)
 	}
+}
 
-	actual, loaded, err := c.GetOrSet("key-12", "newer")
+func TestCacheUpdateEach(t *testing.T) {
+	c, err := New[int, int](1000)
 	if err != nil {
-		t.Fatalf("GetOrSet error: %s", err)
+		t.Fatalf("New error: %s", err)
 	}
-	if !loaded || actual != "value-12" {
-		t.Fatalf("GetOrSet returned (%q, %t); want (%q, true)", actual, loaded, "value-12")
+	defer c.Reset()
+
+	for i := range 100 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
 	}
+	if err := c.SetWithTTL(105, 105, time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	before := c.Stats()
+
+	// Decrement every counter, dropping those that reach zero, and keep the
+	// multiples of ten as they are.
+	calls := 0
+	c.UpdateEach(func(k, v int) (int, UpdateAction) {
+		calls++
+		switch {
+		case k%10 == 0 && k != 0:
+			return 0, UpdateKeep
+		case v <= 1:
+			return 0, UpdateDelete
+		default:
+			return v - 1, UpdateStore
+		}
+	})
 
-	deleted, ok := c.GetAndDelete("key-12")
-	if !ok || deleted != "value-12" {
-		t.Fatalf("GetAndDelete returned (%q, %t); want (%q, true)", deleted, ok, "value-12")
+	if calls != 101 {
+		t.Fatalf("unexpected fn calls; got %d; want 101", calls)
 	}
-	if _, ok := c.Get("key-12"); ok {
-		t.Fatal("forced-collision key still present after GetAndDelete")
+	if c.Len() != 99 {
+		t.Fatalf("unexpected len; got %d; want 99", c.Len())
+	}
+	for _, k := range []int{0, 1} {
+		if c.Has(k) {
+			t.Fatalf("key %d should have been deleted", k)
+		}
+	}
+	for k := 2; k < 100; k++ {
+		want := k - 1
+		if k%10 == 0 {
+			want = k
+		}
+		if v, ok := c.Get(k); !ok || v != want {
+			t.Fatalf("unexpected value for key %d; got (%d, %t); want (%d, true)", k, v, ok, want)
+		}
+	}
+	if v, exp, ok := c.GetWithExpiry(105); !ok || v != 104 || exp.IsZero() {
+		t.Fatal("UpdateEach dropped the expiration of an updated entry")
 	}
 
-	stored, err = c.SetIfAbsent("key-12", "replacement")
-	if err != nil {
-		t.Fatalf("SetIfAbsent error: %s", err)
+	s := c.Stats()
+	if got := s.SetCalls - before.SetCalls; got != 90 {
+		t.Fatalf("unexpected SetCalls from UpdateEach; got %d; want 90", got)
 	}
-	if !stored {
-		t.Fatal("SetIfAbsent failed after deleting a forced-collision key")
+	if got := s.Deletes - before.Deletes; got != 2 {
+		t.Fatalf("unexpected Deletes from UpdateEach; got %d; want 2", got)
 	}
-	if got, ok := c.Get("key-12"); !ok || got != "replacement" {
-		t.Fatalf("unexpected replacement value; got (%q, %t); want (%q, true)", got, ok, "replacement")
+
+	// FIFO order is kept for the remaining entries
+	var order []int
+	for k := range c.AllOrdered() {
+		order = append(order, k)
+	}
+	if order[0] != 2 || order[len(order)-1] != 105 {
+		t.Fatalf("UpdateEach changed the eviction order; got %v", order)
 	}
 }
 
-func TestCacheEnforcesGlobalCapacityAcrossShards(t *testing.T) {
-	c, err := New[string, string](4)
+func TestCacheRangeShard(t *testing.T) {
+	c, err := New[int, int](1000)
 	if err != nil {
 		t.Fatalf("New error: %s", err)
 	}
-	c.hasher = func(k string) uint64 {
-		if k[0] == 'a' {
-			return 1
-		}
+	defer c.Reset()
 
-		return 2
+	if c.ShardCount() != shardsCount {
+		t.Fatalf("unexpected shard count; got %d; want %d", c.ShardCount(), shardsCount)
 	}
-	defer c.Reset()
 
-	for i := range 4 {
-		key := fmt.Sprintf("a%d", i)
-		if err := c.Set(key, key); err != nil {
+	perShard := make(map[int]map[int]int)
+	for i := range 500 {
+		if err := c.Set(i, i*10); err != nil {
 			t.Fatalf("Set error: %s", err)
 		}
+		idx := c.ShardIndex(i)
+		if perShard[idx] == nil {
+			perShard[idx] = make(map[int]int)
+		}
+		perShard[idx][i] = i * 10
 	}
 
-	if err := c.Set("b0", "b0"); err != nil {
-		t.Fatalf("Set error: %s", err)
+	total := 0
+	for idx := range c.ShardCount() {
+		seen := make(map[int]int)
+		if !c.RangeShard(idx, func(k, v int) bool {
+			seen[k] = v
+
+			return true
+		}) {
+			t.Fatalf("RangeShard(%d) stopped early", idx)
+		}
+		if !maps.Equal(seen, perShard[idx]) {
+			t.Fatalf("unexpected entries in shard %d; got %v; want %v", idx, seen, perShard[idx])
+		}
+		total += len(seen)
+	}
+	if total != 500 {
+		t.Fatalf("unexpected total from RangeShard; got %d; want 500", total)
 	}
 
-	if c.Len() != 4 {
-		t.Fatalf("unexpected len after cross-shard insertion at capacity; got %d; want 4", c.Len())
+	// Stop when fn returns false
+	idx := c.ShardIndex(0)
+	calls := 0
+	if c.RangeShard(idx, func(int, int) bool {
+		calls++
+
+		return false
+	}) {
+		t.Fatal("RangeShard returned true after fn returned false")
 	}
-	if _, ok := c.Get("a0"); ok {
-		t.Fatal("oldest key remained after cross-shard insertion at capacity")
+	if calls != 1 {
+		t.Fatalf("unexpected calls after stop; got %d; want 1", calls)
 	}
 
-	for _, key := range []string{"a1", "a2", "a3", "b0"} {
-		got, ok := c.Get(key)
-		if !ok || got != key {
-			t.Fatalf("unexpected value for key %q; got (%q, %t)", key, got, ok)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RangeShard did not panic for an out-of-range shard index")
+		}
+	}()
+	c.RangeShard(c.ShardCount(), func(int, int) bool { return true })
+}
+
+func TestCacheAllParallel(t *testing.T) {
+	c, err := New[int, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	want := make(map[int]int)
+	for i := range 500 {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		want[i] = i * 10
+	}
+
+	for _, workers := range []int{0, 1, 3, shardsCount + 1} {
+		var mu sync.Mutex
+		got := make(map[int]int)
+		c.AllParallel(workers, func(k, v int) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if _, ok := got[k]; ok {
+				t.Errorf("key %d visited twice with %d workers", k, workers)
+			}
+			got[k] = v
+		})
+		if !maps.Equal(got, want) {
+			t.Fatalf("unexpected entries with %d workers; got %d entries; want %d", workers, len(got), len(want))
 		}
 	}
 }
 
-func TestNewReturnsErrorForInvalidMaxEntries(t *testing.T) {
-	cache, err := New[string, string](0)
-	if !errors.Is(err, ErrInvalidMaxEntries) {
-		t.Fatalf("New returned error %v; want %v", err, ErrInvalidMaxEntries)
+func TestCacheWithHashSeed(t *testing.T) {
+	shardsOf := func(opts ...Option) []int {
+		c, err := New[string, int](1000, opts...)
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+		defer c.Reset()
+
+		shards := make([]int, 100)
+		for i := range shards {
+			k := fmt.Sprintf("key%d", i)
+			if err := c.Set(k, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+			if v, ok := c.Get(k); !ok || v != i {
+				t.Fatalf("unexpected value for %q; got (%d, %t); want (%d, true)", k, v, ok, i)
+			}
+			shards[i] = c.ShardIndex(k)
+		}
+
+		return shards
 	}
-	if cache != nil {
-		t.Fatal("New returned non-nil cache for invalid maxEntries")
+
+	if !slices.Equal(shardsOf(), shardsOf(WithHashSeed(0))) {
+		t.Fatal("WithHashSeed(0) distributes keys differently from the default")
+	}
+	seeded := shardsOf(WithHashSeed(42))
+	if !slices.Equal(seeded, shardsOf(WithHashSeed(42))) {
+		t.Fatal("caches with the same seed distribute keys differently")
+	}
+	if slices.Equal(seeded, shardsOf(WithHashSeed(43))) {
+		t.Fatal("caches with different seeds distribute keys identically")
+	}
+
+	// Non-string keys use the seed too
+	a, err := New[int, int](10, WithHashSeed(1))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer a.Reset()
+	b, err := New[int, int](10, WithHashSeed(2))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer b.Reset()
+
+	differ := false
+	for i := range 100 {
+		if a.ShardIndex(i) != b.ShardIndex(i) {
+			differ = true
+		}
+	}
+	if !differ {
+		t.Fatal("int keys ignore the hash seed")
+	}
+}
+
+func TestCacheDrain(t *testing.T) {
+	c, err := New[int, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const itemsCount = 100
+	for i := range itemsCount {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.SetNegative(-1, time.Hour); err != nil {
+		t.Fatalf("SetNegative error: %s", err)
+	}
+
+	// Stopping early leaves the remaining entries intact.
+	drained := make(map[int]int)
+	for k, v := range c.Drain() {
+		if c.Has(k) {
+			t.Fatalf("key %d is still present while it is yielded", k)
+		}
+		drained[k] = v
+		if len(drained) == 10 {
+			break
+		}
+	}
+	if c.Len() != itemsCount-len(drained)+1 {
+		t.Fatalf("unexpected len after partial drain; got %d; want %d", c.Len(), itemsCount-len(drained)+1)
+	}
+
+	for k, v := range c.Drain() {
+		if _, ok := drained[k]; ok {
+			t.Fatalf("key %d drained twice", k)
+		}
+		drained[k] = v
+	}
+	if len(drained) != itemsCount {
+		t.Fatalf("unexpected number of drained entries; got %d; want %d", len(drained), itemsCount)
+	}
+	for k, v := range drained {
+		if k != v {
+			t.Fatalf("unexpected value for key %d; got %d", k, v)
+		}
+	}
+	if c.Len() != 0 {
+		t.Fatalf("unexpected len after drain; got %d; want 0", c.Len())
+	}
+	if s := c.Stats(); s.Deletes != 0 {
+		t.Fatalf("drained entries must not count as deletes; got %d", s.Deletes)
+	}
+}
+
+func TestCacheAllOrdered(t *testing.T) {
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 150 {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Delete(75)
+
+	want := 50
+	for k, v := range c.AllOrdered() {
+		if want == 75 {
+			want++
+		}
+		if k != want || v != want*10 {
+			t.Fatalf("unexpected ordered entry; got (%d, %d); want (%d, %d)", k, v, want, want*10)
+		}
+		want++
+	}
+	if want != 150 {
+		t.Fatalf("ordered iteration stopped early at key %d", want)
+	}
+
+	// Early termination
+	count := 0
+	for range c.AllOrdered() {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Fatalf("unexpected count after break; got %d; want 5", count)
+	}
+}
+
+func TestCacheAllOrderedLRU(t *testing.T) {
+	c, err := New[string, int](10, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Get("a")
+
+	var keys []string
+	for k := range c.AllOrdered() {
+		keys = append(keys, k)
+	}
+	if got := fmt.Sprint(keys); got != "[b c a]" {
+		t.Fatalf("unexpected LRU order; got %s; want [b c a]", got)
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Add entries
+	for i := range 50 {
+		if err := c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// Collect all keys
+	keys := make(map[string]bool)
+	for k := range c.Keys() {
+		keys[k] = true
+	}
+
+	if len(keys) != 50 {
+		t.Fatalf("unexpected key count; got %d; want 50", len(keys))
+	}
+
+	// Verify expected keys exist
+	for i := range 50 {
+		if !keys[fmt.Sprintf("key%d", i)] {
+			t.Fatalf("missing key: key%d", i)
+		}
+	}
+
+	// Test early exit
+	count := 0
+	for range c.Keys() {
+		count++
+		if count >= 10 {
+			break
+		}
+	}
+
+	if count != 10 {
+		t.Fatalf("unexpected count with early exit; got %d; want 10", count)
+	}
+}
+
+func TestCacheValues(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Add entries
+	for i := range 50 {
+		if err := c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// Collect all values
+	values := make(map[string]bool)
+	for v := range c.Values() {
+		values[v] = true
+	}
+
+	if len(values) != 50 {
+		t.Fatalf("unexpected value count; got %d; want 50", len(values))
+	}
+
+	// Verify expected values exist
+	for i := range 50 {
+		if !values[fmt.Sprintf("value%d", i)] {
+			t.Fatalf("missing value: value%d", i)
+		}
+	}
+
+	// Test early exit
+	count := 0
+	for range c.Values() {
+		count++
+		if count >= 10 {
+			break
+		}
+	}
+
+	if count != 10 {
+		t.Fatalf("unexpected count with early exit; got %d; want 10", count)
+	}
+}
+
+func TestCacheClone(t *testing.T) {
+	c, err := New[int, []int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 15 {
+		if err := c.Set(i, []int{i}); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	clone := c.Clone()
+	defer clone.Reset()
+
+	if clone.Len() != c.Len() {
+		t.Fatalf("unexpected clone len; got %d; want %d", clone.Len(), c.Len())
+	}
+	if s := clone.Stats(); s.GetCalls != 0 || s.SetCalls != 0 || s.MaxEntries != 10 {
+		t.Fatalf("unexpected clone stats: %+v", s)
+	}
+
+	// The clone keeps the eviction order
+	var keys []int
+	for k := range clone.AllOrdered() {
+		keys = append(keys, k)
+	}
+	if got := fmt.Sprint(keys); got != "[5 6 7 8 9 10 11 12 13 14]" {
+		t.Fatalf("unexpected clone order; got %s", got)
+	}
+
+	// Mutating one cache must not affect the other
+	c.Delete(5)
+	if !clone.Has(5) {
+		t.Fatal("delete on the original removed the key from the clone")
+	}
+	if err := clone.Set(100, []int{100}); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if !c.Has(6) {
+		t.Fatal("eviction in the clone removed the key from the original")
+	}
+	if clone.Has(5) || !clone.Has(6) {
+		t.Fatal("clone did not evict its own oldest key")
+	}
+	if c.Has(100) {
+		t.Fatal("set on the clone added the key to the original")
+	}
+
+	// Values are shallow copies
+	v, _ := c.Get(6)
+	v[0] = -6
+	if cv, _ := clone.Get(6); cv[0] != -6 {
+		t.Fatalf("unexpected clone value; got %d; want -6", cv[0])
+	}
+}
+
+func TestCacheLen(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.Len() != 0 {
+		t.Fatalf("unexpected len for empty cache; got %d; want 0", c.Len())
+	}
+
+	for i := range 50 {
+		if err := c.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if c.Len() != 50 {
+		t.Fatalf("unexpected len; got %d; want 50", c.Len())
+	}
+
+	c.Reset()
+	if c.Len() != 0 {
+		t.Fatalf("unexpected len after reset; got %d; want 0", c.Len())
+	}
+}
+
+func TestCacheTrim(t *testing.T) {
+	for _, policy := range []Policy{PolicyFIFO, PolicyLRU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			c, err := New[int, int](100, WithPolicy(policy))
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			for i := range 100 {
+				if err := c.Set(i, i); err != nil {
+					t.Fatalf("Set error: %s", err)
+				}
+			}
+			c.Delete(10)
+			c.Get(0)
+
+			var want []int
+			for k := range c.AllOrdered() {
+				want = append(want, k)
+			}
+			want = want[len(want)-50:]
+
+			c.Trim(50)
+			if c.Len() != 50 {
+				t.Fatalf("unexpected len after Trim; got %d; want 50", c.Len())
+			}
+			if c.Capacity() != 100 {
+				t.Fatalf("Trim must not change capacity; got %d; want 100", c.Capacity())
+			}
+			if s := c.Stats(); s.Evictions != 49 {
+				t.Fatalf("unexpected Evictions after Trim; got %d; want 49", s.Evictions)
+			}
+
+			var got []int
+			for k := range c.AllOrdered() {
+				got = append(got, k)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("Trim must evict the oldest entries; got %v; want %v", got, want)
+			}
+
+			// Trimming above the current length is a no-op.
+			c.Trim(80)
+			if c.Len() != 50 {
+				t.Fatalf("unexpected len after no-op Trim; got %d; want 50", c.Len())
+			}
+
+			// The cache refills up to its capacity.
+			for i := 100; i < 150; i++ {
+				if err := c.Set(i, i); err != nil {
+					t.Fatalf("Set error: %s", err)
+				}
+			}
+			if c.Len() != 100 {
+				t.Fatalf("unexpected len after refill; got %d; want 100", c.Len())
+			}
+
+			c.Trim(-1)
+			if c.Len() != 0 {
+				t.Fatalf("unexpected len after Trim to zero; got %d; want 0", c.Len())
+			}
+		})
+	}
+}
+
+func TestCacheEvictOldest(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[int, int](100, WithPolicy(PolicyLRU), WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if _, _, ok := c.EvictOldest(); ok {
+		t.Fatal("EvictOldest returned an entry from an empty cache")
+	}
+
+	if err := c.SetWithTTL(0, 0, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	for i := 1; i < 5; i++ {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Delete(1)
+	c.Get(2) // Key 2 becomes the most recently used.
+	clock.Advance(time.Minute)
+
+	// Key 0 expired and key 1 was deleted, so they are skipped.
+	for _, want := range []int{3, 4, 2} {
+		k, v, ok := c.EvictOldest()
+		if !ok || k != want || v != want*10 {
+			t.Fatalf("unexpected entry; got (%d, %d, %t); want (%d, %d, true)", k, v, ok, want, want*10)
+		}
+	}
+	if _, _, ok := c.EvictOldest(); ok {
+		t.Fatal("EvictOldest returned an entry after evicting all of them")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("unexpected len; got %d; want 0", c.Len())
+	}
+	if s := c.Stats(); s.Evictions != 3 || s.Expirations != 1 {
+		t.Fatalf("unexpected stats; got Evictions=%d, Expirations=%d; want 3, 1", s.Evictions, s.Expirations)
+	}
+}
+
+func TestCacheGlobalFIFOOrder(t *testing.T) {
+	const n = 5000
+	c, err := New[int, int](n)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Keys spread over all shards, yet come back in insertion order.
+	for i := range n {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	for want := range n {
+		if k, _, ok := c.EvictOldest(); !ok || k != want {
+			t.Fatalf("unexpected oldest key; got (%d, %t); want (%d, true)", k, ok, want)
+		}
+	}
+}
+
+func TestCacheEvictsFromOtherShards(t *testing.T) {
+	const n = 8
+	c, err := New[int, int](n)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Fill the cache with keys of one shard, then insert a key of an empty
+	// shard: the oldest key is evicted from the full shard.
+	shardOf := func(k int) int { return c.shardIndexFromHash(c.hasher(k)) }
+	var keys []int
+	for k := 0; len(keys) < n; k++ {
+		if shardOf(k) == shardOf(0) {
+			keys = append(keys, k)
+		}
+	}
+	other := 1
+	for shardOf(other) == shardOf(0) {
+		other++
+	}
+
+	for _, k := range keys {
+		if err := c.Set(k, k); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.Set(other, other); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if c.Has(keys[0]) || !c.Has(other) {
+		t.Fatalf("unexpected entries; want key %d evicted and key %d stored", keys[0], other)
+	}
+}
+
+func TestCacheStruct(t *testing.T) {
+	type User struct {
+		ID   int
+		Name string
+	}
+
+	c, err := New[int, User](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	u := User{ID: 1, Name: "Alice"}
+	if err := c.Set(1, u); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	got, ok := c.Get(1)
+	if !ok {
+		t.Fatal("user not found")
+	}
+	if got.ID != u.ID || got.Name != u.Name {
+		t.Fatalf("unexpected user; got %+v; want %+v", got, u)
+	}
+}
+
+func TestCacheHandlesForcedShardCollisions(t *testing.T) {
+	const maxEntries = 8
+
+	c, err := New[string, string](maxEntries)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	c.hasher = func(string) uint64 { return 1 }
+	defer c.Reset()
+
+	for i := range maxEntries * 2 {
+		key := fmt.Sprintf("key-%d", i)
+		value := fmt.Sprintf("value-%d", i)
+		if err := c.Set(key, value); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if c.Len() != maxEntries {
+		t.Fatalf("unexpected len after forced collisions; got %d; want %d", c.Len(), maxEntries)
+	}
+
+	for i := range maxEntries {
+		key := fmt.Sprintf("key-%d", i)
+		if _, ok := c.Get(key); ok {
+			t.Fatalf("unexpected stale key after fifo eviction under forced collisions: %q", key)
+		}
+	}
+
+	for i := range maxEntries {
+		idx := i + maxEntries
+		key := fmt.Sprintf("key-%d", idx)
+		want := fmt.Sprintf("value-%d", idx)
+		got, ok := c.Get(key)
+		if !ok || got != want {
+			t.Fatalf("unexpected value for forced-collision key %q; got (%q, %t); want (%q, true)", key, got, ok, want)
+		}
+	}
+
+	stored, err := c.SetIfAbsent("key-12", "newer")
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	if stored {
+		t.Fatal("SetIfAbsent unexpectedly overwrote an existing forced-collision key")
+	}
+
+	actual, loaded, err := c.GetOrSet("key-12", "newer")
+	if err != nil {
+		t.Fatalf("GetOrSet error: %s", err)
+	}
+	if !loaded || actual != "value-12" {
+		t.Fatalf("GetOrSet returned (%q, %t); want (%q, true)", actual, loaded, "value-12")
+	}
+
+	deleted, ok := c.GetAndDelete("key-12")
+	if !ok || deleted != "value-12" {
+		t.Fatalf("GetAndDelete returned (%q, %t); want (%q, true)", deleted, ok, "value-12")
+	}
+	if _, ok := c.Get("key-12"); ok {
+		t.Fatal("forced-collision key still present after GetAndDelete")
+	}
+
+	stored, err = c.SetIfAbsent("key-12", "replacement")
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	if !stored {
+		t.Fatal("SetIfAbsent failed after deleting a forced-collision key")
+	}
+	if got, ok := c.Get("key-12"); !ok || got != "replacement" {
+		t.Fatalf("unexpected replacement value; got (%q, %t); want (%q, true)", got, ok, "replacement")
+	}
+}
+
+func TestCacheEnforcesGlobalCapacityAcrossShards(t *testing.T) {
+	c, err := New[string, string](4)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	c.hasher = func(k string) uint64 {
+		if k[0] == 'a' {
+			return 1
+		}
+
+		return 2
+	}
+	defer c.Reset()
+
+	for i := range 4 {
+		key := fmt.Sprintf("a%d", i)
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if err := c.Set("b0", "b0"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	if c.Len() != 4 {
+		t.Fatalf("unexpected len after cross-shard insertion at capacity; got %d; want 4", c.Len())
+	}
+	if _, ok := c.Get("a0"); ok {
+		t.Fatal("oldest key remained after cross-shard insertion at capacity")
+	}
+
+	for _, key := range []string{"a1", "a2", "a3", "b0"} {
+		got, ok := c.Get(key)
+		if !ok || got != key {
+			t.Fatalf("unexpected value for key %q; got (%q, %t)", key, got, ok)
+		}
+	}
+}
+
+func TestCacheLenNeverExceedsMaxEntries(t *testing.T) {
+	for _, maxEntries := range []int{1, 10, shardsCount - 1, shardsCount + 1, 3 * shardsCount} {
+		t.Run(fmt.Sprintf("maxEntries_%d", maxEntries), func(t *testing.T) {
+			c, err := New[int, int](maxEntries)
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			const workers = 8
+			itemsCount := 4 * maxEntries
+
+			var (
+				wg   sync.WaitGroup
+				done atomic.Bool
+			)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for !done.Load() {
+					if n := c.Len(); n > maxEntries {
+						t.Errorf("len exceeds maxEntries during sets; got %d; want at most %d", n, maxEntries)
+
+						return
+					}
+				}
+			}()
+
+			var setters sync.WaitGroup
+			for w := range workers {
+				setters.Add(1)
+				go func() {
+					defer setters.Done()
+					for i := range itemsCount {
+						if err := c.Set(w*itemsCount+i, i); err != nil {
+							t.Errorf("Set error: %s", err)
+
+							return
+						}
+					}
+				}()
+			}
+			setters.Wait()
+			done.Store(true)
+			wg.Wait()
+
+			if c.Len() != maxEntries {
+				t.Fatalf("unexpected len of full cache; got %d; want %d", c.Len(), maxEntries)
+			}
+
+			n := 0
+			for range c.All() {
+				n++
+			}
+			if n != maxEntries {
+				t.Fatalf("unexpected number of live entries; got %d; want %d", n, maxEntries)
+			}
+		})
+	}
+}
+
+func TestCacheLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := New[string, string](3, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// Promote "a", so "b" becomes the least recently used entry
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("key a not found")
+	}
+	if err := c.Set("d", "d"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if c.Has("b") {
+		t.Fatal("least recently used key b was not evicted")
+	}
+
+	// Updating "c" promotes it as well, leaving "a" as the eviction candidate
+	if err := c.Set("c", "c2"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.Set("e", "e"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("least recently used key a was not evicted")
+	}
+
+	for _, key := range []string{"c", "d", "e"} {
+		if !c.Has(key) {
+			t.Fatalf("recently used key %q was evicted", key)
+		}
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.Evictions != 2 {
+		t.Fatalf("unexpected evictions under LRU; got %d; want 2", s.Evictions)
+	}
+	if s.EntriesCount != 3 {
+		t.Fatalf("unexpected entries count under LRU; got %d; want 3", s.EntriesCount)
+	}
+}
+
+func TestCacheFIFOIgnoresReads(t *testing.T) {
+	c, err := New[string, string](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Get("a")
+	if err := c.Set("c", "c"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if c.Has("a") {
+		t.Fatal("FIFO kept the oldest key after it was read")
+	}
+}
+
+func TestCacheEvictionSkipsReinsertedKeys(t *testing.T) {
+	c, err := New[string, string](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("a", "a"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	c.Delete("a")
+	for _, key := range []string{"b", "a", "c"} {
+		if err := c.Set(key, key); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// "b" is the oldest live entry; the re-inserted "a" must survive
+	if c.Has("b") {
+		t.Fatal("oldest key b was not evicted")
+	}
+	if !c.Has("a") {
+		t.Fatal("re-inserted key a was evicted through its stale position")
+	}
+}
+
+func TestNewReturnsErrorForInvalidPolicy(t *testing.T) {
+	cache, err := New[string, string](1, WithPolicy(Policy(42)))
+	if !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("New returned error %v; want %v", err, ErrInvalidPolicy)
+	}
+	if cache != nil {
+		t.Fatal("New returned non-nil cache for invalid policy")
+	}
+}
+
+func TestNewReturnsErrorForInvalidLockStripes(t *testing.T) {
+	for _, n := range []int{-1, 3, 1024} {
+		if _, err := New[string, string](1, WithLockStripes(n)); !errors.Is(err, ErrInvalidLockStripes) {
+			t.Fatalf("unexpected error for %d stripes; got %v; want %v", n, err, ErrInvalidLockStripes)
+		}
+	}
+}
+
+func TestCacheWithLockStripes(t *testing.T) {
+	c, err := New[int, int](1000, WithLockStripes(2))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.shards[0].mu != c.shards[2].mu || c.shards[0].mu == c.shards[1].mu {
+		t.Fatal("shards do not share lock stripes by index")
+	}
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 250 {
+				k := g*250 + i
+				if err := c.Set(k, k); err != nil {
+					t.Errorf("Set error: %s", err)
+				}
+				if v, ok := c.Get(k); !ok || v != k {
+					t.Errorf("unexpected value for %d; got %d, %t; want %d, true", k, v, ok, k)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := c.Len(); n != 1000 {
+		t.Fatalf("unexpected Len; got %d; want 1000", n)
+	}
+	clone := c.Clone()
+	defer clone.Reset()
+	if len(clone.stripes) != 2 {
+		t.Fatalf("unexpected clone stripes; got %d; want 2", len(clone.stripes))
+	}
+}
+
+func TestCacheSkewedKeysKeepFullCapacity(t *testing.T) {
+	const maxEntries = 1024
+
+	c, err := New[int, int](maxEntries)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	// Route 90% of the keys to a single hot shard and the rest to three others.
+	c.hasher = func(k int) uint64 {
+		if k%10 != 0 {
+			return 7
+		}
+
+		return uint64(k % 3)
+	}
+	defer c.Reset()
+
+	for i := range maxEntries * 4 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		if want := min(i+1, maxEntries); c.Len() != want {
+			t.Fatalf("unexpected len after %d sets; got %d; want %d", i+1, c.Len(), want)
+		}
+	}
+
+	// Exactly the newest maxEntries keys must be live.
+	live := 0
+	for i := range maxEntries * 4 {
+		if _, ok := c.Peek(i); ok {
+			if i < maxEntries*3 {
+				t.Fatalf("unexpected live key %d older than the newest %d keys", i, maxEntries)
+			}
+			live++
+		}
+	}
+	if live != maxEntries {
+		t.Fatalf("unexpected live entries under skewed keys; got %d; want %d", live, maxEntries)
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.Evictions != maxEntries*3 {
+		t.Fatalf("unexpected evictions under skewed keys; got %d; want %d", s.Evictions, maxEntries*3)
+	}
+}
+
+func TestCacheDeleteReclaimsEvictionSlots(t *testing.T) {
+	const itemsCount = 4096
+
+	c, err := New[int, int](itemsCount)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range itemsCount {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	for i := range itemsCount / 2 {
+		c.Delete(i)
+	}
+	for i := range itemsCount / 2 {
+		if err := c.Set(itemsCount+i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.Evictions != 0 {
+		t.Fatalf("unexpected evictions after refilling deleted slots; got %d; want 0", s.Evictions)
+	}
+	if c.Len() != itemsCount {
+		t.Fatalf("unexpected len; got %d; want %d", c.Len(), itemsCount)
+	}
+
+	c.orderMu.Lock()
+	listLen := c.order.len
+	c.orderMu.Unlock()
+	if listLen != itemsCount {
+		t.Fatalf("stale eviction list nodes were not reclaimed; got %d nodes; want %d", listLen, itemsCount)
+	}
+}
+
+func TestCacheDeleteHeavyWorkloadBoundsEvictionList(t *testing.T) {
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 100_000 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		c.Delete(i)
+	}
+
+	c.orderMu.Lock()
+	listLen := c.order.len
+	c.orderMu.Unlock()
+	if listLen > 2048 {
+		t.Fatalf("eviction list grew without bound; got %d nodes", listLen)
+	}
+}
+
+func TestCacheCompactNow(t *testing.T) {
+	c, err := New[int, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if n := c.CompactNow(); n != 0 {
+		t.Fatalf("unexpected reclaimed nodes for empty cache; got %d; want 0", n)
+	}
+
+	for i := range 100 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	for i := 0; i < 100; i += 2 {
+		c.Delete(i)
+	}
+
+	if n := c.CompactNow(); n != 50 {
+		t.Fatalf("unexpected reclaimed nodes; got %d; want 50", n)
+	}
+	c.orderMu.Lock()
+	listLen := c.order.len
+	c.orderMu.Unlock()
+	if listLen != 50 {
+		t.Fatalf("unexpected eviction list length after CompactNow; got %d; want 50", listLen)
+	}
+	if n := c.CompactNow(); n != 0 {
+		t.Fatalf("unexpected reclaimed nodes on second CompactNow; got %d; want 0", n)
+	}
+
+	var got []int
+	for k := range c.AllOrdered() {
+		got = append(got, k)
+	}
+	for i, k := range got {
+		if k != 2*i+1 {
+			t.Fatalf("CompactNow changed the eviction order; got %v", got)
+		}
+	}
+}
+
+func TestNewReturnsErrorForInvalidMaxEntries(t *testing.T) {
+	cache, err := New[string, string](0)
+	if !errors.Is(err, ErrInvalidMaxEntries) {
+		t.Fatalf("New returned error %v; want %v", err, ErrInvalidMaxEntries)
+	}
+	if cache != nil {
+		t.Fatal("New returned non-nil cache for invalid maxEntries")
+	}
+}
+
+func TestCacheHugeMaxEntries(t *testing.T) {
+	for _, maxEntries := range []int{2_000_000_000, math.MaxInt} {
+		c, err := New[int, int](maxEntries)
+		if err != nil {
+			t.Fatalf("New(%d) error: %s", maxEntries, err)
+		}
+
+		for i := range 10 {
+			if err := c.Set(i, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+		if c.Len() != 10 {
+			t.Fatalf("unexpected len; got %d; want 10", c.Len())
+		}
+		if c.Capacity() != maxEntries {
+			t.Fatalf("unexpected capacity; got %d; want %d", c.Capacity(), maxEntries)
+		}
+		if c.Available() != maxEntries-10 {
+			t.Fatalf("unexpected available; got %d; want %d", c.Available(), maxEntries-10)
+		}
+		if s := c.Stats(); s.MaxEntries != uint64(maxEntries) || s.AtCapacity {
+			t.Fatalf("unexpected stats; got MaxEntries=%d AtCapacity=%t; want %d, false", s.MaxEntries, s.AtCapacity, maxEntries)
+		}
+		c.Reset()
+	}
+}
+
+func TestCacheWithStrictCapacity(t *testing.T) {
+	for _, policy := range []Policy{PolicyFIFO, PolicyLRU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			c, err := New[int, int](10, WithStrictCapacity(), WithPolicy(policy))
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			for i := range 10 {
+				if !c.TrySet(i, i) {
+					t.Fatalf("TrySet(%d) failed below capacity", i)
+				}
+			}
+
+			if err := c.Set(10, 10); !errors.Is(err, ErrCacheFull) {
+				t.Fatalf("Set returned error %v; want %v", err, ErrCacheFull)
+			}
+			if c.TrySet(11, 11) {
+				t.Fatal("TrySet stored a new key in a full cache")
+			}
+			if _, err := Add(c, 12, 1); !errors.Is(err, ErrCacheFull) {
+				t.Fatalf("Add returned error %v; want %v", err, ErrCacheFull)
+			}
+			if v, loaded, err := c.GetOrSet(13, 13); v != 0 || loaded || !errors.Is(err, ErrCacheFull) {
+				t.Fatalf("GetOrSet of a new key returned (%d, %t, %v); want (0, false, %v)", v, loaded, err, ErrCacheFull)
+			}
+			if v, loaded, err := c.GetOrSet(1, 100); v != 1 || !loaded || err != nil {
+				t.Fatalf("GetOrSet of an existing key returned (%d, %t, %v); want (1, true, <nil>)", v, loaded, err)
+			}
+			if c.Has(10) || c.Has(11) || c.Has(12) || c.Has(13) {
+				t.Fatal("a rejected key was stored")
+			}
+
+			// Existing keys can still be overwritten
+			if !c.TrySet(0, 100) {
+				t.Fatal("TrySet failed to overwrite an existing key")
+			}
+			if v, _ := c.Get(0); v != 100 {
+				t.Fatalf("unexpected value after overwrite; got %d; want 100", v)
+			}
+
+			for i := range 10 {
+				if !c.Has(i) {
+					t.Fatalf("key %d was evicted in strict mode", i)
+				}
+			}
+			if s := c.Stats(); s.Evictions != 0 {
+				t.Fatalf("unexpected evictions in strict mode; got %d; want 0", s.Evictions)
+			}
+
+			// Deleting makes room again
+			c.Delete(5)
+			if !c.TrySet(10, 10) {
+				t.Fatal("TrySet failed after a delete made room")
+			}
+		})
+	}
+}
+
+func TestCacheWithStrictCapacityBytes(t *testing.T) {
+	c, err := New[string, string](100, WithStrictCapacity(), WithMaxBytes(1000),
+		WithSizeOf(func(_, v string) int64 { return int64(len(v)) }))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if !c.TrySet("a", strings.Repeat("a", 600)) {
+		t.Fatal("TrySet failed below the byte limit")
+	}
+	if err := c.Set("b", strings.Repeat("b", 600)); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Set returned error %v; want %v", err, ErrCacheFull)
+	}
+
+	// Growing an existing entry past the limit does not evict anything
+	if !c.TrySet("a", strings.Repeat("a", 1000)) {
+		t.Fatal("TrySet failed to overwrite an existing key")
+	}
+	if err := c.Set("c", "c"); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Set returned error %v; want %v", err, ErrCacheFull)
+	}
+	if !c.Has("a") || c.Stats().Evictions != 0 {
+		t.Fatal("an entry was evicted in strict mode")
+	}
+}
+
+func TestCacheWithStrictCapacityOverwrite(t *testing.T) {
+	c, err := New[string, string](100, WithStrictCapacity(), WithMaxBytes(1000), WithMaxCost(10),
+		WithSizeOf(func(_, v string) int64 { return int64(len(v)) }))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("a", strings.Repeat("a", 600)); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.SetWithCost("b", strings.Repeat("b", 300), 5); err != nil {
+		t.Fatalf("SetWithCost error: %s", err)
+	}
+
+	// Growing an entry beyond the room left is rejected, not left over the
+	// limit.
+	if err := c.Set("a", strings.Repeat("a", 800)); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("Set returned error %v; want %v", err, ErrCacheFull)
+	}
+	if c.Replace("a", strings.Repeat("a", 800)) {
+		t.Fatal("Replace grew an entry beyond the byte limit")
+	}
+	if err := c.SetWithCost("b", "b", 10); !errors.Is(err, ErrCacheFull) {
+		t.Fatalf("SetWithCost returned error %v; want %v", err, ErrCacheFull)
+	}
+	if got := c.Bytes(); got != 900 {
+		t.Fatalf("unexpected bytes; got %d; want 900", got)
+	}
+	if got := c.Cost(); got != 6 {
+		t.Fatalf("unexpected cost; got %d; want 6", got)
+	}
+
+	// Growing within the room left, or shrinking, still succeeds.
+	if err := c.Set("a", strings.Repeat("a", 700)); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.SetWithCost("b", "b", 9); err != nil {
+		t.Fatalf("SetWithCost error: %s", err)
+	}
+	if got, want := c.Bytes(), int64(701); got != want {
+		t.Fatalf("unexpected bytes; got %d; want %d", got, want)
+	}
+	if c.Stats().Evictions != 0 {
+		t.Fatal("an entry was evicted in strict mode")
+	}
+}
+
+func TestCacheWithEvictionBatch(t *testing.T) {
+	for _, batch := range []int{0, 2} {
+		c, err := New[int, int](10, WithEvictionBatch(batch))
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+
+		// Leave 5 slots of deleted entries at the front of the eviction
+		// order, then fill the cache.
+		for i := range 10 {
+			if err := c.Set(i, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+		for i := range 5 {
+			c.Delete(i)
+		}
+		for i := 10; i < 15; i++ {
+			if err := c.Set(i, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+
+		if err := c.Set(15, 15); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		if batch == 0 {
+			// Unbounded: the insert skips all deleted slots and evicts.
+			if n := c.Len(); n != 10 {
+				t.Fatalf("unexpected len without a batch; got %d; want 10", n)
+			}
+			if c.Has(5) {
+				t.Fatal("oldest key 5 was not evicted without a batch")
+			}
+			c.Reset()
+
+			continue
+		}
+
+		// The insert gives up after 2 deleted slots and exceeds capacity.
+		if n := c.Len(); n != 11 {
+			t.Fatalf("unexpected len after a bounded insert; got %d; want 11", n)
+		}
+		if s := c.Stats(); s.Evictions != 0 {
+			t.Fatalf("unexpected evictions; got %d; want 0", s.Evictions)
+		}
+
+		// Later inserts finish skipping the deleted slots, then evict back
+		// down to capacity.
+		for i := 16; i < 18; i++ {
+			if err := c.Set(i, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+		if n := c.Len(); n != 10 {
+			t.Fatalf("unexpected len after catching up; got %d; want 10", n)
+		}
+		for i := 5; i < 8; i++ {
+			if c.Has(i) {
+				t.Fatalf("key %d was not evicted after catching up", i)
+			}
+		}
+		c.Reset()
+	}
+}
+
+func TestNewWithInitialCapacity(t *testing.T) {
+	for _, n := range []int{-1, 0, 1<<20 + 1} {
+		if _, err := New[int, int](1<<20, WithInitialCapacity(n)); !errors.Is(err, ErrInvalidInitialCapacity) {
+			t.Fatalf("New(WithInitialCapacity(%d)) returned error %v; want %v", n, err, ErrInvalidInitialCapacity)
+		}
+	}
+
+	c, err := New[int, int](1<<20, WithInitialCapacity(1024))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.Capacity() != 1<<20 {
+		t.Fatalf("unexpected capacity; got %d; want %d", c.Capacity(), 1<<20)
+	}
+	for i := range 4096 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if c.Len() != 4096 {
+		t.Fatalf("maps did not grow past the initial capacity; got len %d; want 4096", c.Len())
+	}
+	if clone := c.Clone(); clone.initialCapacity != 1024 {
+		t.Fatalf("Clone dropped the initial capacity; got %d; want 1024", clone.initialCapacity)
 	}
 }
 
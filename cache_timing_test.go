@@ -1,6 +1,7 @@
 package fastcache
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -48,6 +49,29 @@ func BenchmarkCacheGet(b *testing.B) {
 	}
 }
 
+func BenchmarkCacheGetStatsDisabled(b *testing.B) {
+	c, err := New[string, string](b.N*2, WithStatsDisabled())
+	if err != nil {
+		b.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := 0; i < b.N; i++ {
+		k := fmt.Sprintf("key %d", i)
+		v := fmt.Sprintf("value %d", i)
+		if err := c.Set(k, v); err != nil {
+			b.Fatalf("Set error: %s", err)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := fmt.Sprintf("key %d", i)
+		c.Get(k)
+	}
+}
+
 func BenchmarkCacheSetGet(b *testing.B) {
 	c, err := New[string, string](b.N * 2)
 	if err != nil {
@@ -82,7 +106,9 @@ func BenchmarkCacheSetGetConcurrent(b *testing.B) {
 			k := fmt.Sprintf("key %d", i)
 			v := fmt.Sprintf("value %d", i)
 			if err := c.Set(k, v); err != nil {
-				b.Fatalf("Set error: %s", err)
+				b.Error(err)
+
+				return
 			}
 			c.Get(k)
 			i++
@@ -90,6 +116,70 @@ func BenchmarkCacheSetGetConcurrent(b *testing.B) {
 	})
 }
 
+// BenchmarkCacheSetConcurrent measures write contention for several numbers
+// of lock stripes. Overwrites of existing keys only take the shard lock, while
+// inserts of new keys also take the cache-wide eviction lock.
+func BenchmarkCacheSetConcurrent(b *testing.B) {
+	const keysCount = 1 << 16
+
+	keys := make([]string, keysCount)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key %d", i)
+	}
+
+	for _, stripes := range []int{1, 16, shardsCount} {
+		b.Run(fmt.Sprintf("stripes=%d/overwrite", stripes), func(b *testing.B) {
+			c, err := New[string, int](keysCount, WithLockStripes(stripes))
+			if err != nil {
+				b.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			for i, k := range keys {
+				if err := c.Set(k, i); err != nil {
+					b.Fatalf("Set error: %s", err)
+				}
+			}
+
+			var counter atomic.Int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := int(counter.Add(1) % keysCount)
+					if err := c.Set(keys[i], i); err != nil {
+						b.Error(err)
+
+						return
+					}
+				}
+			})
+		})
+
+		b.Run(fmt.Sprintf("stripes=%d/insert", stripes), func(b *testing.B) {
+			c, err := New[string, int](keysCount/2, WithLockStripes(stripes))
+			if err != nil {
+				b.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			var counter atomic.Int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := int(counter.Add(1) % keysCount)
+					if err := c.Set(keys[i], i); err != nil {
+						b.Error(err)
+
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
 func BenchmarkCacheGetConcurrent(b *testing.B) {
 	c, err := New[string, string](1000000)
 	if err != nil {
@@ -201,6 +291,85 @@ func BenchmarkCacheUint64Key(b *testing.B) {
 	}
 }
 
+func BenchmarkCacheAllFunc(b *testing.B) {
+	const entries = 100_000
+
+	c, err := New[int, int](entries)
+	if err != nil {
+		b.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range entries {
+		if err := c.Set(i, i); err != nil {
+			b.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// Keep one entry in a hundred
+	pred := func(k, _ int) bool { return k%100 == 0 }
+
+	b.Run("AllFunc", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			n := 0
+			for range c.AllFunc(pred) {
+				n++
+			}
+			if n != entries/100 {
+				b.Fatalf("unexpected count; got %d; want %d", n, entries/100)
+			}
+		}
+	})
+
+	b.Run("All", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			n := 0
+			for k, v := range c.All() {
+				if pred(k, v) {
+					n++
+				}
+			}
+			if n != entries/100 {
+				b.Fatalf("unexpected count; got %d; want %d", n, entries/100)
+			}
+		}
+	})
+}
+
+// BenchmarkLoadFrom measures loading a large dump. Sizing the shard maps for
+// the saved entries shows in B/op; ns/op is dominated by decoding.
+func BenchmarkLoadFrom(b *testing.B) {
+	const itemsCount = 1 << 22
+
+	c, err := New[int, int](itemsCount)
+	if err != nil {
+		b.Fatalf("New error: %s", err)
+	}
+	for i := range itemsCount {
+		if err := c.Set(i, i); err != nil {
+			b.Fatalf("Set error: %s", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		b.Fatalf("SaveTo error: %s", err)
+	}
+	c.Reset()
+	data := buf.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, err := LoadFrom[int, int](bytes.NewReader(data))
+		if err != nil {
+			b.Fatalf("LoadFrom error: %s", err)
+		}
+		c.Reset()
+	}
+}
+
 func BenchmarkMapSetGet(b *testing.B) {
 	m := make(map[string]string, b.N)
 	var mu sync.RWMutex
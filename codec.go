@@ -0,0 +1,222 @@
+package fastcache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minlz"
+)
+
+// Codec serializes the cache data stream for persistence.
+//
+// The same codec must be used for saving and loading.
+type Codec interface {
+	// NewEncoder returns an encoder writing to w.
+	NewEncoder(w io.Writer) Encoder
+
+	// NewDecoder returns a decoder reading from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder encodes a sequence of values.
+type Encoder interface {
+	// Encode writes v to the stream.
+	Encode(v any) error
+
+	// Close flushes any buffered data. It does not close the underlying writer.
+	Close() error
+}
+
+// Decoder decodes a sequence of values written by the matching [Encoder].
+type Decoder interface {
+	// Decode reads the next value from the stream into v.
+	Decode(v any) error
+}
+
+// MinLZGobCodec serializes entries using [gob] and compresses the stream with
+// [minlz]. This is the default codec.
+type MinLZGobCodec struct{}
+
+// NewEncoder implements [Codec].
+func (c MinLZGobCodec) NewEncoder(w io.Writer) Encoder {
+	return newGobEncoder(c, w, nil)
+}
+
+// NewDecoder implements [Codec].
+func (MinLZGobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(minlz.NewReader(r))
+}
+
+// GobCodec serializes entries using [gob] without compression.
+//
+// Use it when values are already compressed.
+type GobCodec struct{}
+
+// NewEncoder implements [Codec].
+func (c GobCodec) NewEncoder(w io.Writer) Encoder {
+	return newGobEncoder(c, w, nil)
+}
+
+// NewDecoder implements [Codec].
+func (GobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+// gobCodec is implemented by the built-in codecs, which gob-encode values
+// into a compressing writer.
+type gobCodec interface {
+	Codec
+
+	// compressWriter returns a writer compressing into w, or nil if the
+	// codec does not compress.
+	compressWriter(w io.Writer) io.WriteCloser
+}
+
+// newGobEncoder returns an encoder gob-encoding values into the compressing
+// writer of codec. If n is not nil, the encoded bytes are added to it before
+// compression.
+func newGobEncoder(codec gobCodec, w io.Writer, n *int64) Encoder {
+	e := &gobEncoder{}
+	if zw := codec.compressWriter(w); zw != nil {
+		w, e.closer = zw, zw
+	}
+	if n != nil {
+		w = &countingWriter{w: w, n: n}
+	}
+	e.enc = gob.NewEncoder(w)
+
+	return e
+}
+
+// countingWriter adds the number of bytes written to w to n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	*cw.n += int64(n)
+
+	return n, err
+}
+
+type gobEncoder struct {
+	enc    *gob.Encoder
+	closer io.Closer
+}
+
+func (e *gobEncoder) Encode(v any) error {
+	err := e.enc.Encode(v)
+	if err != nil {
+		if name, ok := strings.CutPrefix(err.Error(), gobUnregisteredPrefix); ok {
+			return fmt.Errorf("%w: %s; register it with RegisterTypes", ErrUnregisteredType, name)
+		}
+	}
+
+	return err
+}
+
+// gobUnregisteredPrefix starts the error message of gob for a value whose
+// concrete type is not registered, followed by the type name.
+const gobUnregisteredPrefix = "gob: type not registered for interface: "
+
+// RegisterTypes registers the concrete types of vals with [gob.Register],
+// so that values of these types stored in interface-typed keys or values can
+// be saved and loaded with the gob-based codecs.
+//
+// Register every concrete type before saving or loading, e.g. in an init
+// function; saving an unregistered type fails with an error wrapping
+// [ErrUnregisteredType]. Like gob.Register, RegisterTypes panics if a type
+// is registered twice under different names.
+func RegisterTypes(vals ...any) {
+	for _, v := range vals {
+		gob.Register(v)
+	}
+}
+
+func (e *gobEncoder) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+
+	return e.closer.Close()
+}
+
+func (MinLZGobCodec) compression() Compression {
+	return CompressionMinLZ
+}
+
+func (MinLZGobCodec) compressWriter(w io.Writer) io.WriteCloser {
+	return minlz.NewWriter(w)
+}
+
+func (GobCodec) compression() Compression {
+	return CompressionNone
+}
+
+func (GobCodec) compressWriter(io.Writer) io.WriteCloser {
+	return nil
+}
+
+// errDecoder is a [Decoder] that always fails with err.
+type errDecoder struct {
+	err error
+}
+
+func (d errDecoder) Decode(any) error {
+	return d.err
+}
+
+// minLZBlockGobCodec serializes entries using [gob] and compresses the whole
+// stream as a single [minlz] block.
+type minLZBlockGobCodec struct{}
+
+func (c minLZBlockGobCodec) NewEncoder(w io.Writer) Encoder {
+	return newGobEncoder(c, w, nil)
+}
+
+func (minLZBlockGobCodec) NewDecoder(r io.Reader) Decoder {
+	block, err := io.ReadAll(r)
+	if err != nil {
+		return errDecoder{err}
+	}
+	data, err := minlz.Decode(nil, block)
+	if err != nil {
+		return errDecoder{fmt.Errorf("cannot decompress block: %w", err)}
+	}
+
+	return gob.NewDecoder(bytes.NewReader(data))
+}
+
+func (minLZBlockGobCodec) compression() Compression {
+	return CompressionMinLZBlock
+}
+
+func (minLZBlockGobCodec) compressWriter(w io.Writer) io.WriteCloser {
+	return &blockWriter{w: w}
+}
+
+// blockWriter buffers everything written to it and writes it to w as a
+// single [minlz] block on Close.
+type blockWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (bw *blockWriter) Write(p []byte) (int, error) {
+	return bw.buf.Write(p)
+}
+
+func (bw *blockWriter) Close() error {
+	block, err := minlz.Encode(nil, bw.buf.Bytes(), minlz.LevelBalanced)
+	if err != nil {
+		return fmt.Errorf("cannot compress block: %w", err)
+	}
+	_, err = bw.w.Write(block)
+
+	return err
+}
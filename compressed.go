@@ -0,0 +1,192 @@
+package fastcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/minio/minlz"
+)
+
+// Compressed is a cache that stores its values compressed in memory, trading
+// CPU time on every Set and Get for room for more values.
+//
+// Values are compressed with the compress function given to [NewCompressed]
+// and stored as byte slices in an underlying [Cache], and decompressed on
+// every read. With [WithMaxBytes], the byte limit therefore applies to the
+// compressed values. [MinLZCompress] and [MinLZDecompress] compress []byte
+// values with [minlz].
+type Compressed[K comparable, V any] struct {
+	c          *Cache[K, []byte]
+	compress   func(V) []byte
+	decompress func([]byte) V
+}
+
+// NewCompressed returns a new cache storing values compressed with compress
+// and decompressed with decompress. maxEntries and opts are passed to [New].
+//
+// decompress must return a value equal to the one passed to compress, and
+// must not retain its argument, which is owned by the cache. [WithSizeOf] is
+// not supported, since the underlying cache stores the compressed bytes.
+//
+// NewCompressed returns an error if [New] does.
+func NewCompressed[K comparable, V any](maxEntries int, compress func(V) []byte, decompress func([]byte) V, opts ...Option) (*Compressed[K, V], error) {
+	c, err := New[K, []byte](maxEntries, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Compressed[K, V]{c: c, compress: compress, decompress: decompress}, nil
+}
+
+// Set stores (k, v) in the cache. See [Cache.Set].
+func (cc *Compressed[K, V]) Set(k K, v V) error {
+	return cc.c.Set(k, cc.compress(v))
+}
+
+// SetWithTTL stores (k, v) in the cache for the given ttl. See
+// [Cache.SetWithTTL].
+func (cc *Compressed[K, V]) SetWithTTL(k K, v V, ttl time.Duration) error {
+	return cc.c.SetWithTTL(k, cc.compress(v), ttl)
+}
+
+// Get returns the value for the given key. See [Cache.Get].
+func (cc *Compressed[K, V]) Get(k K) (V, bool) {
+	b, ok := cc.c.Get(k)
+	if !ok {
+		var zero V
+
+		return zero, false
+	}
+
+	return cc.decompress(b), true
+}
+
+// Has returns true if an entry for the given key exists. See [Cache.Has].
+func (cc *Compressed[K, V]) Has(k K) bool {
+	return cc.c.Has(k)
+}
+
+// Delete removes the value for the given key. See [Cache.Delete].
+func (cc *Compressed[K, V]) Delete(k K) (deleted bool) {
+	return cc.c.Delete(k)
+}
+
+// Len returns the number of entries in the cache.
+func (cc *Compressed[K, V]) Len() int {
+	return cc.c.Len()
+}
+
+// Bytes returns the estimated size of the compressed entries. See
+// [Cache.Bytes].
+func (cc *Compressed[K, V]) Bytes() int64 {
+	return cc.c.Bytes()
+}
+
+// All returns an iterator over all key-value pairs in the cache, yielding
+// decompressed values. See [Cache.All].
+func (cc *Compressed[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, b := range cc.c.All() {
+			if !yield(k, cc.decompress(b)) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over all keys in the cache. See [Cache.Keys].
+func (cc *Compressed[K, V]) Keys() iter.Seq[K] {
+	return cc.c.Keys()
+}
+
+// Values returns an iterator over all decompressed values in the cache. See
+// [Cache.Values].
+func (cc *Compressed[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for b := range cc.c.Values() {
+			if !yield(cc.decompress(b)) {
+				return
+			}
+		}
+	}
+}
+
+// SaveTo saves cache data to the given writer. See [Cache.SaveTo].
+//
+// Values are saved compressed as they are stored, so the data must be loaded
+// with [LoadCompressedFrom] and the same decompress function.
+func (cc *Compressed[K, V]) SaveTo(w io.Writer, opts ...SaveOption) error {
+	return cc.c.SaveTo(w, opts...)
+}
+
+// LoadCompressedFrom loads cache data saved by [Compressed.SaveTo] from the
+// given reader. See [LoadFrom] for the capacity of the loaded cache and the
+// errors returned.
+func LoadCompressedFrom[K comparable, V any](r io.Reader, compress func(V) []byte, decompress func([]byte) V) (*Compressed[K, V], error) {
+	c, err := LoadFrom[K, []byte](r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Compressed[K, V]{c: c, compress: compress, decompress: decompress}, nil
+}
+
+// Stats returns a fresh snapshot of the cache stats.
+func (cc *Compressed[K, V]) Stats() Stats {
+	return cc.c.Stats()
+}
+
+// Reset removes all the items from the cache. See [Cache.Reset].
+func (cc *Compressed[K, V]) Reset() {
+	cc.c.Reset()
+}
+
+// Prefixes of values compressed by MinLZCompress.
+const (
+	minLZRaw   byte = 0 // stored as is
+	minLZBlock byte = 1 // a minlz block
+)
+
+// MinLZCompress compresses v as a [minlz] block, for use with
+// [NewCompressed] and [MinLZDecompress].
+//
+// Values that do not shrink, or exceed [minlz.MaxBlockSize], are stored
+// uncompressed, so no value grows by more than one byte.
+func MinLZCompress(v []byte) []byte {
+	if len(v) <= minlz.MaxBlockSize {
+		block, err := minlz.AppendEncoded([]byte{minLZBlock}, v, minlz.LevelFastest)
+		if err == nil && len(block) <= len(v) {
+			// The block is allocated for the worst case, so copy it to
+			// release the unused capacity.
+			return bytes.Clone(block)
+		}
+	}
+
+	return append([]byte{minLZRaw}, v...)
+}
+
+// MinLZDecompress returns a copy of the value compressed by [MinLZCompress].
+//
+// It panics if b was not returned by MinLZCompress.
+func MinLZDecompress(b []byte) []byte {
+	if len(b) == 0 {
+		panic("fastcache: MinLZDecompress: empty input")
+	}
+
+	switch b[0] {
+	case minLZRaw:
+		return bytes.Clone(b[1:])
+	case minLZBlock:
+		v, err := minlz.Decode(nil, b[1:])
+		if err != nil {
+			panic(fmt.Sprintf("fastcache: MinLZDecompress: %s", err))
+		}
+
+		return v
+	default:
+		panic(fmt.Sprintf("fastcache: MinLZDecompress: unknown prefix %d", b[0]))
+	}
+}
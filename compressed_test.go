@@ -0,0 +1,80 @@
+package fastcache
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestCompressed(t *testing.T) {
+	c, err := NewCompressed[string](100, MinLZCompress, MinLZDecompress, WithMaxBytes(1<<20))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	blob := []byte(strings.Repeat(`{"name":"value"},`, 1000))
+	if err := c.Set("blob", blob); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.Set("short", []byte("x")); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	v, ok := c.Get("blob")
+	if !ok || !bytes.Equal(v, blob) {
+		t.Fatalf("unexpected value; got %d bytes, %t; want %d bytes, true", len(v), ok, len(blob))
+	}
+	if n := c.Bytes(); n >= int64(len(blob)) {
+		t.Fatalf("value is not stored compressed; got %d bytes; want less than %d", n, len(blob))
+	}
+
+	// Values are decompressed into fresh slices, so callers may modify them.
+	v[0] = '!'
+	if v, _ := c.Get("blob"); v[0] != '{' {
+		t.Fatal("modifying a returned value changed the stored one")
+	}
+	if v, ok := c.Get("short"); !ok || string(v) != "x" {
+		t.Fatalf("unexpected value for an incompressible value; got (%q, %t); want (%q, true)", v, ok, "x")
+	}
+
+	got := make(map[string]string)
+	for k, v := range c.All() {
+		got[k] = string(v)
+	}
+	want := map[string]string{"blob": string(blob), "short": "x"}
+	if !maps.Equal(got, want) {
+		t.Fatalf("unexpected entries from All; got %d entries; want %d", len(got), len(want))
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	c2, err := LoadCompressedFrom[string](&buf, MinLZCompress, MinLZDecompress)
+	if err != nil {
+		t.Fatalf("LoadCompressedFrom error: %s", err)
+	}
+	defer c2.Reset()
+	if v, ok := c2.Get("blob"); !ok || !bytes.Equal(v, blob) {
+		t.Fatalf("unexpected loaded value; got %d bytes, %t; want %d bytes, true", len(v), ok, len(blob))
+	}
+
+	if _, err := NewCompressed[string](0, MinLZCompress, MinLZDecompress); !errors.Is(err, ErrInvalidMaxEntries) {
+		t.Fatalf("NewCompressed returned error %v; want %v", err, ErrInvalidMaxEntries)
+	}
+}
+
+func TestMinLZCompress(t *testing.T) {
+	for _, v := range [][]byte{nil, []byte("a"), bytes.Repeat([]byte("abc"), 1000)} {
+		b := MinLZCompress(v)
+		if len(b) > len(v)+1 {
+			t.Fatalf("compressed value grew by more than one byte; got %d bytes for %d", len(b), len(v))
+		}
+		if got := MinLZDecompress(b); !bytes.Equal(got, v) {
+			t.Fatalf("unexpected round trip; got %q; want %q", got, v)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package fastcache
+
+import "fmt"
+
+// Compression selects how binary dumps are compressed. It is recorded in the
+// dump header, so that [LoadFrom], [LoadFromFile] and [LoadFromDir] detect it.
+type Compression uint8
+
+const (
+	// CompressionMinLZ serializes entries using [gob] and compresses them with
+	// [minlz], like [MinLZGobCodec]. This is the default.
+	CompressionMinLZ Compression = iota
+
+	// CompressionNone serializes entries using [gob] without compression, like
+	// [GobCodec].
+	CompressionNone
+
+	// CompressionZstd serializes entries using [gob] and compresses them with
+	// zstd, trading save and load speed for smaller dumps.
+	//
+	// Zstd support is only compiled in with the fastcache_zstd build tag, so
+	// that other programs do not link the zstd package. Without it, saving
+	// and loading zstd dumps fails with [ErrUnsupportedCompression].
+	CompressionZstd
+
+	// CompressionMinLZBlock serializes entries using [gob] and compresses
+	// them as a single [minlz] block, as produced by [minlz.Encode], instead
+	// of the [minlz] stream format, so that tools expecting the block format
+	// can decode the payload.
+	//
+	// The whole gob stream is buffered in memory before it is compressed,
+	// and decompressed in one piece on load, which needs about twice the
+	// memory of the other compressions. A block holds at most
+	// [minlz.MaxBlockSize] bytes of uncompressed data, so saving larger
+	// caches fails.
+	CompressionMinLZBlock
+)
+
+// compressionCodec marks dumps written with a [Codec] that does not map to a
+// [Compression], and headerless dumps of earlier releases. They are loaded
+// with the codec given by the caller.
+const compressionCodec Compression = 0xff
+
+// String returns the compression name.
+func (c Compression) String() string {
+	switch c {
+	case CompressionMinLZ:
+		return "minlz"
+	case CompressionNone:
+		return "none"
+	case CompressionZstd:
+		return "zstd"
+	case CompressionMinLZBlock:
+		return "minlz-block"
+	case compressionCodec:
+		return "codec"
+	default:
+		return fmt.Sprintf("Compression(%d)", uint8(c))
+	}
+}
+
+// compressionCodecs holds the codecs of the compressions supported by this
+// build. The zstd codec is registered by zstd.go.
+var compressionCodecs = map[Compression]Codec{
+	CompressionMinLZ:      MinLZGobCodec{},
+	CompressionNone:       GobCodec{},
+	CompressionMinLZBlock: minLZBlockGobCodec{},
+}
+
+// codecFor returns the codec for compression c.
+func codecFor(c Compression) (Codec, error) {
+	codec, ok := compressionCodecs[c]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, c)
+	}
+
+	return codec, nil
+}
+
+// compressor is implemented by the codecs behind a [Compression].
+type compressor interface {
+	compression() Compression
+}
+
+// compressionOf returns the compression recorded for dumps written with
+// codec.
+func compressionOf(codec Codec) Compression {
+	if c, ok := codec.(compressor); ok {
+		return c.compression()
+	}
+
+	return compressionCodec
+}
+
+// SaveOption configures how cache data is saved.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	compression Compression
+	stats       bool
+	durable     bool
+}
+
+// WithCompression sets the compression of saved data. The default is
+// [CompressionMinLZ].
+func WithCompression(c Compression) SaveOption {
+	return func(o *saveOptions) {
+		o.compression = c
+	}
+}
+
+// WithSavedStats saves the stats counters of the cache along with its
+// entries, so that the cache loaded from the data continues counting from
+// them, e.g. to keep lifetime metrics across restarts.
+//
+// The counters are restored as cache-wide totals, which [Cache.Stats]
+// includes but [Cache.ShardStats] does not, since the per-shard split of the
+// saved counts is not kept. The Set calls that store the loaded entries are
+// not counted. Without WithSavedStats, a loaded cache starts counting from
+// the Set calls that store its entries.
+func WithSavedStats() SaveOption {
+	return func(o *saveOptions) {
+		o.stats = true
+	}
+}
+
+// WithDurableSave makes [Cache.SaveToFile] and [Cache.SaveToDir] flush
+// each file to stable storage before renaming it into place, and flush its
+// directory after the rename, so that a crash cannot leave a truncated or
+// empty file behind. This makes saves slower. Without WithDurableSave, the
+// rename is still atomic, but the data may not have reached the disk when
+// the save returns.
+//
+// WithDurableSave has no effect on [Cache.SaveTo].
+func WithDurableSave() SaveOption {
+	return func(o *saveOptions) {
+		o.durable = true
+	}
+}
+
+// saveCodec returns the codec and the options selected by opts.
+func saveCodec(opts []SaveOption) (Codec, saveOptions, error) {
+	var o saveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	codec, err := codecFor(o.compression)
+
+	return codec, o, err
+}
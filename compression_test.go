@@ -0,0 +1,195 @@
+package fastcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/minio/minlz"
+)
+
+func TestSaveLoadCompression(t *testing.T) {
+	for _, compression := range []Compression{CompressionMinLZ, CompressionNone, CompressionZstd, CompressionMinLZBlock} {
+		t.Run(compression.String(), func(t *testing.T) {
+			c, err := New[string, string](100)
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			for i := range 50 {
+				if err := c.Set(fmt.Sprintf("key %d", i), "value"); err != nil {
+					t.Fatalf("Set error: %s", err)
+				}
+			}
+
+			var buf bytes.Buffer
+			err = c.SaveTo(&buf, WithCompression(compression))
+			if _, supported := compressionCodecs[compression]; !supported {
+				if !errors.Is(err, ErrUnsupportedCompression) {
+					t.Fatalf("SaveTo returned error %v; want %v", err, ErrUnsupportedCompression)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaveTo error: %s", err)
+			}
+			if got := Compression(buf.Bytes()[5]); got != compression {
+				t.Fatalf("unexpected compression in header; got %s; want %s", got, compression)
+			}
+
+			// The compression is detected on load.
+			c1, err := LoadFrom[string, string](&buf)
+			if err != nil {
+				t.Fatalf("LoadFrom error: %s", err)
+			}
+			defer c1.Reset()
+
+			if c1.Len() != 50 {
+				t.Fatalf("unexpected len; got %d; want 50", c1.Len())
+			}
+
+			filePath := filepath.Join(t.TempDir(), "cache.fastcache")
+			if err := c.SaveToFile(filePath, WithCompression(compression)); err != nil {
+				t.Fatalf("SaveToFile error: %s", err)
+			}
+			c2, err := LoadFromFile[string, string](filePath)
+			if err != nil {
+				t.Fatalf("LoadFromFile error: %s", err)
+			}
+			defer c2.Reset()
+
+			if v, ok := c2.Get("key 7"); !ok || v != "value" {
+				t.Fatalf("unexpected value; got (%q, %t); want (%q, true)", v, ok, "value")
+			}
+		})
+	}
+}
+
+func TestSaveMinLZBlock(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("key", strings.Repeat("value", 100)); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf, WithCompression(CompressionMinLZBlock)); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+
+	// The payload is a plain block that other tools can decode.
+	data, err := minlz.Decode(nil, buf.Bytes()[headerSize:])
+	if err != nil {
+		t.Fatalf("cannot decode payload as a minlz block: %s", err)
+	}
+	dec := GobCodec{}.NewDecoder(bytes.NewReader(data))
+	var maxEntries int
+	if err := dec.Decode(&maxEntries); err != nil || maxEntries != 100 {
+		t.Fatalf("unexpected maxEntries in block; got (%d, %v); want (100, nil)", maxEntries, err)
+	}
+
+	// A block cannot hold more than minlz.MaxBlockSize bytes.
+	for i := range 9 {
+		if err := c.Set(fmt.Sprintf("big %d", i), strings.Repeat("x", 1<<20)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.SaveTo(io.Discard, WithCompression(CompressionMinLZBlock)); err == nil {
+		t.Fatal("SaveTo must fail for data larger than a block")
+	}
+}
+
+func TestLoadUnsupportedCompression(t *testing.T) {
+	c, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+
+	data := buf.Bytes()
+	data[5] = byte(CompressionZstd)
+	if _, supported := compressionCodecs[CompressionZstd]; supported {
+		data[5] = 0x7f
+	}
+
+	if _, err := LoadFrom[string, string](bytes.NewReader(data)); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("LoadFrom returned error %v; want %v", err, ErrUnsupportedCompression)
+	}
+}
+
+func TestSaveToWithReport(t *testing.T) {
+	c, err := New[string, string](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 500 {
+		if err := c.Set(fmt.Sprintf("key %d", i), strings.Repeat("value", 20)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	var plain bytes.Buffer
+	uncompressed, compressed, err := c.SaveToWithReport(&plain, WithCompression(CompressionNone))
+	if err != nil {
+		t.Fatalf("SaveToWithReport error: %s", err)
+	}
+	if uncompressed != compressed {
+		t.Fatalf("unexpected sizes without compression; got %d and %d; want equal", uncompressed, compressed)
+	}
+	if int(compressed)+headerSize != plain.Len() {
+		t.Fatalf("unexpected compressed size; got %d; want %d", compressed, plain.Len()-headerSize)
+	}
+
+	var buf bytes.Buffer
+	u, compressed, err := c.SaveToWithReport(&buf)
+	if err != nil {
+		t.Fatalf("SaveToWithReport error: %s", err)
+	}
+	if u != uncompressed {
+		t.Fatalf("uncompressed size depends on the compression; got %d; want %d", u, uncompressed)
+	}
+	if int(compressed)+headerSize != buf.Len() {
+		t.Fatalf("unexpected compressed size; got %d; want %d", compressed, buf.Len()-headerSize)
+	}
+	if compressed >= uncompressed {
+		t.Fatalf("repetitive values did not compress; got %d of %d bytes", compressed, uncompressed)
+	}
+
+	// The report does not change the saved data.
+	var want bytes.Buffer
+	if err := c.SaveTo(&want); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want.Bytes()) {
+		t.Fatal("SaveToWithReport wrote other data than SaveTo")
+	}
+	c1, err := LoadFrom[string, string](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c1.Reset()
+	if c1.Len() != 500 {
+		t.Fatalf("unexpected len; got %d; want 500", c1.Len())
+	}
+
+	if _, _, err := c.SaveToWithReport(&buf, WithCompression(0x7f)); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("SaveToWithReport returned error %v; want %v", err, ErrUnsupportedCompression)
+	}
+}
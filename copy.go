@@ -0,0 +1,47 @@
+package fastcache
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// valueCopyFromOptions returns the function that copies the values stored in
+// the cache, or nil if they are stored as is; see WithValueCopy.
+func valueCopyFromOptions[V any](o *options) (func(V) V, error) {
+	if o.valueCopyFunc != nil {
+		fn, ok := o.valueCopyFunc.(func(V) V)
+		if !ok {
+			var zero func(V) V
+
+			return nil, fmt.Errorf("%w: got %T; want %T", ErrInvalidValueCopy, o.valueCopyFunc, zero)
+		}
+
+		return fn, nil
+	}
+	if !o.valueCopy {
+		return nil, nil
+	}
+
+	// V is []byte exactly when bytes.Clone has the type func(V) V.
+	if fn, ok := any(bytes.Clone).(func(V) V); ok {
+		return fn, nil
+	}
+
+	var zero V
+	if _, ok := any(zero).(string); ok {
+		// Strings are immutable, so they need no copy.
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("%w: got %T; pass WithValueCopyFunc", ErrInvalidValueCopy, zero)
+}
+
+// ownValue returns the value to store for v: a copy of it if the cache copies
+// values, or v itself.
+func (c *Cache[K, V]) ownValue(v V) V {
+	if c.copyValue == nil {
+		return v
+	}
+
+	return c.copyValue(v)
+}
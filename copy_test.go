@@ -0,0 +1,96 @@
+package fastcache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCacheWithValueCopy(t *testing.T) {
+	c, err := New[int, []byte](10, WithValueCopy())
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	b := []byte("abc")
+	if err := c.Set(1, b); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	b[0] = 'x'
+	if v, _ := c.Get(1); string(v) != "abc" {
+		t.Fatalf("unexpected value after modifying the original; got %q; want %q", v, "abc")
+	}
+
+	// Overwrites are copied as well.
+	if err := c.Set(1, b); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	b[0] = 'y'
+	if v, _ := c.Get(1); string(v) != "xbc" {
+		t.Fatalf("unexpected value after modifying the original; got %q; want %q", v, "xbc")
+	}
+
+	// A clone does not share the values either.
+	clone := c.Clone()
+	defer clone.Reset()
+	v, _ := clone.Get(1)
+	v[0] = 'z'
+	if v, _ := c.Get(1); string(v) != "xbc" {
+		t.Fatalf("unexpected value after modifying the clone; got %q; want %q", v, "xbc")
+	}
+}
+
+func TestCacheWithoutValueCopy(t *testing.T) {
+	c, err := New[int, []byte](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	b := []byte("abc")
+	if err := c.Set(1, b); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	b[0] = 'x'
+	if v, _ := c.Get(1); string(v) != "xbc" {
+		t.Fatalf("unexpected value; got %q; want the stored slice to be shared", v)
+	}
+}
+
+func TestCacheWithValueCopyFunc(t *testing.T) {
+	type value struct {
+		tags []string
+	}
+
+	c, err := New[int, value](10, WithValueCopyFunc(func(v value) value {
+		return value{tags: append([]string(nil), v.tags...)}
+	}))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	v := value{tags: []string{"a"}}
+	if err := c.Set(1, v); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	v.tags[0] = "b"
+	if got, _ := c.Get(1); got.tags[0] != "a" {
+		t.Fatalf("unexpected value after modifying the original; got %q; want %q", got.tags[0], "a")
+	}
+}
+
+func TestCacheWithValueCopyInvalid(t *testing.T) {
+	if _, err := New[int, []int](10, WithValueCopy()); !errors.Is(err, ErrInvalidValueCopy) {
+		t.Fatalf("unexpected error for an uncopyable type; got %v; want %v", err, ErrInvalidValueCopy)
+	}
+	if _, err := New[int, []byte](10, WithValueCopyFunc(func(v string) string { return v })); !errors.Is(err, ErrInvalidValueCopy) {
+		t.Fatalf("unexpected error for a mismatched function; got %v; want %v", err, ErrInvalidValueCopy)
+	}
+
+	c, err := New[int, string](10, WithValueCopy())
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	c.Reset()
+}
@@ -0,0 +1,37 @@
+package fastcache
+
+import "fmt"
+
+// SetWithCost stores (k, v) in the cache with the given cost.
+//
+// The cost counts against the limit set with [WithMaxCost]; entries stored
+// with [Cache.Set] and the other operations cost 1. Setting an existing key
+// replaces its cost, and may evict the oldest entries if the total cost then
+// exceeds the limit. Like [Cache.Set], SetWithCost clears the expiration of
+// an existing entry.
+//
+// The cost of an entry is saved and restored with it by [Cache.SaveTo] and
+// the other save methods.
+//
+// SetWithCost returns an error if cost is not positive, if it exceeds the
+// cost limit, or if the cache cannot evict an existing entry while full.
+func (c *Cache[K, V]) SetWithCost(k K, v V, cost int64) error {
+	if cost <= 0 {
+		return fmt.Errorf("%w: got %d", ErrInvalidCost, cost)
+	}
+	if c.maxCost > 0 && cost > c.maxCost {
+		return fmt.Errorf("%w: entry cost=%d, max cost=%d", ErrCostTooLarge, cost, c.maxCost)
+	}
+
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].set(c, idx, h, k, v, expiry{}, cost)
+}
+
+// Cost returns the total cost of all entries in the cache.
+//
+// Without [Cache.SetWithCost] this equals [Cache.Len].
+func (c *Cache[K, V]) Cost() int64 {
+	return c.cost.Load()
+}
@@ -0,0 +1,161 @@
+package fastcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestCacheMaxCost(t *testing.T) {
+	c, err := New[string, string](100, WithMaxCost(10))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 3 {
+		if err := c.SetWithCost(fmt.Sprintf("key %d", i), "value", 4); err != nil {
+			t.Fatalf("SetWithCost error: %s", err)
+		}
+	}
+
+	// 3 * 4 exceeds the limit, so the oldest entry must be gone
+	if c.Has("key 0") {
+		t.Fatal("oldest entry was not evicted when the cost limit was exceeded")
+	}
+	if got := c.Cost(); got != 8 {
+		t.Fatalf("unexpected cost; got %d; want 8", got)
+	}
+
+	// Plain Set costs 1
+	if err := c.Set("cheap", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if got := c.Cost(); got != 9 {
+		t.Fatalf("unexpected cost after Set; got %d; want 9", got)
+	}
+
+	// Raising the cost of an existing entry evicts older entries
+	if err := c.SetWithCost("cheap", "value", 6); err != nil {
+		t.Fatalf("SetWithCost error: %s", err)
+	}
+	if c.Has("key 1") {
+		t.Fatal("older entry was not evicted after an in-place update exceeded the cost limit")
+	}
+	if got := c.Cost(); got != 10 {
+		t.Fatalf("unexpected cost after in-place update; got %d; want 10", got)
+	}
+
+	c.Delete("cheap")
+	if got := c.Cost(); got != 4 {
+		t.Fatalf("unexpected cost after delete; got %d; want 4", got)
+	}
+
+	if err := c.SetWithCost("huge", "value", 11); !errors.Is(err, ErrCostTooLarge) {
+		t.Fatalf("SetWithCost returned error %v; want %v", err, ErrCostTooLarge)
+	}
+	if err := c.SetWithCost("free", "value", 0); !errors.Is(err, ErrInvalidCost) {
+		t.Fatalf("SetWithCost returned error %v; want %v", err, ErrInvalidCost)
+	}
+
+	s := c.Stats()
+	if s.TotalCost != 4 || s.MaxCost != 10 {
+		t.Fatalf("unexpected cost stats; got TotalCost=%d, MaxCost=%d; want 4, 10", s.TotalCost, s.MaxCost)
+	}
+	if s.EvictionsCost != 2 || s.EvictionsCapacity != 0 || s.Evictions != 2 {
+		t.Fatalf("unexpected eviction stats; got EvictionsCost=%d, EvictionsCapacity=%d, Evictions=%d; want 2, 0, 2", s.EvictionsCost, s.EvictionsCapacity, s.Evictions)
+	}
+}
+
+func TestCacheCostWithoutMaxCost(t *testing.T) {
+	c, err := New[int, int](3)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 5 {
+		if err := c.SetWithCost(i, i, 100); err != nil {
+			t.Fatalf("SetWithCost error: %s", err)
+		}
+	}
+
+	// Without a cost limit only maxEntries applies
+	if c.Len() != 3 {
+		t.Fatalf("unexpected len; got %d; want 3", c.Len())
+	}
+	if got := c.Cost(); got != 300 {
+		t.Fatalf("unexpected cost; got %d; want 300", got)
+	}
+}
+
+func TestCacheMaxCostNearMaxInt64(t *testing.T) {
+	c, err := New[string, int](10, WithMaxCost(math.MaxInt64))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.SetWithCost("big", 1, math.MaxInt64); err != nil {
+		t.Fatalf("SetWithCost error: %s", err)
+	}
+	// Summing the costs would wrap around and skip the eviction
+	if err := c.SetWithCost("small", 2, 1); err != nil {
+		t.Fatalf("SetWithCost error: %s", err)
+	}
+	if c.Has("big") {
+		t.Fatal("big entry should have been evicted to make room")
+	}
+	if c.Cost() != 1 {
+		t.Fatalf("unexpected total cost; got %d; want 1", c.Cost())
+	}
+}
+
+func TestNewReturnsErrorForInvalidMaxCost(t *testing.T) {
+	if _, err := New[string, string](10, WithMaxCost(-1)); !errors.Is(err, ErrInvalidMaxCost) {
+		t.Fatalf("New returned error %v; want %v", err, ErrInvalidMaxCost)
+	}
+}
+
+func TestSaveLoadKeepsCost(t *testing.T) {
+	c, err := New[string, string](100, WithMaxCost(100))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.SetWithCost("heavy", "value", 10); err != nil {
+		t.Fatalf("SetWithCost error: %s", err)
+	}
+	if err := c.Set("light", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	c2, err := LoadFrom[string, string](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c2.Reset()
+	if got := c2.Cost(); got != 11 {
+		t.Fatalf("unexpected cost after LoadFrom; got %d; want 11", got)
+	}
+
+	buf.Reset()
+	if err := c.SaveToJSON(&buf); err != nil {
+		t.Fatalf("SaveToJSON error: %s", err)
+	}
+	c3, err := LoadFromJSON[string, string](&buf)
+	if err != nil {
+		t.Fatalf("LoadFromJSON error: %s", err)
+	}
+	defer c3.Reset()
+	if got := c3.Cost(); got != 11 {
+		t.Fatalf("unexpected cost after LoadFromJSON; got %d; want 11", got)
+	}
+}
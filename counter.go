@@ -0,0 +1,82 @@
+package fastcache
+
+// Counter is a cache of int64 counters, e.g. for rate limiting or hit
+// counting.
+//
+// Every update is applied with [Add] under the shard lock, so concurrent
+// updates of the same key never lose increments. The counters are ordinary
+// cache entries, though: a counter that is evicted or expires is gone, and
+// the next update starts again from zero. Size the cache so that counters
+// are not evicted if they must not reset.
+type Counter[K comparable] struct {
+	c *Cache[K, int64]
+}
+
+// NewCounter returns a new counter cache. maxEntries and opts are passed to
+// [New].
+//
+// NewCounter returns an error if [New] does.
+func NewCounter[K comparable](maxEntries int, opts ...Option) (*Counter[K], error) {
+	c, err := New[K, int64](maxEntries, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Counter[K]{c: c}, nil
+}
+
+// Inc increments the counter for k and returns its new value.
+//
+// Inc returns an error if the cache cannot evict an existing entry while
+// full.
+func (cc *Counter[K]) Inc(k K) (int64, error) {
+	return Add(cc.c, k, 1)
+}
+
+// Dec decrements the counter for k and returns its new value.
+//
+// Dec returns an error if the cache cannot evict an existing entry while
+// full.
+func (cc *Counter[K]) Dec(k K) (int64, error) {
+	return Add(cc.c, k, -1)
+}
+
+// Add adds delta to the counter for k and returns its new value. A missing
+// counter is treated as zero. See [Add].
+//
+// Add returns an error if the cache cannot evict an existing entry while
+// full.
+func (cc *Counter[K]) Add(k K, delta int64) (int64, error) {
+	return Add(cc.c, k, delta)
+}
+
+// Get returns the value of the counter for k, or zero if it is missing.
+//
+// Like [Cache.Get], Get counts as a Get call in [Stats], and a missing
+// counter is a miss.
+func (cc *Counter[K]) Get(k K) int64 {
+	v, _ := cc.c.Get(k)
+
+	return v
+}
+
+// Delete removes the counter for k, resetting it to zero. See
+// [Cache.Delete].
+func (cc *Counter[K]) Delete(k K) (deleted bool) {
+	return cc.c.Delete(k)
+}
+
+// Len returns the number of counters in the cache.
+func (cc *Counter[K]) Len() int {
+	return cc.c.Len()
+}
+
+// Stats returns a fresh snapshot of the cache stats.
+func (cc *Counter[K]) Stats() Stats {
+	return cc.c.Stats()
+}
+
+// Reset removes all the counters from the cache. See [Cache.Reset].
+func (cc *Counter[K]) Reset() {
+	cc.c.Reset()
+}
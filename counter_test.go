@@ -0,0 +1,83 @@
+package fastcache
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	c, err := NewCounter[string](2)
+	if err != nil {
+		t.Fatalf("NewCounter error: %s", err)
+	}
+	defer c.Reset()
+
+	if v := c.Get("hits"); v != 0 {
+		t.Fatalf("unexpected value of a missing counter; got %d; want 0", v)
+	}
+
+	steps := []struct {
+		name string
+		fn   func() (int64, error)
+		want int64
+	}{
+		{"Inc", func() (int64, error) { return c.Inc("hits") }, 1},
+		{"Inc", func() (int64, error) { return c.Inc("hits") }, 2},
+		{"Add", func() (int64, error) { return c.Add("hits", 10) }, 12},
+		{"Dec", func() (int64, error) { return c.Dec("hits") }, 11},
+	}
+	for _, step := range steps {
+		v, err := step.fn()
+		if err != nil {
+			t.Fatalf("%s error: %s", step.name, err)
+		}
+		if v != step.want {
+			t.Fatalf("unexpected value after %s; got %d; want %d", step.name, v, step.want)
+		}
+	}
+	if v := c.Get("hits"); v != 11 {
+		t.Fatalf("unexpected value; got %d; want 11", v)
+	}
+
+	// Evicting a counter resets it.
+	for _, k := range []string{"a", "b"} {
+		if _, err := c.Inc(k); err != nil {
+			t.Fatalf("Inc error: %s", err)
+		}
+	}
+	if v := c.Get("hits"); v != 0 {
+		t.Fatalf("unexpected value of an evicted counter; got %d; want 0", v)
+	}
+	if n := c.Len(); n != 2 {
+		t.Fatalf("unexpected len; got %d; want 2", n)
+	}
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	c, err := NewCounter[int](10)
+	if err != nil {
+		t.Fatalf("NewCounter error: %s", err)
+	}
+	defer c.Reset()
+
+	const workers, incs = 8, 1000
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range incs {
+				if _, err := c.Inc(1); err != nil {
+					t.Errorf("Inc error: %s", err)
+
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v := c.Get(1); v != workers*incs {
+		t.Fatalf("unexpected value; got %d; want %d", v, workers*incs)
+	}
+}
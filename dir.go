@@ -0,0 +1,217 @@
+package fastcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// manifestFile is the name of the manifest written by [Cache.SaveToDir].
+const manifestFile = "manifest.json"
+
+// manifestVersion is the version of the manifest format.
+const manifestVersion = 1
+
+// manifest describes a cache saved by [Cache.SaveToDir].
+type manifest struct {
+	Version    int `json:"version"`
+	MaxEntries int `json:"max_entries"`
+	Shards     int `json:"shards"`
+	Entries    int `json:"entries"`
+
+	// Stats are the counters saved with WithSavedStats, if any. They are
+	// kept here rather than split across the shard files.
+	Stats *savedStats `json:"stats,omitempty"`
+}
+
+// shardFileName returns the name of the file holding the entries of shard i.
+func shardFileName(i int) string {
+	return fmt.Sprintf("shard-%03d.fastcache", i)
+}
+
+// SaveToDir saves cache data to dir, writing one file per shard plus a
+// manifest recording the cache capacity and shard count.
+//
+// Splitting the data keeps each file small for huge caches, and the shards
+// are encoded and written by the specified number of concurrent workers.
+// Each shard file is written atomically in the [Cache.SaveToFile] format, so
+// a single shard file may also be loaded with [LoadFromFile]. opts apply to
+// every shard file. The manifest is
+// written last, once all shard files are in place.
+//
+// Entries are stored in eviction order within each shard file, but the order
+// across shards is not preserved.
+//
+// Like [Cache.SaveTo], each shard file is buffered in memory while it is
+// encoded and read back whole by [LoadFromDir], so saving or loading needs
+// extra memory of up to concurrency times the size of a shard file. This is
+// much less than for a single file.
+//
+// SaveToDir may be called concurrently with other ops on the cache.
+//
+// The saved data may be loaded with [LoadFromDir].
+func (c *Cache[K, V]) SaveToDir(dir string, concurrency int, opts ...SaveOption) error {
+	codec, o, err := saveCodec(opts)
+	if err != nil {
+		return err
+	}
+
+	gomaxprocs := runtime.GOMAXPROCS(-1)
+	if concurrency <= 0 || concurrency > gomaxprocs {
+		concurrency = gomaxprocs
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create dir %q: %w", dir, err)
+	}
+
+	var stats *savedStats
+	if o.stats {
+		saved := savedStatsOf(c.Stats())
+		stats = &saved
+	}
+
+	var groups [shardsCount][]*node[K]
+	for _, n := range c.orderedNodes() {
+		groups[n.shard] = append(groups[n.shard], n)
+	}
+
+	var (
+		counts [shardsCount]int
+		errs   [shardsCount]error
+	)
+	shardCh := make(chan int, shardsCount)
+	for i := range shardsCount {
+		shardCh <- i
+	}
+	close(shardCh)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range shardCh {
+				entries := c.resolveNodes(groups[i])
+				counts[i] = len(entries)
+				errs[i] = writeFile(filepath.Join(dir, shardFileName(i)), o.durable, func(w io.Writer) error {
+					return writeDump(context.Background(), w, codec, c.maxEntries, [][]savedEntry[K, V]{entries}, savedStats{})
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	m := manifest{
+		Version:    manifestVersion,
+		MaxEntries: c.maxEntries,
+		Shards:     shardsCount,
+		Stats:      stats,
+	}
+	for i := range shardsCount {
+		if errs[i] != nil {
+			return fmt.Errorf("cannot save shard %d: %w", i, errs[i])
+		}
+		m.Entries += counts[i]
+	}
+
+	return writeFile(filepath.Join(dir, manifestFile), o.durable, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(m)
+	})
+}
+
+// LoadFromDir loads cache data saved by [Cache.SaveToDir] from dir.
+//
+// The loaded cache has the saved capacity, or more if the data holds more
+// entries than that, so that no entry is evicted while loading.
+//
+// Returns an error wrapping [ErrShardCountMismatch] if the manifest records
+// a shard count other than the one of this package, and [ErrCorruptedData]
+// if a shard file is missing entries recorded in the manifest or fails the
+// checksum.
+func LoadFromDir[K comparable, V any](dir string) (*Cache[K, V], error) {
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := New[K, V](max(m.MaxEntries, m.Entries), entriesSizeHint(m.Entries)...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cache: %w", err)
+	}
+
+	total := 0
+	for i := range m.Shards {
+		n, err := loadShardFile(filepath.Join(dir, shardFileName(i)), c)
+		if err != nil {
+			c.Reset()
+
+			return nil, fmt.Errorf("cannot load shard %d: %w", i, err)
+		}
+		total += n
+	}
+
+	if total != m.Entries {
+		c.Reset()
+
+		return nil, fmt.Errorf("%w: got %d entries; manifest records %d", ErrCorruptedData, total, m.Entries)
+	}
+	if m.Stats != nil {
+		c.restoreStats(*m.Stats)
+	}
+
+	return c, nil
+}
+
+// readManifest reads and validates the manifest in dir.
+func readManifest(dir string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: cannot decode manifest: %w", ErrCorruptedData, err)
+	}
+
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("%w: got manifest version %d; want %d", ErrUnsupportedVersion, m.Version, manifestVersion)
+	}
+	if m.Shards != shardsCount {
+		return nil, fmt.Errorf("%w: got %d; want %d", ErrShardCountMismatch, m.Shards, shardsCount)
+	}
+	if m.Entries < 0 {
+		return nil, fmt.Errorf("%w: negative entry count %d", ErrCorruptedData, m.Entries)
+	}
+
+	return &m, nil
+}
+
+// loadShardFile decodes the shard file at filePath into c and returns the
+// number of entries it holds.
+func loadShardFile[K comparable, V any](filePath string, c *Cache[K, V]) (int, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	d, err := openDump(f, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := decodeEntries(d, c); err != nil {
+		return 0, err
+	}
+
+	return d.totalEntries, nil
+}
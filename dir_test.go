@@ -0,0 +1,199 @@
+package fastcache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadDir(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("concurrency_%d", concurrency), func(t *testing.T) {
+			dir := t.TempDir()
+
+			const itemsCount = 5000
+			c, err := New[string, int](itemsCount + 100)
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			for i := range itemsCount {
+				if err := c.Set(fmt.Sprintf("key %d", i), i); err != nil {
+					t.Fatalf("Set error: %s", err)
+				}
+			}
+			if err := c.SaveToDir(dir, concurrency); err != nil {
+				t.Fatalf("SaveToDir error: %s", err)
+			}
+
+			for i := range shardsCount {
+				if _, err := os.Stat(filepath.Join(dir, shardFileName(i))); err != nil {
+					t.Fatalf("missing shard file %d: %s", i, err)
+				}
+			}
+
+			c1, err := LoadFromDir[string, int](dir)
+			if err != nil {
+				t.Fatalf("LoadFromDir error: %s", err)
+			}
+			defer c1.Reset()
+
+			if c1.initialCapacity != itemsCount {
+				t.Fatalf("unexpected size hint; got %d; want %d", c1.initialCapacity, itemsCount)
+			}
+			if c1.Capacity() != c.Capacity() {
+				t.Fatalf("unexpected capacity; got %d; want %d", c1.Capacity(), c.Capacity())
+			}
+			if c1.Len() != itemsCount {
+				t.Fatalf("unexpected len; got %d; want %d", c1.Len(), itemsCount)
+			}
+			for i := range itemsCount {
+				k := fmt.Sprintf("key %d", i)
+				if v, ok := c1.Get(k); !ok || v != i {
+					t.Fatalf("unexpected value for %q; got (%d, %t); want (%d, true)", k, v, ok, i)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveToDirManifestKeys(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set(1, 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	c.Get(1)
+
+	dir := t.TempDir()
+	if err := c.SaveToDir(dir, 1, WithSavedStats()); err != nil {
+		t.Fatalf("SaveToDir error: %s", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		t.Fatalf("ReadFile error: %s", err)
+	}
+
+	var m struct {
+		MaxEntries int            `json:"max_entries"`
+		Stats      map[string]any `json:"stats"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if m.MaxEntries != 10 {
+		t.Fatalf("unexpected max_entries in %s; got %d; want 10", data, m.MaxEntries)
+	}
+	for _, key := range []string{"saved", "get_calls", "set_calls", "evictions_capacity", "eviction_drops"} {
+		if _, ok := m.Stats[key]; !ok {
+			t.Fatalf("missing stats key %q in %s", key, data)
+		}
+	}
+}
+
+func TestLoadShardFile(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 100 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.SaveToDir(dir, 1); err != nil {
+		t.Fatalf("SaveToDir error: %s", err)
+	}
+
+	// Each shard file is a regular dump.
+	total := 0
+	for i := range shardsCount {
+		c1, err := LoadFromFile[int, int](filepath.Join(dir, shardFileName(i)))
+		if err != nil {
+			t.Fatalf("LoadFromFile error for shard %d: %s", i, err)
+		}
+		total += c1.Len()
+		c1.Reset()
+	}
+	if total != 100 {
+		t.Fatalf("unexpected total entries across shard files; got %d; want 100", total)
+	}
+}
+
+func TestLoadFromDirErrors(t *testing.T) {
+	save := func(t *testing.T) string {
+		t.Helper()
+
+		dir := t.TempDir()
+		c, err := New[int, int](100)
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+		defer c.Reset()
+
+		for i := range 100 {
+			if err := c.Set(i, i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+		if err := c.SaveToDir(dir, 1); err != nil {
+			t.Fatalf("SaveToDir error: %s", err)
+		}
+
+		return dir
+	}
+
+	t.Run("missing manifest", func(t *testing.T) {
+		dir := save(t)
+		if err := os.Remove(filepath.Join(dir, manifestFile)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromDir[int, int](dir); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("LoadFromDir must return os.ErrNotExist; got: %v", err)
+		}
+	})
+
+	t.Run("shard count mismatch", func(t *testing.T) {
+		dir := save(t)
+		manifest := fmt.Sprintf(`{"version":1,"max_entries":100,"shards":%d,"entries":100}`, shardsCount/2)
+		if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromDir[int, int](dir); !errors.Is(err, ErrShardCountMismatch) {
+			t.Fatalf("LoadFromDir must return ErrShardCountMismatch; got: %v", err)
+		}
+	})
+
+	t.Run("missing shard file", func(t *testing.T) {
+		dir := save(t)
+		if err := os.Remove(filepath.Join(dir, shardFileName(7))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromDir[int, int](dir); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("LoadFromDir must return os.ErrNotExist; got: %v", err)
+		}
+	})
+
+	t.Run("entry count mismatch", func(t *testing.T) {
+		dir := save(t)
+		manifest := fmt.Sprintf(`{"version":1,"max_entries":100,"shards":%d,"entries":99}`, shardsCount)
+		if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFromDir[int, int](dir); !errors.Is(err, ErrCorruptedData) {
+			t.Fatalf("LoadFromDir must return ErrCorruptedData; got: %v", err)
+		}
+	})
+}
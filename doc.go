@@ -1,51 +1,219 @@
 // Package fastcache provides a fast, generic, thread-safe in-memory cache
-// with FIFO eviction.
+// with FIFO or LRU eviction.
 //
 // This is a fork of [VictoriaMetrics/fastcache] with a redesigned API using
 // Go generics.
 //
 // # Architecture
 //
-// The cache uses a sharded design with 512 shards, each with its own lock.
-// This reduces contention on multi-core CPUs. Each shard contains:
+// The cache uses a sharded design with 512 shards, each with its own lock
+// unless [WithLockStripes] lets shards share locks. This reduces contention
+// on multi-core CPUs. Each shard contains:
 //
 //   - A map[K]V for O(1) lookups.
-//   - A ring buffer tracking insertion order for FIFO eviction.
 //
-// Keys are distributed across shards using rapidhash-based shard hashing.
+// The maps are preallocated for maxEntries entries in total; pass
+// [WithInitialCapacity] to start them smaller and let them grow on demand.
+// Call [Cache.Reset] to release them; [WithLeakCheck] logs caches that are
+// garbage collected without it. [Cache.Clear] empties the cache but keeps
+// the maps allocated for refilling it.
+//
+// Keys are distributed across shards using rapidhash-based shard hashing,
+// with a fixed seed by default; see [WithHashSeed].
+// A cache-wide intrusive doubly-linked list tracks eviction order; every
+// entry references its list node, so entries can be promoted or unlinked in
+// O(1).
+//
+// Under the default FIFO policy, lookups and overwrites of existing keys only
+// take the lock of their shard. Inserts of new keys, and all accesses under
+// [PolicyLRU], also take the lock of the eviction list, which limits
+// write-heavy workloads more than the number of shard locks does.
+//
+// Values are stored as is, so a []byte, map or pointer value shares its data
+// with the caller that stored it. Pass [WithValueCopy] to store a copy of
+// []byte values instead, or [WithValueCopyFunc] for other value types.
+//
+// Keys must be comparable. For other keys, such as slices, [NewKeyed] creates
+// a [ByKeyFunc] cache that stores entries by a string derived from each key.
+// [NewCompressed] creates a [Compressed] cache that stores values compressed
+// in memory, e.g. with [MinLZCompress] and [MinLZDecompress] for large []byte
+// values.
+//
+// [Cache.AsStore] adapts a cache to the minimal [Store] interface, for code
+// that switches between cache implementations. [Cache.ReadOnly] returns a
+// [ReadOnlyCache] view without methods that modify the cache, for code that
+// must only read it.
 //
 // # Eviction
 //
 // When the cache reaches capacity, the oldest entries are evicted first
-// (FIFO - First In, First Out). There is no time-based expiration; entries
-// are only evicted when space is needed for new entries.
+// (FIFO - First In, First Out). The order is exact across the whole cache,
+// not just within each shard, so the cache can serve as a bounded FIFO
+// buffer.
+//
+// Capacity is counted in entries. Pass [WithMaxBytes] to [New] to also cap
+// the estimated memory usage; entries are then evicted when either limit is
+// exceeded. [Cache.Bytes] reports the current estimate, and
+// [ValueSizeHistogram] shows how the sizes of string or []byte values are
+// distributed.
+//
+// Pass [WithMaxCost] to cap the total cost of entries instead, where each
+// entry stored with [Cache.SetWithCost] carries its own cost and all other
+// entries cost 1.
+//
+// Pass [WithPolicy] with [PolicyLRU] to [New] to evict the least recently
+// used entries instead. Under LRU, hits move the entry to the most recent
+// position.
+//
+// [Cache.Trim] evicts the oldest entries down to a target length without
+// changing the capacity, e.g. ahead of memory pressure. [Cache.EvictOldest]
+// evicts and returns the single oldest entry, e.g. to spill it to disk.
+// [WithEvictionChannel] sends evicted entries to a channel for downstream
+// processing, outside the cache locks.
+//
+// Pass [WithStrictCapacity] to reject new keys with [ErrCacheFull] instead
+// of evicting when the cache is full; [Cache.TrySet] reports whether a key
+// was stored.
+// [Cache.CompactNow] reclaims eviction order nodes left behind by deletes
+// without waiting for the next compaction, and [WithEvictionBatch] bounds
+// how many of them a single insert skips.
+//
+// # Expiration
+//
+// Entries stored with [Cache.SetWithTTL] expire after the given duration and
+// are removed when next accessed. Pass [WithJanitor] to [New] to also purge
+// them periodically in the background; [Cache.Stop] stops the janitor.
+// [Cache.GetWithExpiry] reports when an entry expires, so that it can be
+// refreshed ahead of time. [Cache.SetTTL] attaches a TTL to an existing
+// entry, and [Cache.Touch] renews it, e.g. to keep a session alive.
+// [Cache.GetAndTouch] reads a value and slides its expiration in one step.
+// [Cache.GetStale] also returns values that expired recently, e.g. while the
+// origin is down.
+//
+// [Cache.SetNegative] caches a "not found" result for a key with its own TTL,
+// which [Cache.GetNegative] tells apart from a cache miss.
+//
+// Expiration reads the current time from the clock passed with [WithClock],
+// so tests can advance a fake clock instead of sleeping.
 //
 // # Iteration
 //
 // The cache provides Go 1.23+ iterators for range-based iteration:
 //
 //   - [Cache.All] - iterate over key-value pairs.
+//   - [Cache.AllFunc] - iterate over key-value pairs matching a predicate.
 //   - [Cache.Keys] - iterate over keys only.
 //   - [Cache.Values] - iterate over values only.
+//   - [Cache.AllOrdered] - iterate over key-value pairs in eviction order.
+//   - [Cache.AllWithSeq] - like AllOrdered, with each entry's position and expiry.
+//   - [Cache.Drain] - iterate over key-value pairs, removing each one.
+//
+// [Cache.KeysSlice] and [Cache.ValuesSlice] return the keys or values as a
+// slice, and [Cache.Entries] returns all key-value pairs as a map.
+// [Cache.ForEach] visits all key-value pairs with a fallible callback and
+// stops at the first error, and [Cache.UpdateEach] stores or deletes entries
+// in bulk while visiting them. [Cache.RangeShard] visits a single shard under
+// one lock; use [Cache.ShardIndex] and [Cache.ShardCount] to pick the shard.
+// [Cache.AllParallel] splits the shards among goroutines for full scans that
+// scale with the number of cores.
 //
 // # Atomic Operations
 //
 // The cache provides atomic compound operations:
 //
 //   - [Cache.GetOrSet] - get existing value or store new one.
+//   - [Cache.GetOrCompute] - get existing value or compute and store a new one.
+//   - [Cache.GetOrSetFunc] - like GetOrCompute for computations that cannot fail.
 //   - [Cache.GetAndDelete] - atomically get and remove a value.
 //   - [Cache.SetIfAbsent] - store only if key doesn't exist.
+//   - [Cache.SetIfAbsentReport] - like SetIfAbsent, also reporting evictions.
+//   - [Cache.Replace] - store only if key already exists.
+//   - [Cache.GetAndSet] - store only if key already exists, returning the old value.
+//   - [Cache.Swap] - store a value and return the previous one.
+//   - [CompareAndDelete] - delete only if the value matches.
+//   - [Add] - add a delta to a numeric value, treating missing keys as zero.
+//
+// [Cache.WithLock] locks the shards of several keys at once, so that a
+// function can read and write them atomically through a [Txn], e.g. to move
+// a value from one key to another. Only the keys passed to WithLock are
+// accessible.
+//
+// [Counter] wraps a cache of int64 values with Inc, Dec and Add methods
+// built on [Add], e.g. for rate limiting or hit counting.
+//
+// For read-through caching, [NewLoading] wraps a cache with a loader function.
+// [Loading.Get] loads and stores missing keys, sharing one loader call among
+// concurrent misses for the same key. Loader errors are only cached with
+// [WithNegativeCache].
+//
+// [Cache.GetOrRefresh] reloads a missing or expired key with a TTL, so that
+// only one of the concurrent callers reloads a popular key when it expires.
+// With [WithStaleWhileRevalidate], the others get the expired value
+// meanwhile.
+//
+// # Batch Operations
+//
+// [Cache.SetMany], [Cache.GetMany] and [Cache.DeleteMany] group keys by shard
+// and take each shard lock once per batch, amortizing lock acquisition in
+// tight loops. [Cache.GetMulti] also returns the missing keys in input order,
+// ready for fetching from the origin. [Cache.WarmFrom] preloads entries
+// like [Cache.SetMany] without counting them as Set calls.
+// [Cache.SetMultiWithTTL] stores a batch of entries, each with its own TTL.
+// [Cache.Merge] folds the entries of another cache into a cache, resolving
+// key conflicts with a callback. [DeletePrefix] removes all string keys with
+// a prefix, e.g. to invalidate a namespace.
 //
 // # Persistence
 //
 // The cache can be saved (with [Cache.SaveTo], [Cache.SaveToFile], and
 // [Cache.SaveToFileConcurrent]) and loaded (from [LoadFrom] and [LoadFromFile])
 // to/from [io.Writer]/[io.Reader] or files using [gob] encoding with [minlz]
-// compression.
+// compression. Pass [WithCompression] to select [CompressionNone],
+// [CompressionMinLZBlock] for the minlz block format or, when built with the
+// fastcache_zstd tag, [CompressionZstd]; loading detects the compression,
+// and [Cache.SaveToWithReport] reports how well the data compressed. Other
+// formats may be plugged in with a [Codec], see [Cache.SaveToWithCodec] and
+// [LoadFromWithCodec]. For human-readable dumps, use [Cache.SaveToJSON] and
+// [LoadFromJSON]. [LoadFromFunc] skips or rejects entries that fail a
+// validator, for dumps from untrusted sources. Pass [WithSavedStats] to
+// save the stats counters as well, so that a loaded cache continues counting
+// from them across restarts. Files are replaced atomically by a rename;
+// pass [WithDurableSave] to also sync them to disk, so that a crash right
+// after a save cannot leave a truncated file.
+//
+// Entries stored with a TTL are saved with the time they have left, which
+// keeps running from the moment they are loaded; entries that expired before
+// the save are skipped. The downtime between saving and loading is not
+// counted against the TTL. Entry costs and negative entries are saved and
+// restored as well.
+//
+// [Cache.SaveToFileContext] aborts a long-running save when its context is
+// done, without touching the destination file. For huge caches,
+// [Cache.SaveToDir] writes one file per shard plus a manifest, and
+// [LoadFromDir] loads them back. A [Group] saves, loads and reports stats
+// for several named caches of different types at once, which are added to
+// it with [Register].
+//
+// Concrete types stored in interface-typed keys or values must be
+// registered with [RegisterTypes] before saving; otherwise saving fails with
+// [ErrUnregisteredType] naming the type.
+//
+// Binary dumps start with a header holding a magic number, a format version
+// and a checksum of the payload, so truncated or corrupted data is rejected
+// with [ErrCorruptedData] before decoding. Data saved from a cache with other
+// key or value types is rejected with [ErrTypeMismatch], and I/O errors are
+// wrapped, so callers can tell them apart with [errors.Is]. Headerless dumps
+// written by earlier releases are still loaded, without the checksum.
+//
+// Binary and JSON dumps store entries in eviction order, so a loaded cache
+// evicts its entries in the same order as the saved one. [Cache.SaveToDir]
+// preserves the order within each shard only.
 //
 // # Thread Safety
 //
 // All [Cache] methods are safe for concurrent use by multiple goroutines.
+// Each shard is guarded by a plain mutex rather than a read-write one, since
+// lookups also update the shard, so reads and writes cost the same lock.
 // Iterator methods provide a snapshot view and do not block other operations.
 //
 // [VictoriaMetrics/fastcache]: https://github.com/VictoriaMetrics/fastcache.
@@ -6,8 +6,104 @@ var (
 	// ErrInvalidMaxEntries reports an invalid cache capacity.
 	ErrInvalidMaxEntries = errors.New("fastcache: maxEntries must be greater than 0")
 
+	// ErrInvalidPolicy reports an unknown eviction policy.
+	ErrInvalidPolicy = errors.New("fastcache: invalid eviction policy")
+
+	// ErrInvalidMaxBytes reports an invalid byte capacity.
+	ErrInvalidMaxBytes = errors.New("fastcache: maxBytes must not be negative")
+
+	// ErrInvalidMaxCost reports an invalid cost capacity.
+	ErrInvalidMaxCost = errors.New("fastcache: maxCost must not be negative")
+
+	// ErrInvalidSampleRate reports an access sampling rate outside [0, 1].
+	ErrInvalidSampleRate = errors.New("fastcache: sampling rate must be in [0, 1]")
+
+	// ErrInvalidLockStripes reports a lock stripe count that is not a power
+	// of two between 1 and the number of shards.
+	ErrInvalidLockStripes = errors.New("fastcache: lock stripes must be a power of two in [1, 512]")
+
+	// ErrInvalidSizeOf reports a size function whose type does not match the
+	// cache key and value types.
+	ErrInvalidSizeOf = errors.New("fastcache: size function does not match cache types")
+
+	// ErrInvalidEvictionChannel reports an eviction channel whose type does
+	// not match the cache key and value types.
+	ErrInvalidEvictionChannel = errors.New("fastcache: eviction channel does not match cache types")
+
+	// ErrInvalidValueCopy reports a value copy function whose type does not
+	// match the cache value type, or a value type that [WithValueCopy]
+	// cannot copy by itself.
+	ErrInvalidValueCopy = errors.New("fastcache: value copy function does not match cache types")
+
+	// ErrEntryTooLarge reports an entry whose estimated size exceeds maxBytes.
+	ErrEntryTooLarge = errors.New("fastcache: entry is larger than maxBytes")
+
+	// ErrInvalidCost reports a non-positive entry cost.
+	ErrInvalidCost = errors.New("fastcache: cost must be greater than 0")
+
+	// ErrCostTooLarge reports an entry whose cost exceeds maxCost.
+	ErrCostTooLarge = errors.New("fastcache: entry cost is larger than maxCost")
+
+	// ErrInvalidInitialCapacity reports an initial capacity that is not
+	// positive or exceeds maxEntries.
+	ErrInvalidInitialCapacity = errors.New("fastcache: initial capacity must be in (0, maxEntries]")
+
+	// ErrInvalidTTL reports a non-positive TTL.
+	ErrInvalidTTL = errors.New("fastcache: ttl must be greater than 0")
+
 	// ErrEvictionFailed reports that the cache could not evict an entry while full.
 	ErrEvictionFailed = errors.New("fastcache: failed to evict while cache is full")
 
+	// ErrCacheFull reports a new key rejected by a cache created with
+	// [WithStrictCapacity] because it is full.
+	ErrCacheFull = errors.New("fastcache: cache is full")
+
+	// ErrCapacityExceeded reports persisted data holding more entries than the
+	// requested capacity.
+	ErrCapacityExceeded = errors.New("fastcache: saved entries exceed maxEntries")
+
+	// ErrCorruptedData reports persisted data that is truncated or fails the
+	// checksum.
+	ErrCorruptedData = errors.New("fastcache: corrupted data")
+
+	// ErrUnsupportedVersion reports persisted data written in an unknown format
+	// version.
+	ErrUnsupportedVersion = errors.New("fastcache: unsupported data format version")
+
+	// ErrUnregisteredType reports a value saved in an interface-typed key or
+	// value whose concrete type was not registered with [RegisterTypes].
+	ErrUnregisteredType = errors.New("fastcache: concrete type is not registered")
+
+	// ErrTypeMismatch reports persisted entries that cannot be decoded into
+	// the key and value types of the loading cache.
+	ErrTypeMismatch = errors.New("fastcache: saved entries do not match cache types")
+
+	// ErrInvalidEntry reports a persisted entry rejected by the validator
+	// passed to [LoadFromFunc].
+	ErrInvalidEntry = errors.New("fastcache: saved entry failed validation")
+
+	// ErrUnsupportedCompression reports a compression that is unknown or not
+	// compiled into the current build.
+	ErrUnsupportedCompression = errors.New("fastcache: unsupported compression")
+
+	// ErrShardCountMismatch reports a saved directory whose shard count does
+	// not match the one of the loading cache.
+	ErrShardCountMismatch = errors.New("fastcache: saved shard count does not match")
+
+	// ErrNegativeCached reports a key whose load failed recently and is
+	// remembered by a [Loading] cache created with [WithNegativeCache].
+	ErrNegativeCached = errors.New("fastcache: load failed recently")
+
+	// ErrInvalidGroupName reports a [Group] cache name that is empty or not a
+	// single path element.
+	ErrInvalidGroupName = errors.New("fastcache: invalid group cache name")
+
+	// ErrDuplicateGroupName reports a [Group] cache name that is already
+	// registered.
+	ErrDuplicateGroupName = errors.New("fastcache: group cache name is already registered")
+
+	// ErrExpvarExists reports an expvar name that is already published.
+	ErrExpvarExists = errors.New("fastcache: expvar name is already published")
+
 	errUnknownOp = errors.New("fastcache: unknown operation")
 )
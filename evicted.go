@@ -0,0 +1,81 @@
+package fastcache
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Evicted is an entry evicted from a cache, as delivered to the channel set
+// with [WithEvictionChannel].
+type Evicted[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// evictSink collects the entries evicted while c.orderMu is held and sends
+// them to the eviction channel once it is released; see WithEvictionChannel.
+type evictSink[K comparable, V any] struct {
+	ch       chan<- Evicted[K, V]
+	blocking bool
+	pending  []Evicted[K, V] // guarded by c.orderMu
+	drops    atomic.Uint64   // entries not sent because ch was full
+}
+
+// evictSinkFromOptions returns the sink for the channel set with
+// WithEvictionChannel, or nil if there is none.
+func evictSinkFromOptions[K comparable, V any](o *options) (*evictSink[K, V], error) {
+	if o.evictionChannel == nil {
+		return nil, nil
+	}
+
+	ch, ok := o.evictionChannel.(chan<- Evicted[K, V])
+	if !ok {
+		var zero chan<- Evicted[K, V]
+
+		return nil, fmt.Errorf("%w: got %T; want %T", ErrInvalidEvictionChannel, o.evictionChannel, zero)
+	}
+	if ch == nil {
+		return nil, nil
+	}
+
+	return &evictSink[K, V]{ch: ch, blocking: o.evictionBlocking}, nil
+}
+
+// addEvictedLocked queues e for the eviction channel, if any. Negative
+// entries are not queued, since they hold no value. c.orderMu must be held.
+func (c *Cache[K, V]) addEvictedLocked(e *entry[K, V]) {
+	if c.sink != nil && !e.negative {
+		c.sink.pending = append(c.sink.pending, Evicted[K, V]{Key: e.Key, Value: e.Value})
+	}
+}
+
+// unlockOrder releases c.orderMu and then sends the entries evicted while it
+// was held to the eviction channel, so that a slow receiver blocks neither
+// the eviction lock nor any shard lock.
+func (c *Cache[K, V]) unlockOrder() {
+	if c.sink == nil || len(c.sink.pending) == 0 {
+		c.orderMu.Unlock()
+
+		return
+	}
+
+	pending := c.sink.pending
+	c.sink.pending = nil
+	c.orderMu.Unlock()
+
+	for _, e := range pending {
+		if c.sink.blocking {
+			c.sink.ch <- e
+
+			continue
+		}
+
+		select {
+		case c.sink.ch <- e:
+		default:
+			if !c.noStats {
+				c.sink.drops.Add(1)
+			}
+		}
+	}
+}
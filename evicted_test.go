@@ -0,0 +1,111 @@
+package fastcache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCacheWithEvictionChannel(t *testing.T) {
+	ch := make(chan Evicted[int, int], 10)
+	c, err := New[int, int](3, WithEvictionChannel[int, int](ch, true))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 5 {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Trim(2)
+	if _, _, ok := c.EvictOldest(); !ok {
+		t.Fatal("EvictOldest found no entry")
+	}
+
+	// Deleted entries are not evictions.
+	c.Delete(4)
+
+	close(ch)
+	var got []Evicted[int, int]
+	for e := range ch {
+		got = append(got, e)
+	}
+	want := []Evicted[int, int]{{0, 0}, {1, 10}, {2, 20}, {3, 30}}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected evicted entries; got %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected evicted entries; got %v; want %v", got, want)
+		}
+	}
+}
+
+func TestCacheWithEvictionChannelDrops(t *testing.T) {
+	ch := make(chan Evicted[int, int], 1)
+	c, err := New[int, int](10, WithEvictionChannel[int, int](ch, false))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 15 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if e := <-ch; e.Key != 0 {
+		t.Fatalf("unexpected first evicted key; got %d; want 0", e.Key)
+	}
+	s := c.Stats()
+	if s.Evictions != 5 || s.EvictionDrops != 4 {
+		t.Fatalf("unexpected stats; got Evictions=%d, EvictionDrops=%d; want 5, 4", s.Evictions, s.EvictionDrops)
+	}
+
+	c.Reset()
+	if s := c.Stats(); s.EvictionDrops != 0 {
+		t.Fatalf("unexpected EvictionDrops after Reset; got %d; want 0", s.EvictionDrops)
+	}
+}
+
+func TestCacheWithEvictionChannelSendsOutsideLocks(t *testing.T) {
+	ch := make(chan Evicted[int, int])
+	c, err := New[int, int](1, WithPolicy(PolicyLRU), WithEvictionChannel[int, int](ch, true))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// The receiver uses the cache while the sender waits for it, which
+	// would deadlock if entries were sent under a cache lock.
+	done := make(chan int)
+	go func() {
+		var n int
+		for e := range ch {
+			c.Get(e.Key)
+			c.Len()
+			n++
+		}
+		done <- n
+	}()
+
+	for i := range 100 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	close(ch)
+	if n := <-done; n != 99 {
+		t.Fatalf("unexpected number of evicted entries; got %d; want 99", n)
+	}
+}
+
+func TestCacheWithEvictionChannelTypeMismatch(t *testing.T) {
+	ch := make(chan Evicted[string, int])
+	_, err := New[int, int](10, WithEvictionChannel[string, int](ch, false))
+	if !errors.Is(err, ErrInvalidEvictionChannel) {
+		t.Fatalf("unexpected error; got %v; want %v", err, ErrInvalidEvictionChannel)
+	}
+}
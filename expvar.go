@@ -0,0 +1,36 @@
+package fastcache
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// expvarMu makes checking and publishing a name atomic, since
+// [expvar.Publish] panics on duplicates.
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes the cache stats as an [expvar] variable with the
+// given name, so that they show up at /debug/vars.
+//
+// The stats are collected with [Cache.UpdateStats] each time the variable is
+// read, and encoded as described in [Stats.MarshalJSON]. Published variables
+// cannot be removed, so the cache stays reachable for the lifetime of the
+// process.
+//
+// PublishExpvar returns an error wrapping [ErrExpvarExists] if name is
+// already published.
+func (c *Cache[K, V]) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w: %q", ErrExpvarExists, name)
+	}
+
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Stats()
+	}))
+
+	return nil
+}
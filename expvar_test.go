@@ -0,0 +1,38 @@
+package fastcache
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+)
+
+func TestCachePublishExpvar(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const name = "fastcache_TestCachePublishExpvar"
+	if err := c.PublishExpvar(name); err != nil {
+		t.Fatalf("PublishExpvar error: %s", err)
+	}
+	if err := c.PublishExpvar(name); !errors.Is(err, ErrExpvarExists) {
+		t.Fatalf("PublishExpvar returned error %v; want %v", err, ErrExpvarExists)
+	}
+
+	if err := c.Set("key", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	c.Get("key")
+
+	// Stats are collected on every read
+	var s Stats
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &s); err != nil {
+		t.Fatalf("cannot decode published stats: %s", err)
+	}
+	if s.EntriesCount != 1 || s.GetCalls != 1 || s.SetCalls != 1 || s.MaxEntries != 100 {
+		t.Fatalf("unexpected published stats: %+v", s)
+	}
+}
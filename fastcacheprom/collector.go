@@ -0,0 +1,102 @@
+// Package fastcacheprom exposes [fastcache.Stats] as Prometheus metrics.
+//
+// It lives in its own module so that the core fastcache package does not
+// depend on the Prometheus client library.
+package fastcacheprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.dw1.io/fastcache"
+)
+
+// StatsSource is implemented by [fastcache.Cache] for any key and value type.
+type StatsSource interface {
+	UpdateStats(s *fastcache.Stats)
+}
+
+var (
+	labels = []string{"cache"}
+
+	getCallsDesc = prometheus.NewDesc(
+		"fastcache_get_calls_total",
+		"Number of Get calls.",
+		labels, nil,
+	)
+	setCallsDesc = prometheus.NewDesc(
+		"fastcache_set_calls_total",
+		"Number of Set calls.",
+		labels, nil,
+	)
+	missesDesc = prometheus.NewDesc(
+		"fastcache_misses_total",
+		"Number of cache misses.",
+		labels, nil,
+	)
+	hitsDesc = prometheus.NewDesc(
+		"fastcache_hits_total",
+		"Number of cache hits.",
+		labels, nil,
+	)
+	deletesDesc = prometheus.NewDesc(
+		"fastcache_deletes_total",
+		"Number of Delete calls.",
+		labels, nil,
+	)
+	evictionsDesc = prometheus.NewDesc(
+		"fastcache_evictions_total",
+		"Number of entries evicted due to capacity limits.",
+		labels, nil,
+	)
+	entriesDesc = prometheus.NewDesc(
+		"fastcache_entries",
+		"Current number of entries in the cache.",
+		labels, nil,
+	)
+	maxEntriesDesc = prometheus.NewDesc(
+		"fastcache_max_entries",
+		"Maximum number of entries allowed in the cache.",
+		labels, nil,
+	)
+)
+
+type collector struct {
+	name string
+	src  StatsSource
+}
+
+// NewCollector returns a Prometheus collector for the stats of src.
+//
+// Every metric carries a "cache" label set to name, so several caches may be
+// registered side by side. Cumulative [fastcache.Stats] fields are exported as
+// counters and the entry counts as gauges.
+func NewCollector(name string, src StatsSource) prometheus.Collector {
+	return &collector{name: name, src: src}
+}
+
+// Describe implements [prometheus.Collector].
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- getCallsDesc
+	ch <- setCallsDesc
+	ch <- missesDesc
+	ch <- hitsDesc
+	ch <- deletesDesc
+	ch <- evictionsDesc
+	ch <- entriesDesc
+	ch <- maxEntriesDesc
+}
+
+// Collect implements [prometheus.Collector].
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	var s fastcache.Stats
+	c.src.UpdateStats(&s)
+
+	ch <- prometheus.MustNewConstMetric(getCallsDesc, prometheus.CounterValue, float64(s.GetCalls), c.name)
+	ch <- prometheus.MustNewConstMetric(setCallsDesc, prometheus.CounterValue, float64(s.SetCalls), c.name)
+	ch <- prometheus.MustNewConstMetric(missesDesc, prometheus.CounterValue, float64(s.Misses), c.name)
+	ch <- prometheus.MustNewConstMetric(hitsDesc, prometheus.CounterValue, float64(s.Hits), c.name)
+	ch <- prometheus.MustNewConstMetric(deletesDesc, prometheus.CounterValue, float64(s.Deletes), c.name)
+	ch <- prometheus.MustNewConstMetric(evictionsDesc, prometheus.CounterValue, float64(s.Evictions), c.name)
+	ch <- prometheus.MustNewConstMetric(entriesDesc, prometheus.GaugeValue, float64(s.EntriesCount), c.name)
+	ch <- prometheus.MustNewConstMetric(maxEntriesDesc, prometheus.GaugeValue, float64(s.MaxEntries), c.name)
+}
@@ -0,0 +1,56 @@
+package fastcacheprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"go.dw1.io/fastcache"
+)
+
+func TestCollector(t *testing.T) {
+	c, err := fastcache.New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("key", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	c.Get("key")
+	c.Get("missing")
+
+	want := `
+# HELP fastcache_entries Current number of entries in the cache.
+# TYPE fastcache_entries gauge
+fastcache_entries{cache="test"} 1
+# HELP fastcache_get_calls_total Number of Get calls.
+# TYPE fastcache_get_calls_total counter
+fastcache_get_calls_total{cache="test"} 2
+# HELP fastcache_hits_total Number of cache hits.
+# TYPE fastcache_hits_total counter
+fastcache_hits_total{cache="test"} 1
+# HELP fastcache_max_entries Maximum number of entries allowed in the cache.
+# TYPE fastcache_max_entries gauge
+fastcache_max_entries{cache="test"} 10
+# HELP fastcache_misses_total Number of cache misses.
+# TYPE fastcache_misses_total counter
+fastcache_misses_total{cache="test"} 1
+`
+	err = testutil.CollectAndCompare(NewCollector("test", c), strings.NewReader(want),
+		"fastcache_entries",
+		"fastcache_get_calls_total",
+		"fastcache_hits_total",
+		"fastcache_max_entries",
+		"fastcache_misses_total",
+	)
+	if err != nil {
+		t.Fatalf("unexpected metrics: %s", err)
+	}
+
+	if n := testutil.CollectAndCount(NewCollector("test", c)); n != 8 {
+		t.Fatalf("unexpected number of metrics; got %d; want 8", n)
+	}
+}
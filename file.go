@@ -1,7 +1,8 @@
 package fastcache
 
 import (
-	"encoding/gob"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,18 +10,17 @@ import (
 	"path/filepath"
 	"runtime"
 	"sync"
-
-	"github.com/minio/minlz"
 )
 
 // SaveToFile atomically saves cache data to the given filePath.
 //
-// The data is serialized using [gob] and compressed with [snappy].
+// The data is serialized using [gob] and compressed with [minlz], unless
+// another compression is selected with [WithCompression].
 // SaveToFile may be called concurrently with other ops on the cache.
 //
 // The saved data may be loaded with [LoadFromFile].
-func (c *Cache[K, V]) SaveToFile(filePath string) error {
-	return c.SaveToFileConcurrent(filePath, 1)
+func (c *Cache[K, V]) SaveToFile(filePath string, opts ...SaveOption) error {
+	return c.SaveToFileConcurrent(filePath, 1, opts...)
 }
 
 // SaveToFileConcurrent saves cache data to the given filePath using
@@ -29,144 +29,350 @@ func (c *Cache[K, V]) SaveToFile(filePath string) error {
 // SaveToFileConcurrent may be called concurrently with other ops on the cache.
 //
 // The saved data may be loaded with [LoadFromFile].
-func (c *Cache[K, V]) SaveToFileConcurrent(filePath string, concurrency int) error {
+func (c *Cache[K, V]) SaveToFileConcurrent(filePath string, concurrency int, opts ...SaveOption) error {
+	return c.SaveToFileContext(context.Background(), filePath, concurrency, opts...)
+}
+
+// SaveToFileContext is like [Cache.SaveToFileConcurrent], but aborts when ctx
+// is done.
+//
+// The context is checked while collecting and encoding shards. On
+// cancellation SaveToFileContext returns an error wrapping ctx.Err(), removes
+// the temporary file and leaves any existing file at filePath untouched.
+func (c *Cache[K, V]) SaveToFileContext(ctx context.Context, filePath string, concurrency int, opts ...SaveOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	codec, o, err := saveCodec(opts)
+	if err != nil {
+		return err
+	}
+
+	gomaxprocs := runtime.GOMAXPROCS(-1)
+	if concurrency <= 0 || concurrency > gomaxprocs {
+		concurrency = gomaxprocs
+	}
+
+	return writeFile(filePath, o.durable, func(w io.Writer) error {
+		return c.save(ctx, w, codec, concurrency, o.stats)
+	})
+}
+
+// writeFile atomically writes filePath with write, creating its directory if
+// needed. The data is written to a temporary file, which is renamed to
+// filePath only if write succeeds. If durable is set, the temporary file is
+// synced before the rename and the directory after it; see WithDurableSave.
+func writeFile(filePath string, durable bool, write func(w io.Writer) error) error {
 	dir := filepath.Dir(filePath)
 	if _, err := os.Stat(dir); err != nil {
 		if !os.IsNotExist(err) {
-			return fmt.Errorf("cannot stat %q: %s", dir, err)
+			return fmt.Errorf("cannot stat %q: %w", dir, err)
 		}
 
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("cannot create dir %q: %s", dir, err)
+			return fmt.Errorf("cannot create dir %q: %w", dir, err)
 		}
 	}
 
-	// Save cache data into a temporary file.
 	tmpFile, err := os.CreateTemp(dir, "fastcache.tmp.*")
 	if err != nil {
-		return fmt.Errorf("cannot create temporary file in %q: %s", dir, err)
+		return fmt.Errorf("cannot create temporary file in %q: %w", dir, err)
 	}
 	tmpPath := tmpFile.Name()
 	defer func() {
 		_ = os.Remove(tmpPath)
 	}()
 
-	gomaxprocs := runtime.GOMAXPROCS(-1)
-	if concurrency <= 0 || concurrency > gomaxprocs {
-		concurrency = gomaxprocs
+	if err := write(tmpFile); err != nil {
+		_ = tmpFile.Close()
+
+		return fmt.Errorf("cannot save cache data to %q: %w", tmpPath, err)
 	}
 
-	if err := c.save(tmpFile, concurrency); err != nil {
-		_ = tmpFile.Close()
+	if durable {
+		if err := tmpFile.Sync(); err != nil {
+			_ = tmpFile.Close()
 
-		return fmt.Errorf("cannot save cache data to %q: %s", tmpPath, err)
+			return fmt.Errorf("cannot sync temporary file %q: %w", tmpPath, err)
+		}
 	}
 
 	if err := tmpFile.Close(); err != nil {
-		return fmt.Errorf("cannot close temporary file %q: %s", tmpPath, err)
+		return fmt.Errorf("cannot close temporary file %q: %w", tmpPath, err)
 	}
 
 	if err := os.Rename(tmpPath, filePath); err != nil {
-		return fmt.Errorf("cannot rename %q to %q: %s", tmpPath, filePath, err)
+		return fmt.Errorf("cannot rename %q to %q: %w", tmpPath, filePath, err)
+	}
+
+	if durable {
+		if err := syncDir(dir); err != nil {
+			return fmt.Errorf("cannot sync dir %q: %w", dir, err)
+		}
 	}
 
 	return nil
 }
 
+// syncDir flushes the entries of dir, such as a renamed file, to stable
+// storage. Windows cannot sync directories, so it is a no-op there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+
+		return err
+	}
+
+	return d.Close()
+}
+
 // SaveTo saves cache data to the given writer.
 //
-// The data is serialized using [gob] and compressed with [snappy].
+// The data is serialized using [gob] and compressed with [minlz], unless
+// another compression is selected with [WithCompression].
 // SaveTo may be called concurrently with other ops on the cache.
 //
+// Entries with a TTL are saved with the time they have left and expire that
+// long after they are loaded.
+//
+// The encoded payload is buffered in memory before it is written, so that
+// its checksum can precede it, and [LoadFrom] reads it back whole before
+// decoding. Saving or loading a huge cache therefore needs about as much
+// extra memory as the size of the saved data.
+//
 // The saved data may be loaded with [LoadFrom].
-func (c *Cache[K, V]) SaveTo(w io.Writer) error {
-	return c.save(w, 1)
+func (c *Cache[K, V]) SaveTo(w io.Writer, opts ...SaveOption) error {
+	codec, o, err := saveCodec(opts)
+	if err != nil {
+		return err
+	}
+
+	return c.save(context.Background(), w, codec, 1, o.stats)
 }
 
-func (c *Cache[K, V]) save(w io.Writer, concurrency int) error {
-	zw := minlz.NewWriter(w)
-	enc := gob.NewEncoder(zw)
+// SaveToWithReport is like [Cache.SaveTo], but also reports the size of the
+// saved entries before and after compression, e.g. to decide whether a
+// [Compression] is worth its CPU cost.
+//
+// uncompressed is the size of the serialized entries, and compressed is the
+// size of the payload written to w after compression. The written data is
+// 18 bytes larger, for the header preceding the payload. With
+// [CompressionNone] both sizes are equal.
+func (c *Cache[K, V]) SaveToWithReport(w io.Writer, opts ...SaveOption) (uncompressed, compressed int64, err error) {
+	codec, o, err := saveCodec(opts)
+	if err != nil {
+		return 0, 0, err
+	}
 
-	if err := enc.Encode(c.maxEntries); err != nil {
-		return fmt.Errorf("cannot encode maxEntries: %s", err)
+	rc := &reportCodec{gobCodec: codec.(gobCodec)}
+	cw := &countingWriter{w: w, n: &compressed}
+	if err := c.save(context.Background(), cw, rc, 1, o.stats); err != nil {
+		return 0, 0, err
 	}
 
-	type shardData struct {
-		idx     int
-		entries []entry[K, V]
+	return rc.uncompressed, compressed - int64(headerSize), nil
+}
+
+// reportCodec wraps a built-in codec to count the bytes it encodes before
+// compression.
+type reportCodec struct {
+	gobCodec
+	uncompressed int64
+}
+
+func (rc *reportCodec) NewEncoder(w io.Writer) Encoder {
+	return newGobEncoder(rc.gobCodec, w, &rc.uncompressed)
+}
+
+func (rc *reportCodec) compression() Compression {
+	return compressionOf(rc.gobCodec)
+}
+
+// SaveToWithCodec saves cache data to the given writer using codec.
+//
+// SaveToWithCodec may be called concurrently with other ops on the cache.
+//
+// The saved data may be loaded with [LoadFromWithCodec] and the same codec.
+func (c *Cache[K, V]) SaveToWithCodec(w io.Writer, codec Codec) error {
+	return c.save(context.Background(), w, codec, 1, false)
+}
+
+// saveChunkSize is the number of eviction list nodes resolved by a save
+// worker at a time.
+const saveChunkSize = 1024
+
+// save writes the entries of c to w. If withStats is true, the stats
+// counters are saved as well; see WithSavedStats.
+func (c *Cache[K, V]) save(ctx context.Context, w io.Writer, codec Codec, concurrency int, withStats bool) error {
+	var stats savedStats
+	if withStats {
+		stats = savedStatsOf(c.Stats())
 	}
 
-	shardCh := make(chan int, shardsCount)
-	resultCh := make(chan shardData, shardsCount)
-	errCh := make(chan error, concurrency)
+	// Entries are saved in eviction order, so that loading them in turn
+	// restores it. Only the nodes are copied under the eviction lock; their
+	// entries are resolved by the workers under the shard locks.
+	nodes := c.orderedNodes()
+
+	chunks := make([][]savedEntry[K, V], (len(nodes)+saveChunkSize-1)/saveChunkSize)
+	chunkCh := make(chan int, len(chunks))
+	for i := range chunks {
+		chunkCh <- i
+	}
+	close(chunkCh)
 
 	var wg sync.WaitGroup
 	for range concurrency {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for idx := range shardCh {
-				shard := &c.shards[idx]
-				shard.mu.Lock()
-				entries := make([]entry[K, V], 0, shard.entryCount)
-				for _, bucket := range shard.entries {
-					for _, bucketEntry := range bucket {
-						entries = append(entries, entry[K, V]{Key: bucketEntry.Key, Value: bucketEntry.Value})
-					}
+			for i := range chunkCh {
+				if ctx.Err() != nil {
+					return
 				}
-				shard.mu.Unlock()
-				resultCh <- shardData{idx: idx, entries: entries}
+
+				end := min((i+1)*saveChunkSize, len(nodes))
+				chunks[i] = c.resolveNodes(nodes[i*saveChunkSize : end])
 			}
 		}()
 	}
+	wg.Wait()
 
-	go func() {
-		for i := range shardsCount {
-			shardCh <- i
-		}
-		close(shardCh)
-		wg.Wait()
-		close(resultCh)
-	}()
-
-	shardEntries := make([][]entry[K, V], shardsCount)
-	for data := range resultCh {
-		shardEntries[data.idx] = data.entries
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	select {
-	case err := <-errCh:
-		return err
-	default:
+	return writeDump(ctx, w, codec, c.maxEntries, chunks, stats)
+}
+
+// writeDump writes a header followed by the codec-encoded maxEntries,
+// entries and stats to w. The entries are written in the order of chunks.
+func writeDump[K comparable, V any](ctx context.Context, w io.Writer, codec Codec, maxEntries int, chunks [][]savedEntry[K, V], stats savedStats) error {
+	// The payload is buffered, so that its checksum can be written ahead of it.
+	var payload bytes.Buffer
+	enc := codec.NewEncoder(&payload)
+
+	if err := enc.Encode(maxEntries); err != nil {
+		return fmt.Errorf("cannot encode maxEntries: %w", err)
 	}
 
 	totalEntries := 0
-	for _, entries := range shardEntries {
+	for _, entries := range chunks {
 		totalEntries += len(entries)
 	}
 
 	if err := enc.Encode(totalEntries); err != nil {
-		return fmt.Errorf("cannot encode entry count: %s", err)
+		return fmt.Errorf("cannot encode entry count: %w", err)
 	}
 
-	for _, entries := range shardEntries {
+	for _, entries := range chunks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		for _, e := range entries {
 			if err := enc.Encode(e); err != nil {
-				return fmt.Errorf("cannot encode entry: %s", err)
+				return fmt.Errorf("cannot encode entry: %w", err)
 			}
 		}
 	}
 
-	if err := zw.Close(); err != nil {
-		return fmt.Errorf("cannot close minlz writer: %s", err)
+	if err := enc.Encode(stats); err != nil {
+		return fmt.Errorf("cannot encode stats: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("cannot close encoder: %w", err)
+	}
+
+	if err := writeHeader(w, compressionOf(codec), payload.Bytes()); err != nil {
+		return fmt.Errorf("cannot write header: %w", err)
+	}
+
+	if _, err := payload.WriteTo(w); err != nil {
+		return fmt.Errorf("cannot write payload: %w", err)
 	}
 
 	return nil
 }
 
+// orderedNodes returns a snapshot of the eviction list, starting with the next
+// node to be evicted.
+func (c *Cache[K, V]) orderedNodes() []*node[K] {
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	nodes := make([]*node[K], 0, c.order.len)
+	for n := c.order.front(); n != nil; n = c.order.next(n) {
+		nodes = append(nodes, n)
+	}
+
+	return nodes
+}
+
+// savedEntry is the persisted form of an entry. Its fields are exported for
+// encoding.
+type savedEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+
+	// ExpiresIn is the TTL left when the entry was saved, and TTL the one it
+	// was stored with, both in nanoseconds; 0 if none.
+	ExpiresIn int64
+	TTL       int64
+
+	// Cost is the cost set with SetWithCost; 0 for the default cost of 1.
+	Cost int64
+
+	// Negative is set for an entry stored with SetNegative, whose Value is
+	// the zero value.
+	Negative bool
+}
+
+// resolveNodes returns the live and negative entries of nodes, in the same
+// order. Nodes whose entries were deleted, evicted or expired are skipped.
+func (c *Cache[K, V]) resolveNodes(nodes []*node[K]) []savedEntry[K, V] {
+	// The remaining TTLs are relative to a single instant, at which every
+	// saved entry is still live.
+	now := c.now()
+
+	entries := make([]savedEntry[K, V], 0, len(nodes))
+	for _, n := range nodes {
+		shard := &c.shards[n.shard]
+		shard.mu.Lock()
+		bucket := shard.entries[n.hash]
+		if pos := findNode(bucket, n); pos >= 0 {
+			e := &bucket[pos]
+			saved := savedEntry[K, V]{Key: e.Key, Value: e.Value, Negative: e.negative}
+			if e.cost != 1 {
+				saved.Cost = e.cost
+			}
+			if e.expireAt == 0 {
+				entries = append(entries, saved)
+			} else if now < e.expireAt {
+				saved.ExpiresIn, saved.TTL = e.expireAt-now, e.ttl
+				entries = append(entries, saved)
+			}
+		}
+		shard.mu.Unlock()
+	}
+
+	return entries
+}
+
 // LoadFromFile loads cache data from the given filePath.
 //
-// Returns an error if the file does not exist or is corrupted.
+// Returns an error wrapping [os.ErrNotExist] if the file does not exist, and
+// the errors described in [LoadFrom] if it cannot be decoded.
 //
 // See [Cache.SaveToFile] for saving cache data to file.
 func LoadFromFile[K comparable, V any](filePath string) (*Cache[K, V], error) {
@@ -178,7 +384,7 @@ func LoadFromFile[K comparable, V any](filePath string) (*Cache[K, V], error) {
 		_ = f.Close()
 	}()
 
-	return load[K, V](f)
+	return load[K, V](f, nil, 0)
 }
 
 // LoadFromFileOrNew tries loading cache data from the given filePath.
@@ -202,41 +408,232 @@ func LoadFromFileOrNew[K comparable, V any](filePath string, maxEntries int) (*C
 
 // LoadFrom loads cache data from the given reader.
 //
-// Returns an error if the data is corrupted.
+// The loaded cache has the saved capacity, or more if the data holds more
+// entries than that, so that no entry is evicted while loading.
+//
+// Returns an error wrapping [ErrCorruptedData] if the data is truncated or
+// fails the checksum, [ErrUnsupportedVersion] if it was written in an unknown
+// format version, and [ErrTypeMismatch] if it was saved from a cache with
+// other key or value types. I/O errors of r are wrapped as well.
 //
 // See [Cache.SaveTo] for saving cache data to a writer.
 func LoadFrom[K comparable, V any](r io.Reader) (*Cache[K, V], error) {
-	return load[K, V](r)
+	return load[K, V](r, nil, 0)
+}
+
+// LoadFromWithCapacity is like [LoadFrom], but creates the cache with the
+// given maxEntries capacity instead of the saved one.
+//
+// Returns an error wrapping [ErrCapacityExceeded] if the data holds more than
+// maxEntries entries, rather than silently evicting some of them.
+func LoadFromWithCapacity[K comparable, V any](r io.Reader, maxEntries int) (*Cache[K, V], error) {
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("%w: got %d", ErrInvalidMaxEntries, maxEntries)
+	}
+
+	return load[K, V](r, nil, maxEntries)
+}
+
+// LoadFromWithCodec loads cache data from the given reader using codec.
+//
+// Unlike [LoadFrom], LoadFromWithCodec ignores the compression recorded in
+// the data and always decodes it with codec.
+//
+// Returns an error if the data is corrupted or was saved with another codec.
+//
+// See [Cache.SaveToWithCodec] for saving cache data with a codec.
+func LoadFromWithCodec[K comparable, V any](r io.Reader, codec Codec) (*Cache[K, V], error) {
+	return load[K, V](r, codec, 0)
+}
+
+// LoadFromFunc is like [LoadFrom], but stores only the entries for which
+// validate returns true and returns the number of entries it skipped, e.g.
+// to log data-quality issues of dumps from untrusted sources.
+//
+// If failOnInvalid is true, LoadFromFunc instead stops at the first entry
+// failing validation and returns an error wrapping [ErrInvalidEntry].
+// Negative entries hold no value and are not validated.
+func LoadFromFunc[K comparable, V any](r io.Reader, validate func(K, V) bool, failOnInvalid bool) (c *Cache[K, V], skipped int, err error) {
+	d, err := openDump(r, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c, err = New[K, V](d.capacity(), d.sizeHint()...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot create cache: %w", err)
+	}
+
+	skipped, err = decodeEntriesFunc(d, c, validate, failOnInvalid)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return c, skipped, nil
 }
 
-func load[K comparable, V any](r io.Reader) (*Cache[K, V], error) {
-	zr := minlz.NewReader(r)
-	dec := gob.NewDecoder(zr)
+// load decodes a cache from r. A nil codec selects the one of the recorded
+// compression. A zero maxEntries selects the saved capacity, grown to the
+// number of saved entries if needed.
+func load[K comparable, V any](r io.Reader, codec Codec, maxEntries int) (*Cache[K, V], error) {
+	d, err := openDump(r, codec)
+	if err != nil {
+		return nil, err
+	}
 
-	var maxEntries int
-	if err := dec.Decode(&maxEntries); err != nil {
-		return nil, fmt.Errorf("cannot decode maxEntries: %s", err)
+	switch {
+	case maxEntries == 0:
+		maxEntries = d.capacity()
+	case d.totalEntries > maxEntries:
+		return nil, fmt.Errorf("%w: entry count=%d, max entries=%d", ErrCapacityExceeded, d.totalEntries, maxEntries)
 	}
 
-	c, err := New[K, V](maxEntries)
+	c, err := New[K, V](maxEntries, d.sizeHint()...)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create cache: %w", err)
 	}
 
-	var totalEntries int
-	if err := dec.Decode(&totalEntries); err != nil {
-		return nil, fmt.Errorf("cannot decode entry count: %s", err)
+	if err := decodeEntries(d, c); err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < totalEntries; i++ {
-		var e entry[K, V]
-		if err := dec.Decode(&e); err != nil {
-			return nil, fmt.Errorf("cannot decode entry %d: %s", i, err)
+	return c, nil
+}
+
+// dump is persisted cache data whose entries are yet to be decoded.
+type dump struct {
+	dec          Decoder
+	version      uint8 // format version of the header
+	maxEntries   int
+	totalEntries int
+}
+
+// openDump reads the dump from r and decodes its capacity and entry count.
+//
+// A nil codec selects the one of the compression recorded in the header,
+// falling back to [MinLZGobCodec] for data written by a custom [Codec] and
+// headerless dumps of earlier releases.
+func openDump(r io.Reader, codec Codec) (*dump, error) {
+	payload, version, compression, err := readPayload(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if codec == nil {
+		codec = MinLZGobCodec{}
+		if compression != compressionCodec {
+			if codec, err = codecFor(compression); err != nil {
+				return nil, err
+			}
 		}
-		if err := c.Set(e.Key, e.Value); err != nil {
-			return nil, fmt.Errorf("cannot insert entry %d: %w", i, err)
+	}
+
+	d := &dump{dec: codec.NewDecoder(payload), version: version}
+	if err := d.dec.Decode(&d.maxEntries); err != nil {
+		return nil, fmt.Errorf("%w: cannot decode maxEntries: %w", ErrCorruptedData, err)
+	}
+	if err := d.dec.Decode(&d.totalEntries); err != nil {
+		return nil, fmt.Errorf("%w: cannot decode entry count: %w", ErrCorruptedData, err)
+	}
+
+	return d, nil
+}
+
+// capacity returns the saved capacity, grown to the number of saved entries.
+//
+// A concurrent save may capture slightly more entries than the capacity,
+// since shards are collected one at a time.
+func (d *dump) capacity() int {
+	return max(d.maxEntries, d.totalEntries)
+}
+
+// sizeHint returns the options sizing the shard maps of the loaded cache for
+// the saved entries; see entriesSizeHint.
+func (d *dump) sizeHint() []Option {
+	return entriesSizeHint(d.totalEntries)
+}
+
+// entriesSizeHint returns the options sizing the shard maps of a loaded
+// cache for n saved entries, so that decoding them does not grow the maps.
+// The default hint is capped, so large dumps would otherwise allocate every
+// shard map several times over. This saves allocations rather than load
+// time, which is dominated by decoding; see BenchmarkLoadFrom.
+func entriesSizeHint(n int) []Option {
+	if n == 0 {
+		return nil
+	}
+
+	return []Option{WithInitialCapacity(n)}
+}
+
+// restore stores an entry decoded from a dump with its cost, reapplying the
+// TTL it had left when it was saved. A negative entry is stored as such.
+func (c *Cache[K, V]) restore(e *savedEntry[K, V]) error {
+	h := c.hasher(e.Key)
+	idx := c.shardIndexFromHash(h)
+
+	var exp expiry
+	if e.ExpiresIn > 0 {
+		exp = expiry{at: c.now() + e.ExpiresIn, ttl: e.TTL}
+	}
+
+	cost := int64(1)
+	if e.Cost > 0 {
+		cost = e.Cost
+	}
+
+	if e.Negative && exp.at != 0 {
+		var zero V
+		_, err := c.runInsert(opSetNegative, idx, h, e.Key, zero, exp, cost)
+
+		return err
+	}
+
+	return c.shards[idx].set(c, idx, h, e.Key, e.Value, exp, cost)
+}
+
+// decodeEntries decodes the entries of d and stores them in c.
+func decodeEntries[K comparable, V any](d *dump, c *Cache[K, V]) error {
+	_, err := decodeEntriesFunc(d, c, nil, false)
+
+	return err
+}
+
+// decodeEntriesFunc is decodeEntries, but skips the entries for which
+// validate returns false, or fails on them if failOnInvalid is true. A nil
+// validate accepts every entry. It returns the number of skipped entries.
+func decodeEntriesFunc[K comparable, V any](d *dump, c *Cache[K, V], validate func(K, V) bool, failOnInvalid bool) (skipped int, err error) {
+	for i := 0; i < d.totalEntries; i++ {
+		// Headerless entries hold only Key and Value, which gob decodes
+		// into the matching fields.
+		var e savedEntry[K, V]
+		if err := d.dec.Decode(&e); err != nil {
+			// The payload passed the checksum, so an entry that cannot be
+			// decoded was saved with other key or value types.
+			return skipped, fmt.Errorf("%w: cannot decode entry %d: %w", ErrTypeMismatch, i, err)
+		}
+		if validate != nil && !e.Negative && !validate(e.Key, e.Value) {
+			if failOnInvalid {
+				return skipped, fmt.Errorf("%w: entry %d", ErrInvalidEntry, i)
+			}
+			skipped++
+
+			continue
+		}
+		if err := c.restore(&e); err != nil {
+			return skipped, fmt.Errorf("cannot insert entry %d: %w", i, err)
 		}
 	}
 
-	return c, nil
+	if d.version > 0 {
+		var stats savedStats
+		if err := d.dec.Decode(&stats); err != nil {
+			return skipped, fmt.Errorf("%w: cannot decode stats: %w", ErrCorruptedData, err)
+		}
+		if stats.Saved {
+			c.restoreStats(stats)
+		}
+	}
+
+	return skipped, nil
 }
@@ -2,11 +2,18 @@ package fastcache
 
 import (
 	"bytes"
+	"context"
+	"encoding/gob"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/minio/minlz"
 )
 
 func TestSaveLoadSmall(t *testing.T) {
@@ -215,6 +222,86 @@ func TestSaveLoadStruct(t *testing.T) {
 	}
 }
 
+// cancelAfterContext reports cancellation once Err has been called n times.
+type cancelAfterContext struct {
+	context.Context
+	n atomic.Int64
+}
+
+func (ctx *cancelAfterContext) Err() error {
+	if ctx.n.Add(-1) < 0 {
+		return context.Canceled
+	}
+
+	return nil
+}
+
+func TestSaveToFileContextCancel(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "TestSaveToFileContextCancel.fastcache")
+
+	c, err := New[int, int](2000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 1000 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.SaveToFile(filePath); err != nil {
+		t.Fatalf("SaveToFile error: %s", err)
+	}
+	want, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Set(1000, 1000); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	// Cancel before, during collection, after collection and during encoding
+	for _, n := range []int64{0, 1, 2, 3} {
+		ctx := &cancelAfterContext{Context: context.Background()}
+		ctx.n.Store(n)
+
+		err := c.SaveToFileContext(ctx, filePath, 4)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("SaveToFileContext after %d checks returned error %v; want %v", n, err, context.Canceled)
+		}
+
+		got, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("SaveToFileContext after %d checks modified the destination file", n)
+		}
+
+		files, err := os.ReadDir(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("SaveToFileContext after %d checks left %d files; want 1", n, len(files))
+		}
+	}
+
+	if err := c.SaveToFileContext(context.Background(), filePath, 4); err != nil {
+		t.Fatalf("SaveToFileContext error: %s", err)
+	}
+	c2, err := LoadFromFile[int, int](filePath)
+	if err != nil {
+		t.Fatalf("LoadFromFile error: %s", err)
+	}
+	if c2.Len() != 1001 {
+		t.Fatalf("unexpected len after load; got %d; want 1001", c2.Len())
+	}
+}
+
 func TestLoadFromFileOrNew_NonExistent(t *testing.T) {
 	c, err := LoadFromFileOrNew[string, string]("non-existing-file", 100)
 	if err != nil {
@@ -277,6 +364,241 @@ func TestSaveToLoadFrom(t *testing.T) {
 	}
 }
 
+func TestLoadFromFunc(t *testing.T) {
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 10 {
+		if err := c.Set(i, i*10); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	// Values that do not match their key fail validation.
+	for i := 10; i < 13; i++ {
+		if err := c.Set(i, -1); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	data := buf.Bytes()
+
+	validate := func(k, v int) bool {
+		return v == k*10
+	}
+
+	c2, skipped, err := LoadFromFunc(bytes.NewReader(data), validate, false)
+	if err != nil {
+		t.Fatalf("LoadFromFunc error: %s", err)
+	}
+	defer c2.Reset()
+	if skipped != 3 {
+		t.Fatalf("unexpected skipped count; got %d; want 3", skipped)
+	}
+	if c2.Len() != 10 {
+		t.Fatalf("unexpected length; got %d; want 10", c2.Len())
+	}
+	for i := range 13 {
+		v, ok := c2.Peek(i)
+		if want := i < 10; ok != want || ok && v != i*10 {
+			t.Fatalf("unexpected value for key %d; got (%d, %t); want valid entries only", i, v, ok)
+		}
+	}
+
+	if _, _, err := LoadFromFunc(bytes.NewReader(data), validate, true); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("LoadFromFunc returned error %v; want %v", err, ErrInvalidEntry)
+	}
+}
+
+func TestLoadFromKeepsAllEntries(t *testing.T) {
+	const itemsCount = 1000
+	c, err := New[int, int](itemsCount)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	for i := range itemsCount {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	data := buf.Bytes()
+
+	c2, err := LoadFrom[int, int](bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	if c2.Len() != itemsCount {
+		t.Fatalf("unexpected length; got %d; want %d", c2.Len(), itemsCount)
+	}
+	for i := range itemsCount {
+		if v, ok := c2.Get(i); !ok || v != i {
+			t.Fatalf("unexpected cache value for k=%d; got (%d, %t); want (%d, true)", i, v, ok, i)
+		}
+	}
+
+	c3, err := LoadFromWithCapacity[int, int](bytes.NewReader(data), itemsCount*2)
+	if err != nil {
+		t.Fatalf("LoadFromWithCapacity error: %s", err)
+	}
+	if c3.Len() != itemsCount {
+		t.Fatalf("unexpected length; got %d; want %d", c3.Len(), itemsCount)
+	}
+	if s := c3.Stats(); s.MaxEntries != itemsCount*2 {
+		t.Fatalf("unexpected MaxEntries; got %d; want %d", s.MaxEntries, itemsCount*2)
+	}
+
+	_, err = LoadFromWithCapacity[int, int](bytes.NewReader(data), itemsCount-1)
+	if !errors.Is(err, ErrCapacityExceeded) {
+		t.Fatalf("LoadFromWithCapacity returned error %v; want %v", err, ErrCapacityExceeded)
+	}
+
+	_, err = LoadFromWithCapacity[int, int](bytes.NewReader(data), 0)
+	if !errors.Is(err, ErrInvalidMaxEntries) {
+		t.Fatalf("LoadFromWithCapacity returned error %v; want %v", err, ErrInvalidMaxEntries)
+	}
+}
+
+func TestSaveLoadWithSavedStats(t *testing.T) {
+	c, err := New[int, int](3)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 5 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Get(4)
+	c.Get(0)
+	c.Delete(2)
+	want := c.Stats()
+
+	// counters returns the counters of s, leaving out the gauges.
+	counters := func(s Stats) Stats {
+		return Stats{
+			GetCalls:          s.GetCalls,
+			SetCalls:          s.SetCalls,
+			Misses:            s.Misses,
+			Hits:              s.Hits,
+			Deletes:           s.Deletes,
+			Evictions:         s.Evictions,
+			EvictionsCapacity: s.EvictionsCapacity,
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf, WithSavedStats()); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	c2, err := LoadFrom[int, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c2.Reset()
+
+	if got := counters(c2.Stats()); got != counters(want) {
+		t.Fatalf("unexpected stats after loading; got %+v; want %+v", got, counters(want))
+	}
+	var shardSets uint64
+	for _, ss := range c2.ShardStats() {
+		shardSets += ss.SetCalls
+	}
+	if shardSets != 0 {
+		t.Fatalf("loading entries was counted in the shard stats; got %d Set calls", shardSets)
+	}
+
+	// The loaded cache keeps counting from the saved stats.
+	c2.Get(4)
+	if s := c2.Stats(); s.GetCalls != want.GetCalls+1 || s.Hits != want.Hits+1 {
+		t.Fatalf("unexpected stats after Get; got GetCalls=%d, Hits=%d; want %d, %d", s.GetCalls, s.Hits, want.GetCalls+1, want.Hits+1)
+	}
+
+	dir := t.TempDir()
+	if err := c.SaveToDir(dir, 2, WithSavedStats()); err != nil {
+		t.Fatalf("SaveToDir error: %s", err)
+	}
+	c3, err := LoadFromDir[int, int](dir)
+	if err != nil {
+		t.Fatalf("LoadFromDir error: %s", err)
+	}
+	defer c3.Reset()
+	if got := counters(c3.Stats()); got != counters(want) {
+		t.Fatalf("unexpected stats after loading from dir; got %+v; want %+v", got, counters(want))
+	}
+
+	// Reset drops the restored stats.
+	c2.Reset()
+	if s := c2.Stats(); s.GetCalls != 0 || s.SetCalls != 0 {
+		t.Fatalf("unexpected stats after Reset; got GetCalls=%d, SetCalls=%d; want 0, 0", s.GetCalls, s.SetCalls)
+	}
+
+	// Without WithSavedStats, only the Set calls storing the entries count.
+	buf.Reset()
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	c4, err := LoadFrom[int, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c4.Reset()
+	if s := c4.Stats(); s.SetCalls != uint64(c4.Len()) || s.GetCalls != 0 {
+		t.Fatalf("unexpected stats without saved stats; got SetCalls=%d, GetCalls=%d; want %d, 0", s.SetCalls, s.GetCalls, c4.Len())
+	}
+}
+
+func TestLoadFromGrowsCapacityToEntryCount(t *testing.T) {
+	const (
+		maxEntries   = 10
+		totalEntries = 20
+	)
+
+	// Data with more entries than its capacity, as a concurrent save may
+	// produce.
+	var payload bytes.Buffer
+	enc := GobCodec{}.NewEncoder(&payload)
+	for _, v := range []any{maxEntries, totalEntries} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode error: %s", err)
+		}
+	}
+	for i := range totalEntries {
+		if err := enc.Encode(entry[int, int]{Key: i, Value: i}); err != nil {
+			t.Fatalf("Encode error: %s", err)
+		}
+	}
+	if err := enc.Encode(savedStats{}); err != nil {
+		t.Fatalf("Encode error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeHeader(&buf, CompressionNone, payload.Bytes()); err != nil {
+		t.Fatalf("writeHeader error: %s", err)
+	}
+	buf.Write(payload.Bytes())
+
+	c, err := LoadFromWithCodec[int, int](&buf, GobCodec{})
+	if err != nil {
+		t.Fatalf("LoadFromWithCodec error: %s", err)
+	}
+	if c.Len() != totalEntries {
+		t.Fatalf("unexpected length; got %d; want %d", c.Len(), totalEntries)
+	}
+}
+
 func TestSaveToLoadFrom_Struct(t *testing.T) {
 	type User struct {
 		ID   int
@@ -332,3 +654,331 @@ func TestLoadFrom_EmptyReader(t *testing.T) {
 		t.Fatal("LoadFrom must return error for empty reader")
 	}
 }
+
+func TestSaveToLoadFromWithCodec(t *testing.T) {
+	for _, codec := range []Codec{MinLZGobCodec{}, GobCodec{}} {
+		t.Run(fmt.Sprintf("%T", codec), func(t *testing.T) {
+			const itemsCount = 100
+			c, err := New[string, string](itemsCount)
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+
+			for i := range itemsCount {
+				if err := c.Set(fmt.Sprintf("key %d", i), fmt.Sprintf("value %d", i)); err != nil {
+					t.Fatalf("Set error: %s", err)
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := c.SaveToWithCodec(&buf, codec); err != nil {
+				t.Fatalf("SaveToWithCodec error: %s", err)
+			}
+
+			c2, err := LoadFromWithCodec[string, string](&buf, codec)
+			if err != nil {
+				t.Fatalf("LoadFromWithCodec error: %s", err)
+			}
+			if c2.Len() != itemsCount {
+				t.Fatalf("unexpected length; got %d; want %d", c2.Len(), itemsCount)
+			}
+			for i := range itemsCount {
+				k := fmt.Sprintf("key %d", i)
+				v := fmt.Sprintf("value %d", i)
+				if vv, ok := c2.Get(k); !ok || vv != v {
+					t.Fatalf("unexpected cache value for k=%q; got %q; want %q", k, vv, v)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFromWithMismatchedCodec(t *testing.T) {
+	c, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	if err := c.Set("key", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveToWithCodec(&buf, GobCodec{}); err != nil {
+		t.Fatalf("SaveToWithCodec error: %s", err)
+	}
+
+	if _, err := LoadFromWithCodec[string, string](&buf, MinLZGobCodec{}); !errors.Is(err, ErrCorruptedData) {
+		t.Fatalf("LoadFromWithCodec returned error %v for uncompressed data with the MinLZ codec; want %v", err, ErrCorruptedData)
+	}
+}
+
+func TestLoadFromTypeMismatch(t *testing.T) {
+	c, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("key", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+
+	if _, err := LoadFrom[string, int](&buf); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("LoadFrom returned error %v; want %v", err, ErrTypeMismatch)
+	}
+}
+
+func TestSaveToFileDurable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sub")
+	filePath := filepath.Join(dir, "cache.fastcache")
+
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 50 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := c.SaveToFile(filePath, WithDurableSave()); err != nil {
+		t.Fatalf("SaveToFile error: %s", err)
+	}
+	if err := c.SaveToDir(filepath.Join(dir, "shards"), 4, WithDurableSave()); err != nil {
+		t.Fatalf("SaveToDir error: %s", err)
+	}
+
+	loaded, err := LoadFromFile[int, int](filePath)
+	if err != nil {
+		t.Fatalf("LoadFromFile error: %s", err)
+	}
+	defer loaded.Reset()
+	if n := loaded.Len(); n != 50 {
+		t.Fatalf("unexpected Len; got %d; want 50", n)
+	}
+
+	// Only the saved file is left in the directory.
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("unexpected files in %q; got %d; want 2", dir, len(files))
+	}
+}
+
+func TestSaveToFileWrapsIOErrors(t *testing.T) {
+	c, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// A regular file cannot be used as a directory.
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var pathErr *os.PathError
+	if err := c.SaveToFile(filepath.Join(notDir, "cache.fastcache")); !errors.As(err, &pathErr) {
+		t.Fatalf("SaveToFile must wrap the underlying *os.PathError; got: %v", err)
+	}
+}
+
+func TestLoadFromCorruptedData(t *testing.T) {
+	c, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	for i := range 10 {
+		if err := c.Set(fmt.Sprintf("key %d", i), "value"); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	data := buf.Bytes()
+
+	truncated := data[:len(data)-1]
+	if _, err := LoadFrom[string, string](bytes.NewReader(truncated)); !errors.Is(err, ErrCorruptedData) {
+		t.Fatalf("LoadFrom returned error %v for truncated data; want %v", err, ErrCorruptedData)
+	}
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)-1] ^= 0xff
+	if _, err := LoadFrom[string, string](bytes.NewReader(flipped)); !errors.Is(err, ErrCorruptedData) {
+		t.Fatalf("LoadFrom returned error %v for flipped payload; want %v", err, ErrCorruptedData)
+	}
+
+	badMagic := bytes.Clone(data)
+	badMagic[0] = 'X'
+	if _, err := LoadFrom[string, string](bytes.NewReader(badMagic)); !errors.Is(err, ErrCorruptedData) {
+		t.Fatalf("LoadFrom returned error %v for bad magic; want %v", err, ErrCorruptedData)
+	}
+
+	futureVersion := bytes.Clone(data)
+	futureVersion[4] = formatVersion + 1
+	if _, err := LoadFrom[string, string](bytes.NewReader(futureVersion)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("LoadFrom returned error %v for future version; want %v", err, ErrUnsupportedVersion)
+	}
+
+	noVersion := bytes.Clone(data)
+	noVersion[4] = 0
+	if _, err := LoadFrom[string, string](bytes.NewReader(noVersion)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("LoadFrom returned error %v for version %d; want %v", err, noVersion[4], ErrUnsupportedVersion)
+	}
+
+	unknownCompression := bytes.Clone(data)
+	unknownCompression[5] = 0x7f
+	if _, err := LoadFrom[string, string](bytes.NewReader(unknownCompression)); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("LoadFrom returned error %v for unknown compression; want %v", err, ErrUnsupportedCompression)
+	}
+
+	if _, err := LoadFrom[string, string](bytes.NewReader(data)); err != nil {
+		t.Fatalf("LoadFrom error for intact data: %s", err)
+	}
+}
+
+func TestLoadFromHeaderless(t *testing.T) {
+	// Write the layout of releases predating the header: a bare minlz
+	// stream of maxEntries, the entry count and the entries.
+	var buf bytes.Buffer
+	zw := minlz.NewWriter(&buf)
+	enc := gob.NewEncoder(zw)
+	for _, v := range []any{200, 100} {
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("Encode error: %s", err)
+		}
+	}
+	for i := range 100 {
+		if err := enc.Encode(entry[string, int]{Key: fmt.Sprintf("key %d", i), Value: i}); err != nil {
+			t.Fatalf("Encode error: %s", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close error: %s", err)
+	}
+
+	c, err := LoadFrom[string, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.Len() != 100 || c.Capacity() != 200 {
+		t.Fatalf("unexpected cache; got Len=%d, Capacity=%d; want 100, 200", c.Len(), c.Capacity())
+	}
+	for i := range 100 {
+		k := fmt.Sprintf("key %d", i)
+		if v, ok := c.Get(k); !ok || v != i {
+			t.Fatalf("unexpected cache value for k=%q; got (%d, %t); want (%d, true)", k, v, ok, i)
+		}
+	}
+}
+
+func TestSaveLoadPreservesEvictionOrder(t *testing.T) {
+	for _, policy := range []Policy{PolicyFIFO, PolicyLRU} {
+		t.Run(policy.String(), func(t *testing.T) {
+			const itemsCount = 3000
+			c, err := New[int, int](itemsCount, WithPolicy(policy))
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			for i := range itemsCount + 100 {
+				if err := c.Set(i, i); err != nil {
+					t.Fatalf("Set error: %s", err)
+				}
+			}
+			c.Delete(500)
+			c.Get(200)
+
+			var want []int
+			for k := range c.AllOrdered() {
+				want = append(want, k)
+			}
+
+			var buf bytes.Buffer
+			if err := c.SaveToWithCodec(&buf, GobCodec{}); err != nil {
+				t.Fatalf("SaveToWithCodec error: %s", err)
+			}
+			c2, err := LoadFromWithCodec[int, int](&buf, GobCodec{})
+			if err != nil {
+				t.Fatalf("LoadFromWithCodec error: %s", err)
+			}
+
+			var got []int
+			for k := range c2.AllOrdered() {
+				got = append(got, k)
+			}
+			if !slices.Equal(got, want) {
+				t.Fatalf("eviction order was not preserved; got %d keys starting with %v; want %d keys starting with %v", len(got), got[:5], len(want), want[:5])
+			}
+
+			// The next insert evicts the oldest saved entry
+			if err := c2.Set(-1, -1); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+			if err := c2.Set(-2, -2); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+			if c2.Has(want[0]) {
+				t.Fatalf("key %d should have been evicted first after reload", want[0])
+			}
+		})
+	}
+}
+
+type registeredShape struct{ Sides int }
+
+type unregisteredShape struct{ Sides int }
+
+func TestSaveRegisterTypes(t *testing.T) {
+	c, err := New[string, any](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("square", unregisteredShape{Sides: 4}); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	var buf bytes.Buffer
+	err = c.SaveTo(&buf)
+	if !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("unexpected error for an unregistered type; got %v; want %v", err, ErrUnregisteredType)
+	}
+	if !strings.Contains(err.Error(), "unregisteredShape") {
+		t.Fatalf("error does not name the unregistered type: %s", err)
+	}
+
+	RegisterTypes(registeredShape{})
+	c.Reset()
+	if err := c.Set("triangle", registeredShape{Sides: 3}); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	buf.Reset()
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	c2, err := LoadFrom[string, any](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c2.Reset()
+	if v, ok := c2.Get("triangle"); !ok || v != (registeredShape{Sides: 3}) {
+		t.Fatalf("unexpected loaded value; got (%v, %t); want (%v, true)", v, ok, registeredShape{Sides: 3})
+	}
+}
@@ -7,4 +7,4 @@ require (
 	go.dw1.io/rapidhash v0.3.0
 )
 
-require github.com/klauspost/compress v1.17.11 // indirect
+require github.com/klauspost/compress v1.17.11
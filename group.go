@@ -0,0 +1,169 @@
+package fastcache
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// groupFileExt is the extension of the files written by [Group.SaveAll].
+const groupFileExt = ".fastcache"
+
+// persistable is the part of [Cache] that a [Group] needs, so that it can
+// hold caches of different key and value types.
+type persistable interface {
+	SaveToFile(filePath string, opts ...SaveOption) error
+	Stats() Stats
+	loadInto(r io.Reader) error
+}
+
+// Group manages several named caches, possibly with different key and value
+// types, so that they can be saved, loaded and monitored together. Caches
+// are added with [Register].
+//
+// A Group is safe for concurrent use. The zero Group is empty and ready to
+// use.
+type Group struct {
+	mu     sync.Mutex
+	caches map[string]persistable
+}
+
+// NewGroup returns an empty group.
+func NewGroup() *Group {
+	return &Group{}
+}
+
+// Register adds the cache c to the group g under name. It is a function
+// rather than a method of [Group] because Go methods cannot have type
+// parameters, and a group holds caches of any key and value types.
+//
+// name is used as the file name of c in [Group.SaveAll], so it must be a
+// single path element. Register returns an error wrapping
+// [ErrInvalidGroupName] if it is not, and [ErrDuplicateGroupName] if
+// another cache is already registered under name.
+func Register[K comparable, V any](g *Group, name string, c *Cache[K, V]) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: got %q", ErrInvalidGroupName, name)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.caches[name]; ok {
+		return fmt.Errorf("%w: got %q", ErrDuplicateGroupName, name)
+	}
+	if g.caches == nil {
+		g.caches = make(map[string]persistable)
+	}
+	g.caches[name] = c
+
+	return nil
+}
+
+// Names returns the names of the registered caches in sorted order.
+func (g *Group) Names() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(g.caches))
+}
+
+// SaveAll saves every registered cache to dir, one file per cache named
+// after it, in the [Cache.SaveToFile] format. opts apply to every file.
+//
+// Caches are saved in name order, and SaveAll stops at the first one that
+// cannot be saved. Files of caches saved before are kept.
+func (g *Group) SaveAll(dir string, opts ...SaveOption) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create dir %q: %w", dir, err)
+	}
+
+	caches := g.snapshot()
+	for _, name := range slices.Sorted(maps.Keys(caches)) {
+		if err := caches[name].SaveToFile(groupFilePath(dir, name), opts...); err != nil {
+			return fmt.Errorf("cannot save cache %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadAll replaces the contents of every registered cache with the data
+// saved by [Group.SaveAll] in dir, and resets their stats.
+//
+// The caches keep their capacity and options. LoadAll returns an error
+// wrapping [os.ErrNotExist] if the file of a cache is missing,
+// [ErrCapacityExceeded] if it holds more entries than the cache can, and
+// the errors described in [LoadFrom] if it cannot be decoded. Caches are
+// loaded in name order, and LoadAll stops at the first one that cannot be
+// loaded, which may then hold part of its entries.
+func (g *Group) LoadAll(dir string) error {
+	caches := g.snapshot()
+	for _, name := range slices.Sorted(maps.Keys(caches)) {
+		if err := loadFileInto(groupFilePath(dir, name), caches[name]); err != nil {
+			return fmt.Errorf("cannot load cache %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// AggregateStats returns the stats of every registered cache by name.
+func (g *Group) AggregateStats() map[string]Stats {
+	caches := g.snapshot()
+	stats := make(map[string]Stats, len(caches))
+	for name, c := range caches {
+		stats[name] = c.Stats()
+	}
+
+	return stats
+}
+
+// snapshot returns a copy of the registered caches, so that they can be
+// saved or loaded without holding g.mu.
+func (g *Group) snapshot() map[string]persistable {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return maps.Clone(g.caches)
+}
+
+// groupFilePath returns the path of the file holding the cache name in dir.
+func groupFilePath(dir, name string) string {
+	return filepath.Join(dir, name+groupFileExt)
+}
+
+// loadFileInto replaces the contents of c with the data in filePath.
+func loadFileInto(filePath string, c persistable) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return c.loadInto(f)
+}
+
+// loadInto replaces the contents of c with the data read from r, keeping
+// the capacity and options of c.
+func (c *Cache[K, V]) loadInto(r io.Reader) error {
+	d, err := openDump(r, nil)
+	if err != nil {
+		return err
+	}
+	if d.totalEntries > c.maxEntries {
+		return fmt.Errorf("%w: entry count=%d, max entries=%d", ErrCapacityExceeded, d.totalEntries, c.maxEntries)
+	}
+
+	c.clear(false)
+	c.presize(d.totalEntries)
+
+	return decodeEntries(d, c)
+}
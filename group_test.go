@@ -0,0 +1,157 @@
+package fastcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGroupSaveAllLoadAll(t *testing.T) {
+	users, err := New[int, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer users.Reset()
+	sessions, err := New[string, int64](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer sessions.Reset()
+
+	var g Group
+	if err := Register(&g, "users", users); err != nil {
+		t.Fatalf("Register error: %s", err)
+	}
+	if err := Register(&g, "sessions", sessions); err != nil {
+		t.Fatalf("Register error: %s", err)
+	}
+	if names := g.Names(); !slices.Equal(names, []string{"sessions", "users"}) {
+		t.Fatalf("unexpected names; got %v; want [sessions users]", names)
+	}
+
+	for i := range 10 {
+		if err := users.Set(i, "user"); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := sessions.Set("token", 42); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	sessions.Get("token")
+	sessions.Get("missing")
+
+	stats := g.AggregateStats()
+	if len(stats) != 2 {
+		t.Fatalf("unexpected stats count; got %d; want 2", len(stats))
+	}
+	if s := stats["users"]; s.EntriesCount != 10 || s.SetCalls != 10 {
+		t.Fatalf("unexpected users stats; got entries=%d sets=%d; want entries=10 sets=10", s.EntriesCount, s.SetCalls)
+	}
+	if s := stats["sessions"]; s.GetCalls != 2 || s.Misses != 1 {
+		t.Fatalf("unexpected sessions stats; got gets=%d misses=%d; want gets=2 misses=1", s.GetCalls, s.Misses)
+	}
+
+	dir := filepath.Join(t.TempDir(), "caches")
+	if err := g.SaveAll(dir); err != nil {
+		t.Fatalf("SaveAll error: %s", err)
+	}
+	for _, name := range []string{"users", "sessions"} {
+		if _, err := os.Stat(filepath.Join(dir, name+".fastcache")); err != nil {
+			t.Fatalf("missing file for cache %q: %s", name, err)
+		}
+	}
+
+	users.Delete(0)
+	if err := users.Set(100, "stale"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	sessions.Delete("token")
+
+	if err := g.LoadAll(dir); err != nil {
+		t.Fatalf("LoadAll error: %s", err)
+	}
+	if users.Len() != 10 || users.Has(100) {
+		t.Fatalf("LoadAll did not replace users; got len %d", users.Len())
+	}
+	if v, ok := sessions.Get("token"); !ok || v != 42 {
+		t.Fatalf("unexpected session after LoadAll; got (%d, %t); want (42, true)", v, ok)
+	}
+	if users.Capacity() != 100 {
+		t.Fatalf("LoadAll changed the capacity; got %d; want 100", users.Capacity())
+	}
+}
+
+func TestGroupRegisterErrors(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	g := NewGroup()
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		if err := Register(g, name, c); !errors.Is(err, ErrInvalidGroupName) {
+			t.Fatalf("Register(%q) returned error %v; want %v", name, err, ErrInvalidGroupName)
+		}
+	}
+
+	if err := Register(g, "ints", c); err != nil {
+		t.Fatalf("Register error: %s", err)
+	}
+	if err := Register(g, "ints", c); !errors.Is(err, ErrDuplicateGroupName) {
+		t.Fatalf("Register returned error %v; want %v", err, ErrDuplicateGroupName)
+	}
+}
+
+func TestGroupLoadAllErrors(t *testing.T) {
+	small, err := New[int, int](1)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer small.Reset()
+
+	g := NewGroup()
+	if err := Register(g, "ints", small); err != nil {
+		t.Fatalf("Register error: %s", err)
+	}
+
+	dir := t.TempDir()
+	if err := g.LoadAll(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadAll returned error %v; want %v", err, os.ErrNotExist)
+	}
+
+	big, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer big.Reset()
+	for i := range 5 {
+		if err := big.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := big.SaveToFile(filepath.Join(dir, "ints.fastcache")); err != nil {
+		t.Fatalf("SaveToFile error: %s", err)
+	}
+	if err := g.LoadAll(dir); !errors.Is(err, ErrCapacityExceeded) {
+		t.Fatalf("LoadAll returned error %v; want %v", err, ErrCapacityExceeded)
+	}
+
+	strs, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer strs.Reset()
+	if err := os.Rename(filepath.Join(dir, "ints.fastcache"), filepath.Join(dir, "strs.fastcache")); err != nil {
+		t.Fatalf("Rename error: %s", err)
+	}
+	g = NewGroup()
+	if err := Register(g, "strs", strs); err != nil {
+		t.Fatalf("Register error: %s", err)
+	}
+	if err := g.LoadAll(dir); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("LoadAll returned error %v; want %v", err, ErrTypeMismatch)
+	}
+}
@@ -0,0 +1,91 @@
+package fastcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Persisted data starts with a header:
+//
+//	magic       [4]byte  "FCv\x00"
+//	version     uint8
+//	compression uint8    [Compression] of the payload
+//	length      uint64   payload length, little-endian
+//	crc         uint32   CRC-32 (Castagnoli) of the payload, little-endian
+//
+// The payload is the codec-encoded stream written by [Cache.save]:
+// maxEntries, the entry count, the entries in eviction order starting with
+// the next entry to be evicted, and the stats counters, which are only
+// restored if saved with [WithSavedStats].
+//
+// Data written before the header was introduced has no header: it is a bare
+// [minlz] stream holding maxEntries, the entry count and the entries, with
+// only their keys and values, in arbitrary order. It is detected by the minlz
+// stream identifier and loaded as version 0, without a checksum.
+const (
+	headerMagic   = "FCv\x00"
+	formatVersion = 1
+	headerSize    = len(headerMagic) + 1 + 1 + 8 + 4
+
+	// legacyMagic is the start of the stream identifier of a minlz stream.
+	legacyMagic = "\xff\x06\x00\x00M"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+func writeHeader(w io.Writer, compression Compression, payload []byte) error {
+	var hdr [headerSize]byte
+	copy(hdr[:], headerMagic)
+	hdr[4] = formatVersion
+	hdr[5] = byte(compression)
+	binary.LittleEndian.PutUint64(hdr[6:], uint64(len(payload)))
+	binary.LittleEndian.PutUint32(hdr[14:], crc32.Checksum(payload, crcTable))
+
+	_, err := w.Write(hdr[:])
+
+	return err
+}
+
+// readPayload reads the header and the payload from r and validates them.
+//
+// It returns the format version and the compression recorded in the header.
+// Headerless data is returned as is, as version 0 with [compressionCodec].
+func readPayload(r io.Reader) (io.Reader, uint8, Compression, error) {
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(r, hdr[:5]); err != nil {
+		return nil, 0, 0, fmt.Errorf("%w: cannot read header: %w", ErrCorruptedData, err)
+	}
+
+	if string(hdr[:5]) == legacyMagic {
+		return io.MultiReader(bytes.NewReader(hdr[:5]), r), 0, compressionCodec, nil
+	}
+	if string(hdr[:4]) != headerMagic {
+		return nil, 0, 0, fmt.Errorf("%w: invalid magic %q", ErrCorruptedData, hdr[:4])
+	}
+	if hdr[4] != formatVersion {
+		return nil, 0, 0, fmt.Errorf("%w: got %d; want %d", ErrUnsupportedVersion, hdr[4], formatVersion)
+	}
+	if _, err := io.ReadFull(r, hdr[5:]); err != nil {
+		return nil, 0, 0, fmt.Errorf("%w: cannot read header: %w", ErrCorruptedData, err)
+	}
+	compression := Compression(hdr[5])
+
+	length := binary.LittleEndian.Uint64(hdr[6:])
+	payload, err := io.ReadAll(io.LimitReader(r, int64(length)))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("cannot read payload: %w", err)
+	}
+	if uint64(len(payload)) != length {
+		return nil, 0, 0, fmt.Errorf("%w: truncated payload; got %d bytes; want %d", ErrCorruptedData, len(payload), length)
+	}
+
+	want := binary.LittleEndian.Uint32(hdr[14:])
+	if got := crc32.Checksum(payload, crcTable); got != want {
+		return nil, 0, 0, fmt.Errorf("%w: checksum mismatch; got %08x; want %08x", ErrCorruptedData, got, want)
+	}
+
+	return bytes.NewReader(payload), hdr[4], compression, nil
+}
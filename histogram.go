@@ -0,0 +1,26 @@
+package fastcache
+
+import "sort"
+
+// ValueSizeHistogram counts the values of c by length in bytes, e.g. to see
+// whether a few huge values dominate the memory of the cache before tuning
+// [WithMaxBytes].
+//
+// buckets holds the upper bounds of the buckets in ascending order. The
+// returned slice has one more count than buckets: counts[i] is the number of
+// values longer than buckets[i-1] bytes and at most buckets[i] bytes long,
+// and the last count is the number of values longer than every bound.
+//
+// ValueSizeHistogram scans every entry of the cache, locking one shard at a
+// time like [Cache.ForEach]. It is a diagnostic meant for occasional use,
+// and it does not count in [Stats].
+func ValueSizeHistogram[K comparable, V ~string | ~[]byte](c *Cache[K, V], buckets []int) []int {
+	counts := make([]int, len(buckets)+1)
+	_ = c.ForEach(func(_ K, v V) error {
+		counts[sort.SearchInts(buckets, len(v))]++
+
+		return nil
+	})
+
+	return counts
+}
@@ -0,0 +1,47 @@
+package fastcache
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValueSizeHistogram(t *testing.T) {
+	c, err := New[int, []byte](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i, size := range []int{0, 10, 11, 100, 101, 5000} {
+		if err := c.Set(i, make([]byte, size)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	got := ValueSizeHistogram(c, []int{10, 100, 1000})
+	if want := []int{2, 2, 1, 1}; !slices.Equal(got, want) {
+		t.Fatalf("unexpected histogram; got %v; want %v", got, want)
+	}
+	if got := ValueSizeHistogram(c, nil); !slices.Equal(got, []int{6}) {
+		t.Fatalf("unexpected histogram without buckets; got %v; want [6]", got)
+	}
+}
+
+func TestValueSizeHistogramString(t *testing.T) {
+	c, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("a", strings.Repeat("x", 64)); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if got := ValueSizeHistogram(c, []int{32}); !slices.Equal(got, []int{0, 1}) {
+		t.Fatalf("unexpected histogram; got %v; want [0 1]", got)
+	}
+	if s := c.Stats(); s.GetCalls != 0 {
+		t.Fatalf("unexpected GetCalls; got %d; want 0", s.GetCalls)
+	}
+}
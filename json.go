@@ -0,0 +1,178 @@
+package fastcache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonEntry is the JSON representation of a key-value pair and, like
+// [savedEntry], its remaining and original TTL in nanoseconds and its cost.
+type jsonEntry[K comparable, V any] struct {
+	Key       K     `json:"key"`
+	Value     V     `json:"value"`
+	ExpiresIn int64 `json:"expiresIn,omitempty"`
+	TTL       int64 `json:"ttl,omitempty"`
+	Cost      int64 `json:"cost,omitempty"`
+	Negative  bool  `json:"negative,omitempty"`
+}
+
+// SaveToJSON saves cache data to the given writer as a JSON object of the form
+//
+//	{"maxEntries":100,"entries":[{"key":"k","value":"v"}]}
+//
+// Entries with a TTL also record the nanoseconds left until they expire in
+// "expiresIn", and the TTL they were stored with in "ttl". Entries stored
+// with [Cache.SetWithCost] record their cost in "cost", and negative entries
+// stored with [Cache.SetNegative] are marked by "negative". Entries are
+// streamed in eviction order, like [Cache.SaveTo] writes them, so that
+// [LoadFromJSON] restores it. Keys and values must be marshalable by
+// [encoding/json]; struct keys work, while keys such as channels or funcs do
+// not.
+//
+// SaveToJSON may be called concurrently with other ops on the cache.
+//
+// The saved data may be loaded with [LoadFromJSON].
+func (c *Cache[K, V]) SaveToJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if _, err := fmt.Fprintf(bw, `{"maxEntries":%d,"entries":[`, c.maxEntries); err != nil {
+		return fmt.Errorf("cannot write header: %w", err)
+	}
+
+	first := true
+	nodes := c.orderedNodes()
+	for len(nodes) > 0 {
+		chunk := nodes[:min(saveChunkSize, len(nodes))]
+		nodes = nodes[len(chunk):]
+
+		for _, e := range c.resolveNodes(chunk) {
+			data, err := json.Marshal(jsonEntry[K, V]{Key: e.Key, Value: e.Value, ExpiresIn: e.ExpiresIn, TTL: e.TTL, Cost: e.Cost, Negative: e.Negative})
+			if err != nil {
+				return fmt.Errorf("cannot encode entry: %w", err)
+			}
+			if !first {
+				if err := bw.WriteByte(','); err != nil {
+					return fmt.Errorf("cannot write entry: %w", err)
+				}
+			}
+			first = false
+			if _, err := bw.Write(data); err != nil {
+				return fmt.Errorf("cannot write entry: %w", err)
+			}
+		}
+	}
+
+	if _, err := bw.WriteString("]}\n"); err != nil {
+		return fmt.Errorf("cannot write trailer: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("cannot flush: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFromJSON loads cache data written by [Cache.SaveToJSON] from the given
+// reader.
+//
+// The "maxEntries" field sets the capacity of the loaded cache, which is grown
+// to the number of entries if needed. It may appear before or after
+// "entries"; unknown fields are ignored. An entry with a positive
+// "expiresIn" expires that many nanoseconds after loading.
+//
+// Returns an error wrapping [ErrCorruptedData] if the data is malformed, and
+// [ErrTypeMismatch] if an entry does not match the key and value types.
+func LoadFromJSON[K comparable, V any](r io.Reader) (*Cache[K, V], error) {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var (
+		maxEntries int
+		hasMax     bool
+		entries    []jsonEntry[K, V]
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: cannot decode field name: %w", ErrCorruptedData, err)
+		}
+
+		switch tok {
+		case "maxEntries":
+			if err := dec.Decode(&maxEntries); err != nil {
+				return nil, fmt.Errorf("%w: cannot decode maxEntries: %w", jsonDecodeError(err), err)
+			}
+			hasMax = true
+		case "entries":
+			if err := expectDelim(dec, '['); err != nil {
+				return nil, err
+			}
+			for i := 0; dec.More(); i++ {
+				var e jsonEntry[K, V]
+				if err := dec.Decode(&e); err != nil {
+					return nil, fmt.Errorf("%w: cannot decode entry %d: %w", jsonDecodeError(err), i, err)
+				}
+				entries = append(entries, e)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return nil, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, fmt.Errorf("%w: cannot decode field %v: %w", ErrCorruptedData, tok, err)
+			}
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	if !hasMax {
+		return nil, fmt.Errorf("%w: cannot decode maxEntries: field is missing", ErrCorruptedData)
+	}
+
+	c, err := New[K, V](max(maxEntries, len(entries)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cache: %w", err)
+	}
+
+	for i, e := range entries {
+		if err := c.restore(&savedEntry[K, V]{Key: e.Key, Value: e.Value, ExpiresIn: e.ExpiresIn, TTL: e.TTL, Cost: e.Cost, Negative: e.Negative}); err != nil {
+			return nil, fmt.Errorf("cannot insert entry %d: %w", i, err)
+		}
+	}
+
+	return c, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: cannot decode %q: %w", ErrCorruptedData, want, err)
+	}
+	if tok != want {
+		return fmt.Errorf("%w: unexpected token %v; want %q", ErrCorruptedData, tok, want)
+	}
+
+	return nil
+}
+
+// jsonDecodeError returns the sentinel error for a decoding error: a JSON
+// value of the wrong type for the cache is reported as [ErrTypeMismatch],
+// anything else as [ErrCorruptedData].
+func jsonDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return ErrTypeMismatch
+	}
+
+	return ErrCorruptedData
+}
@@ -0,0 +1,131 @@
+package fastcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadJSON(t *testing.T) {
+	const itemsCount = 100
+	c, err := New[string, int](itemsCount * 2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range itemsCount {
+		if err := c.Set(fmt.Sprintf("key %d", i), i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveToJSON(&buf); err != nil {
+		t.Fatalf("SaveToJSON error: %s", err)
+	}
+
+	c2, err := LoadFromJSON[string, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFromJSON error: %s", err)
+	}
+	defer c2.Reset()
+
+	if c2.Len() != itemsCount {
+		t.Fatalf("unexpected length; got %d; want %d", c2.Len(), itemsCount)
+	}
+	if s := c2.Stats(); s.MaxEntries != itemsCount*2 {
+		t.Fatalf("unexpected MaxEntries; got %d; want %d", s.MaxEntries, itemsCount*2)
+	}
+	for i := range itemsCount {
+		k := fmt.Sprintf("key %d", i)
+		if v, ok := c2.Get(k); !ok || v != i {
+			t.Fatalf("unexpected cache value for k=%q; got (%d, %t); want (%d, true)", k, v, ok, i)
+		}
+	}
+}
+
+func TestSaveLoadJSONPreservesEvictionOrder(t *testing.T) {
+	const itemsCount = 3000
+	c, err := New[int, int](itemsCount, WithPolicy(PolicyLRU))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range itemsCount {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	c.Get(0)
+
+	var want []int
+	for k := range c.AllOrdered() {
+		want = append(want, k)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveToJSON(&buf); err != nil {
+		t.Fatalf("SaveToJSON error: %s", err)
+	}
+	c2, err := LoadFromJSON[int, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFromJSON error: %s", err)
+	}
+	defer c2.Reset()
+
+	var got []int
+	for k := range c2.AllOrdered() {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("eviction order was not preserved; got %d keys starting with %v; want %d keys starting with %v", len(got), got[:5], len(want), want[:5])
+	}
+}
+
+func TestLoadFromJSONHandEdited(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+
+	data := `{
+		"entries": [
+			{"key": {"X": 1, "Y": 2}, "value": "a"},
+			{"key": {"X": 3, "Y": 4}, "value": "b"}
+		],
+		"comment": "ignored",
+		"maxEntries": 10
+	}`
+
+	c, err := LoadFromJSON[point, string](strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("LoadFromJSON error: %s", err)
+	}
+	defer c.Reset()
+
+	if v, ok := c.Get(point{3, 4}); !ok || v != "b" {
+		t.Fatalf("unexpected value; got (%q, %t); want (%q, true)", v, ok, "b")
+	}
+}
+
+func TestLoadFromJSONInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		want error
+	}{
+		{``, ErrCorruptedData},
+		{`[]`, ErrCorruptedData},
+		{`{"entries": []}`, ErrCorruptedData},
+		{`{"maxEntries": 0, "entries": []}`, ErrInvalidMaxEntries},
+		{`{"maxEntries": 10, "entries": [{"key": 1, "value": 2}]}`, ErrTypeMismatch},
+		{`{"maxEntries": 10, "entries": [`, ErrCorruptedData},
+	} {
+		if _, err := LoadFromJSON[string, string](strings.NewReader(tc.data)); !errors.Is(err, tc.want) {
+			t.Fatalf("LoadFromJSON returned error %v for %q; want %v", err, tc.data, tc.want)
+		}
+	}
+}
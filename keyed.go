@@ -0,0 +1,122 @@
+package fastcache
+
+import (
+	"iter"
+	"time"
+)
+
+// ByKeyFunc is a cache for keys that are not comparable, such as slices or
+// structs holding slices.
+//
+// Each key is mapped to a string with the key function given to [NewKeyed],
+// and entries are stored by that string in an underlying [Cache], together
+// with the original key. Iterators therefore yield the original keys.
+//
+// Keys that map to the same string are treated as the same key: setting one
+// replaces the entry of the other, and getting one returns the value stored
+// for the other. Avoiding such collisions is up to the key function.
+type ByKeyFunc[K any, V any] struct {
+	c     *Cache[string, keyedEntry[K, V]]
+	keyFn func(K) string
+}
+
+// keyedEntry is an entry of [ByKeyFunc], holding the original key.
+type keyedEntry[K any, V any] struct {
+	Key   K
+	Value V
+}
+
+// NewKeyed returns a new cache for non-comparable keys, which are mapped to
+// strings with keyFn. maxEntries and opts are passed to [New].
+//
+// [WithSizeOf] is not supported, since the underlying cache stores the
+// derived string keys and entries holding the original keys.
+//
+// NewKeyed returns an error if [New] does.
+func NewKeyed[K any, V any](maxEntries int, keyFn func(K) string, opts ...Option) (*ByKeyFunc[K, V], error) {
+	c, err := New[string, keyedEntry[K, V]](maxEntries, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ByKeyFunc[K, V]{c: c, keyFn: keyFn}, nil
+}
+
+// Set stores (k, v) in the cache. See [Cache.Set].
+func (b *ByKeyFunc[K, V]) Set(k K, v V) error {
+	return b.c.Set(b.keyFn(k), keyedEntry[K, V]{Key: k, Value: v})
+}
+
+// SetWithTTL stores (k, v) in the cache for the given ttl. See
+// [Cache.SetWithTTL].
+func (b *ByKeyFunc[K, V]) SetWithTTL(k K, v V, ttl time.Duration) error {
+	return b.c.SetWithTTL(b.keyFn(k), keyedEntry[K, V]{Key: k, Value: v}, ttl)
+}
+
+// Get returns the value for the given key. See [Cache.Get].
+func (b *ByKeyFunc[K, V]) Get(k K) (V, bool) {
+	e, ok := b.c.Get(b.keyFn(k))
+
+	return e.Value, ok
+}
+
+// Has returns true if an entry for the given key exists. See [Cache.Has].
+func (b *ByKeyFunc[K, V]) Has(k K) bool {
+	return b.c.Has(b.keyFn(k))
+}
+
+// Delete removes the value for the given key. See [Cache.Delete].
+func (b *ByKeyFunc[K, V]) Delete(k K) (deleted bool) {
+	return b.c.Delete(b.keyFn(k))
+}
+
+// Len returns the number of entries in the cache.
+func (b *ByKeyFunc[K, V]) Len() int {
+	return b.c.Len()
+}
+
+// All returns an iterator over all key-value pairs in the cache, yielding
+// the keys as they were set. See [Cache.All].
+func (b *ByKeyFunc[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range b.c.All() {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over all keys in the cache, as they were set. See
+// [Cache.Keys].
+func (b *ByKeyFunc[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for e := range b.c.Values() {
+			if !yield(e.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over all values in the cache. See
+// [Cache.Values].
+func (b *ByKeyFunc[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for e := range b.c.Values() {
+			if !yield(e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Stats returns a fresh snapshot of the cache stats.
+func (b *ByKeyFunc[K, V]) Stats() Stats {
+	return b.c.Stats()
+}
+
+// Reset removes all the items from the cache. See [Cache.Reset].
+func (b *ByKeyFunc[K, V]) Reset() {
+	b.c.Reset()
+}
@@ -0,0 +1,65 @@
+package fastcache
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func sliceKey(k []int) string {
+	return fmt.Sprint(k)
+}
+
+func TestByKeyFunc(t *testing.T) {
+	c, err := NewKeyed[[]int, string](100, sliceKey)
+	if err != nil {
+		t.Fatalf("NewKeyed error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set([]int{1, 2}, "a"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.Set([]int{3}, "b"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	// Equal keys map to the same entry, even if they are distinct slices.
+	if v, ok := c.Get([]int{1, 2}); !ok || v != "a" {
+		t.Fatalf("unexpected value; got (%q, %t); want (%q, true)", v, ok, "a")
+	}
+	if err := c.Set([]int{1, 2}, "c"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("unexpected len; got %d; want 2", c.Len())
+	}
+
+	got := make(map[string]string)
+	for k, v := range c.All() {
+		got[sliceKey(k)] = v
+	}
+	if want := map[string]string{"[1 2]": "c", "[3]": "b"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("unexpected entries; got %v; want %v", got, want)
+	}
+
+	var keys [][]int
+	for k := range c.Keys() {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b []int) int {
+		return slices.Compare(a, b)
+	})
+	if len(keys) != 2 || !slices.Equal(keys[0], []int{1, 2}) || !slices.Equal(keys[1], []int{3}) {
+		t.Fatalf("unexpected keys; got %v; want [[1 2] [3]]", keys)
+	}
+
+	if !c.Delete([]int{3}) || c.Has([]int{3}) {
+		t.Fatal("Delete did not remove the entry")
+	}
+	for v := range c.Values() {
+		if v != "c" {
+			t.Fatalf("unexpected value; got %q; want %q", v, "c")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package fastcache
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sync/atomic"
+)
+
+// leakLogf logs leaked caches. It is a variable so tests can capture the
+// warnings.
+var leakLogf = log.Printf
+
+// leakCheck warns about a cache that is garbage collected without
+// [Cache.Reset]; see [WithLeakCheck].
+//
+// It is referenced by the cleanup, so it must not point back to the cache:
+// that would keep the cache reachable and the cleanup would never run.
+type leakCheck struct {
+	reset atomic.Bool
+	site  string // file:line of the call that created the cache, if known
+}
+
+// startLeakCheck registers the leak warning for c. skip is the number of
+// stack frames to skip to reach the caller that created c, as in
+// [runtime.Caller].
+func (c *Cache[K, V]) startLeakCheck(skip int) {
+	l := &leakCheck{site: "unknown location"}
+	if _, file, line, ok := runtime.Caller(skip + 1); ok {
+		l.site = fmt.Sprintf("%s:%d", file, line)
+	}
+	c.leak = l
+
+	// Unlike a finalizer, a cleanup never resurrects c.
+	runtime.AddCleanup(c, (*leakCheck).check, l)
+}
+
+func (l *leakCheck) check() {
+	if !l.reset.Load() {
+		leakLogf("fastcache: cache created at %s was garbage collected without Reset", l.site)
+	}
+}
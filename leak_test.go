@@ -0,0 +1,64 @@
+package fastcache
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheWithLeakCheck(t *testing.T) {
+	logged := make(chan string, 10)
+	logf := leakLogf
+	leakLogf = func(format string, args ...any) {
+		logged <- fmt.Sprintf(format, args...)
+	}
+	defer func() {
+		leakLogf = logf
+	}()
+
+	newCache := func(reset bool) {
+		c, err := New[int, int](10, WithLeakCheck())
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+		c.Set(1, 1)
+		if reset {
+			c.Reset()
+		}
+	}
+
+	// A cache that was reset is collected silently.
+	newCache(true)
+	collect := func() {
+		for range 5 {
+			runtime.GC()
+			time.Sleep(time.Millisecond)
+		}
+	}
+	collect()
+	select {
+	case msg := <-logged:
+		t.Fatalf("unexpected leak warning for a reset cache: %s", msg)
+	default:
+	}
+
+	newCache(false)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		select {
+		case msg := <-logged:
+			if !strings.Contains(msg, "without Reset") || !strings.Contains(msg, "leak_test.go:") {
+				t.Fatalf("unexpected leak warning; got %q", msg)
+			}
+
+			return
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no leak warning for a cache collected without Reset")
+		}
+		collect()
+	}
+}
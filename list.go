@@ -0,0 +1,83 @@
+package fastcache
+
+// node is an element of the cache-wide eviction list.
+//
+// Each live entry references its node, so the node can be moved or unlinked
+// in O(1) without scanning the list.
+type node[K comparable] struct {
+	prev, next *node[K]
+
+	shard int
+	hash  uint64
+	key   K
+}
+
+// evictionList is an intrusive doubly-linked list of nodes ordered from the
+// next eviction candidate (front) to the most recently inserted or used entry
+// (back).
+//
+// The zero value is an empty list ready to use.
+type evictionList[K comparable] struct {
+	root node[K] // sentinel; root.next is the front, root.prev is the back
+	len  int
+}
+
+func (l *evictionList[K]) lazyInit() {
+	if l.root.next == nil {
+		l.root.next = &l.root
+		l.root.prev = &l.root
+	}
+}
+
+func (l *evictionList[K]) front() *node[K] {
+	if l.len == 0 {
+		return nil
+	}
+
+	return l.root.next
+}
+
+func (l *evictionList[K]) next(n *node[K]) *node[K] {
+	if n.next == &l.root {
+		return nil
+	}
+
+	return n.next
+}
+
+func (l *evictionList[K]) pushBack(n *node[K]) {
+	l.lazyInit()
+	l.insertBefore(n, &l.root)
+	l.len++
+}
+
+func (l *evictionList[K]) remove(n *node[K]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev = nil
+	n.next = nil
+	l.len--
+}
+
+func (l *evictionList[K]) moveToBack(n *node[K]) {
+	if l.root.prev == n {
+		return
+	}
+
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	l.insertBefore(n, &l.root)
+}
+
+func (l *evictionList[K]) insertBefore(n, at *node[K]) {
+	n.prev = at.prev
+	n.next = at
+	at.prev.next = n
+	at.prev = n
+}
+
+func (l *evictionList[K]) reset() {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+}
@@ -0,0 +1,195 @@
+package fastcache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Loading is a read-through cache: on a miss, [Loading.Get] calls a loader
+// function and stores its result in the underlying [Cache].
+//
+// Concurrent misses for the same key share a single loader call.
+type Loading[K comparable, V any] struct {
+	c           *Cache[K, V]
+	loader      func(K) (V, error)
+	negativeTTL time.Duration
+	flights     [shardsCount]flightGroup[K, V]
+}
+
+// flightGroup tracks the loader calls in progress for the keys of a shard.
+type flightGroup[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*flight[V]
+}
+
+// flight is a loader call in progress. v and err are set before done is
+// closed.
+type flight[V any] struct {
+	done chan struct{}
+	v    V
+	err  error
+
+	// stale is the expired value being refreshed by [Cache.GetOrRefresh];
+	// hasStale is false if there is none.
+	stale    V
+	hasStale bool
+}
+
+// start registers a new flight for k. g.mu must be held.
+func (g *flightGroup[K, V]) start(k K) *flight[V] {
+	f := &flight[V]{done: make(chan struct{})}
+	if g.calls == nil {
+		g.calls = make(map[K]*flight[V])
+	}
+	g.calls[k] = f
+
+	return f
+}
+
+// finish removes f from g and wakes up its waiters.
+func (g *flightGroup[K, V]) finish(k K, f *flight[V]) {
+	g.mu.Lock()
+	delete(g.calls, k)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// NewLoading returns a new read-through cache backed by a [Cache] created
+// with [New] and the given maxEntries and opts. loader is called on misses
+// to load the value for a key.
+//
+// Loader errors are returned to the callers waiting for the load and are not
+// cached, unless [WithNegativeCache] is passed.
+//
+// NewLoading returns an error if [New] does.
+func NewLoading[K comparable, V any](maxEntries int, loader func(K) (V, error), opts ...Option) (*Loading[K, V], error) {
+	c, err := New[K, V](maxEntries, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Loading[K, V]{
+		c:           c,
+		loader:      loader,
+		negativeTTL: o.negativeTTL,
+	}, nil
+}
+
+// Cache returns the underlying cache, e.g. to read its [Stats], or to set or
+// delete keys directly.
+func (l *Loading[K, V]) Cache() *Cache[K, V] {
+	return l.c
+}
+
+// Get returns the value for the given key, loading and storing it on a miss.
+//
+// If the key is being loaded by another goroutine, Get waits for that load
+// and returns its result instead of calling the loader again.
+//
+// Get returns the loader error if the load fails. With [WithNegativeCache],
+// the failure is cached, and Get returns an error wrapping
+// [ErrNegativeCached] without calling the loader until the negative entry
+// expires. Get also returns an error if the loaded value cannot be stored
+// because the cache cannot evict an existing entry while full.
+func (l *Loading[K, V]) Get(k K) (V, error) {
+	var zero V
+
+	v, found, negative := l.c.GetNegative(k)
+	if found {
+		return v, nil
+	}
+	if negative {
+		return zero, fmt.Errorf("%w: %v", ErrNegativeCached, k)
+	}
+
+	h := l.c.hasher(k)
+	idx := l.c.shardIndexFromHash(h)
+	g := &l.flights[idx]
+
+	g.mu.Lock()
+	if f, ok := g.calls[k]; ok {
+		g.mu.Unlock()
+		<-f.done
+
+		return f.v, f.err
+	}
+
+	// A load may have completed between the lookup above and taking g.mu.
+	v, found, negative = l.c.shards[idx].probe(l.c, h, k)
+	if found || negative {
+		g.mu.Unlock()
+		if negative {
+			return zero, fmt.Errorf("%w: %v", ErrNegativeCached, k)
+		}
+
+		return v, nil
+	}
+
+	f := g.start(k)
+	g.mu.Unlock()
+
+	// The flight is completed even if the loader panics, so that waiters are
+	// not blocked forever.
+	defer func() {
+		if r := recover(); r != nil {
+			f.err = fmt.Errorf("fastcache: loader panicked: %v", r)
+			g.finish(k, f)
+			panic(r)
+		}
+		g.finish(k, f)
+	}()
+
+	f.v, f.err = l.load(k)
+	if f.err != nil {
+		f.v = zero
+	}
+
+	return f.v, f.err
+}
+
+// load calls the loader for k and stores its result.
+func (l *Loading[K, V]) load(k K) (V, error) {
+	v, err := l.loader(k)
+	if err != nil {
+		if l.negativeTTL > 0 {
+			if setErr := l.c.SetNegative(k, l.negativeTTL); setErr != nil {
+				return v, errors.Join(err, fmt.Errorf("cannot cache loader error: %w", setErr))
+			}
+		}
+
+		return v, err
+	}
+
+	if err := l.c.Set(k, v); err != nil {
+		return v, err
+	}
+
+	return v, nil
+}
+
+// probe is like getNegative, but does not count as a Get call in [Stats] and
+// does not promote the key under [PolicyLRU].
+func (s *shard[K, V]) probe(c *Cache[K, V], hash uint64, k K) (V, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero V
+
+	bucket := s.entries[hash]
+	pos := findEntry(bucket, k)
+	if pos < 0 || c.expired(&bucket[pos]) {
+		return zero, false, false
+	}
+	if bucket[pos].negative {
+		return zero, false, true
+	}
+
+	return bucket[pos].Value, true, false
+}
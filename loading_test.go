@@ -0,0 +1,174 @@
+package fastcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoading(t *testing.T) {
+	var calls atomic.Int64
+	l, err := NewLoading(100, func(k int) (int, error) {
+		calls.Add(1)
+
+		return k * 2, nil
+	})
+	if err != nil {
+		t.Fatalf("NewLoading error: %s", err)
+	}
+	defer l.Cache().Reset()
+
+	for range 3 {
+		v, err := l.Get(21)
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		if v != 42 {
+			t.Fatalf("unexpected value; got %d; want 42", v)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("unexpected loader calls; got %d; want 1", n)
+	}
+	if v, ok := l.Cache().Get(21); !ok || v != 42 {
+		t.Fatalf("loaded value must be stored; got (%d, %t); want (42, true)", v, ok)
+	}
+}
+
+func TestLoadingSingleFlight(t *testing.T) {
+	var calls atomic.Int64
+	release := make(chan struct{})
+	l, err := NewLoading(100, func(k string) (string, error) {
+		calls.Add(1)
+		<-release
+
+		return "value of " + k, nil
+	})
+	if err != nil {
+		t.Fatalf("NewLoading error: %s", err)
+	}
+	defer l.Cache().Reset()
+
+	const workers = 16
+
+	var (
+		wg      sync.WaitGroup
+		started sync.WaitGroup
+	)
+	started.Add(workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			v, err := l.Get("key")
+			if err != nil {
+				t.Errorf("Get error: %s", err)
+
+				return
+			}
+			if v != "value of key" {
+				t.Errorf("unexpected value; got %q; want %q", v, "value of key")
+			}
+		}()
+	}
+	started.Wait()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("concurrent misses must share one loader call; got %d calls", n)
+	}
+}
+
+func TestLoadingErrors(t *testing.T) {
+	errLoad := errors.New("load failed")
+
+	t.Run("not cached", func(t *testing.T) {
+		var calls atomic.Int64
+		l, err := NewLoading(100, func(int) (int, error) {
+			calls.Add(1)
+
+			return 0, errLoad
+		})
+		if err != nil {
+			t.Fatalf("NewLoading error: %s", err)
+		}
+		defer l.Cache().Reset()
+
+		for range 2 {
+			if _, err := l.Get(1); !errors.Is(err, errLoad) {
+				t.Fatalf("Get must return the loader error; got: %v", err)
+			}
+		}
+		if n := calls.Load(); n != 2 {
+			t.Fatalf("errors must not be cached; got %d loader calls; want 2", n)
+		}
+		if l.Cache().Len() != 0 {
+			t.Fatalf("unexpected len; got %d; want 0", l.Cache().Len())
+		}
+	})
+
+	t.Run("negative cache", func(t *testing.T) {
+		var calls atomic.Int64
+		l, err := NewLoading(100, func(int) (int, error) {
+			calls.Add(1)
+
+			return 0, errLoad
+		}, WithNegativeCache(time.Hour))
+		if err != nil {
+			t.Fatalf("NewLoading error: %s", err)
+		}
+		defer l.Cache().Reset()
+
+		if _, err := l.Get(1); !errors.Is(err, errLoad) {
+			t.Fatalf("Get must return the loader error; got: %v", err)
+		}
+		if _, err := l.Get(1); !errors.Is(err, ErrNegativeCached) {
+			t.Fatalf("Get must return ErrNegativeCached; got: %v", err)
+		}
+		if n := calls.Load(); n != 1 {
+			t.Fatalf("errors must be cached; got %d loader calls; want 1", n)
+		}
+
+		// The loader is called again once the negative entry expires.
+		expireKey(l.Cache(), 1)
+		if _, err := l.Get(1); !errors.Is(err, errLoad) {
+			t.Fatalf("Get must return the loader error; got: %v", err)
+		}
+		if n := calls.Load(); n != 2 {
+			t.Fatalf("unexpected loader calls after expiry; got %d; want 2", n)
+		}
+	})
+}
+
+func TestLoadingPanic(t *testing.T) {
+	l, err := NewLoading(100, func(int) (int, error) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("NewLoading error: %s", err)
+	}
+	defer l.Cache().Reset()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("unexpected panic; got %v; want boom", r)
+			}
+		}()
+		_, _ = l.Get(1)
+	}()
+
+	// The failed flight must not block later loads.
+	g := &l.flights[l.Cache().shardIndexFromHash(l.Cache().hasher(1))]
+	g.mu.Lock()
+	n := len(g.calls)
+	g.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("unexpected flights in progress after panic; got %d; want 0", n)
+	}
+}
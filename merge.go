@@ -0,0 +1,71 @@
+package fastcache
+
+// Merge stores all entries of other in c, in the eviction order of other.
+//
+// If a key is present in both caches, onConflict is called with the key, the
+// value in c and the value in other, and its result is stored. A nil
+// onConflict keeps the value in other. Merged entries keep their expiration
+// and cost from other, and each counts as a Set call in [Stats] of c.
+//
+// Merge takes a consistent snapshot of other before storing anything, so
+// concurrent changes to other are not reflected. c keeps its capacity and
+// eviction policy: if the merged entries do not fit, the oldest entries of c,
+// including entries merged earlier, are evicted as if they had been set one
+// at a time.
+//
+// Note: onConflict is called while c's locks are held, so it must not call
+// methods of c, which would deadlock.
+//
+// Merge returns an error if an entry of other cannot be stored, e.g. because
+// it exceeds the byte or cost limit of c. Entries merged before the error
+// remain stored.
+func (c *Cache[K, V]) Merge(other *Cache[K, V], onConflict func(k K, existing, incoming V) V) error {
+	entries := other.orderedEntries()
+
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	for i := range entries {
+		if err := c.mergeLocked(&entries[i], onConflict); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeLocked stores e in c, resolving a conflict with an existing entry
+// with onConflict. c.orderMu must be held.
+func (c *Cache[K, V]) mergeLocked(e *entry[K, V], onConflict func(k K, existing, incoming V) V) error {
+	h := c.hasher(e.Key)
+	idx := c.shardIndexFromHash(h)
+	s := &c.shards[idx]
+
+	s.mu.Lock()
+	if !c.noStats {
+		s.setCalls++
+	}
+
+	bucket, pos := s.lookupLocked(c, h, e.Key)
+	if pos >= 0 {
+		v := e.Value
+		if onConflict != nil {
+			v = onConflict(e.Key, bucket[pos].Value, e.Value)
+		}
+		if err := s.replaceLocked(c, &bucket[pos], v, expiry{at: e.expireAt, ttl: e.ttl}, e.cost); err != nil {
+			s.mu.Unlock()
+
+			return err
+		}
+		c.touchLocked(bucket[pos].node)
+		s.mu.Unlock()
+		c.evictOverLimitsLocked()
+
+		return nil
+	}
+	s.mu.Unlock()
+
+	_, err := c.insertLocked(opSet, idx, h, e.Key, e.Value, expiry{at: e.expireAt, ttl: e.ttl}, e.cost)
+
+	return err
+}
@@ -0,0 +1,137 @@
+package fastcache
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCacheMerge(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	other, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer other.Reset()
+
+	for k, v := range map[string]int{"a": 1, "b": 2} {
+		if err := c.Set(k, v); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	for k, v := range map[string]int{"b": 20, "c": 30} {
+		if err := other.Set(k, v); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if err := other.SetWithTTL("ttl", 40, time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+
+	sum := func(_ string, existing, incoming int) int {
+		return existing + incoming
+	}
+	if err := c.Merge(other, sum); err != nil {
+		t.Fatalf("Merge error: %s", err)
+	}
+
+	want := map[string]int{"a": 1, "b": 22, "c": 30, "ttl": 40}
+	if c.Len() != len(want) {
+		t.Fatalf("unexpected len after Merge; got %d; want %d", c.Len(), len(want))
+	}
+	for k, v := range want {
+		if got, ok := c.Get(k); !ok || got != v {
+			t.Fatalf("unexpected value for %q; got (%d, %t); want (%d, true)", k, got, ok, v)
+		}
+	}
+	if _, exp, _ := c.GetWithExpiry("ttl"); exp.IsZero() {
+		t.Fatal("Merge must keep the expiration of merged entries")
+	}
+	if other.Len() != 3 {
+		t.Fatalf("Merge must not modify other; got len %d; want 3", other.Len())
+	}
+
+	// A nil onConflict keeps the incoming value.
+	if err := other.Set("a", 100); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.Merge(other, nil); err != nil {
+		t.Fatalf("Merge error: %s", err)
+	}
+	if v, _ := c.Get("a"); v != 100 {
+		t.Fatalf("unexpected value after Merge without onConflict; got %d; want 100", v)
+	}
+}
+
+func TestCacheMergeEvicts(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	other, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer other.Reset()
+
+	for i := range 5 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	for i := 100; i < 120; i++ {
+		if err := other.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	if err := c.Merge(other, nil); err != nil {
+		t.Fatalf("Merge error: %s", err)
+	}
+
+	// The merged entries are inserted in the eviction order of other, so the
+	// last ten survive.
+	var got []int
+	for k := range c.AllOrdered() {
+		got = append(got, k)
+	}
+	if want := []int{110, 111, 112, 113, 114, 115, 116, 117, 118, 119}; !slices.Equal(got, want) {
+		t.Fatalf("unexpected entries after Merge; got %v; want %v", got, want)
+	}
+	if s := c.Stats(); s.Evictions != 15 {
+		t.Fatalf("unexpected Evictions; got %d; want 15", s.Evictions)
+	}
+}
+
+func TestCacheMergeSelf(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 5 {
+		if err := c.Set(i, i); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	double := func(_ int, existing, incoming int) int {
+		return existing + incoming
+	}
+	if err := c.Merge(c, double); err != nil {
+		t.Fatalf("Merge error: %s", err)
+	}
+	for i := range 5 {
+		if v, _ := c.Get(i); v != 2*i {
+			t.Fatalf("unexpected value for %d; got %d; want %d", i, v, 2*i)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package fastcache
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetNegative stores a negative entry for k, which records that k is known
+// to be absent, for the given ttl.
+//
+// A negative entry holds no value. [Cache.Get], [Cache.Peek] and the
+// iterators treat it as missing, while [Cache.GetNegative] reports it, so
+// that callers can tell a cached "not found" result from a cache miss. Any
+// operation that stores a value for k replaces the negative entry.
+//
+// Negative entries count towards [Cache.Len] and the capacity of the cache,
+// and are evicted like other entries. They are saved and restored with their
+// remaining TTL by [Cache.SaveTo] and the other save methods.
+//
+// SetNegative returns an error if ttl is not positive or if the cache cannot
+// evict an existing entry while full.
+func (c *Cache[K, V]) SetNegative(k K, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("%w: got %s", ErrInvalidTTL, ttl)
+	}
+
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	var zero V
+	_, err := c.runInsert(opSetNegative, idx, h, k, zero, c.expiryAfter(ttl), 1)
+
+	return err
+}
+
+// GetNegative returns the value for the given key, like [Cache.Get], and
+// additionally reports whether the key has a negative entry set with
+// [Cache.SetNegative].
+//
+// The found result is true if a value is stored for the key. The negative
+// result is true if the key is known to be absent; found is then false and v
+// is the zero value. Both are false on a cache miss. A negative entry counts
+// as a hit in [Stats].
+func (c *Cache[K, V]) GetNegative(k K) (v V, found, negative bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].getNegative(c, h, k)
+}
+
+// visible reports whether e holds a value, i.e. it is neither expired nor
+// negative.
+func (c *Cache[K, V]) visible(e *entry[K, V]) bool {
+	return !e.negative && !c.expired(e)
+}
+
+func (s *shard[K, V]) getNegative(c *Cache[K, V], hash uint64, k K) (V, bool, bool) {
+	c.lockShard(s)
+	defer c.unlockShard(s)
+
+	if !c.noStats {
+		s.getCalls++
+	}
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		c.touchLocked(bucket[pos].node)
+
+		return bucket[pos].Value, true, false
+	}
+
+	var zero V
+	if pos := findEntry(bucket, k); pos >= 0 {
+		// Only a negative entry can remain after lookupLocked.
+		c.touchLocked(bucket[pos].node)
+
+		return zero, false, true
+	}
+
+	if !c.noStats {
+		s.misses++
+	}
+
+	return zero, false, false
+}
+
+// dropNegativeLocked removes the negative entry for k from bucket, if any,
+// and returns the updated bucket. It must only be called after lookupLocked
+// reported k as missing. s.mu must be held.
+func (s *shard[K, V]) dropNegativeLocked(c *Cache[K, V], hash uint64, bucket []entry[K, V], k K) []entry[K, V] {
+	pos := findEntry(bucket, k)
+	if pos < 0 {
+		return bucket
+	}
+
+	s.unlinkLocked(c, hash, bucket, pos)
+
+	return s.entries[hash]
+}
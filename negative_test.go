@@ -0,0 +1,130 @@
+package fastcache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCacheSetNegative(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.SetNegative("key", 0); !errors.Is(err, ErrInvalidTTL) {
+		t.Fatalf("SetNegative returned error %v; want %v", err, ErrInvalidTTL)
+	}
+
+	if _, found, negative := c.GetNegative("key"); found || negative {
+		t.Fatalf("unexpected GetNegative result for missing key; got found=%t, negative=%t", found, negative)
+	}
+
+	if err := c.SetNegative("key", time.Hour); err != nil {
+		t.Fatalf("SetNegative error: %s", err)
+	}
+	if v, found, negative := c.GetNegative("key"); found || !negative || v != 0 {
+		t.Fatalf("unexpected GetNegative result; got (%d, %t, %t); want (0, false, true)", v, found, negative)
+	}
+
+	// Negative entries are hidden from the other read paths
+	if _, ok := c.Get("key"); ok {
+		t.Fatal("Get returned a negative entry")
+	}
+	if _, ok := c.Peek("key"); ok {
+		t.Fatal("Peek returned a negative entry")
+	}
+	for range c.All() {
+		t.Fatal("All yielded a negative entry")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("unexpected len with a negative entry; got %d; want 1", c.Len())
+	}
+
+	// Storing a value replaces the negative entry
+	stored, err := c.SetIfAbsent("key", 42)
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	if !stored {
+		t.Fatal("SetIfAbsent did not replace a negative entry")
+	}
+	if v, found, negative := c.GetNegative("key"); !found || negative || v != 42 {
+		t.Fatalf("unexpected GetNegative result; got (%d, %t, %t); want (42, true, false)", v, found, negative)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("unexpected len after replacing a negative entry; got %d; want 1", c.Len())
+	}
+
+	// A value can be turned into a negative entry
+	if err := c.SetNegative("key", time.Hour); err != nil {
+		t.Fatalf("SetNegative error: %s", err)
+	}
+	if _, found, negative := c.GetNegative("key"); found || !negative {
+		t.Fatalf("unexpected GetNegative result; got found=%t, negative=%t; want false, true", found, negative)
+	}
+
+	// Negative entries expire
+	expireKey(c, "key")
+	if _, found, negative := c.GetNegative("key"); found || negative {
+		t.Fatalf("unexpected GetNegative result after expiry; got found=%t, negative=%t", found, negative)
+	}
+
+	if err := c.SetNegative("other", time.Hour); err != nil {
+		t.Fatalf("SetNegative error: %s", err)
+	}
+	if c.Delete("other") {
+		t.Fatal("Delete reported a negative entry as present")
+	}
+	if _, _, negative := c.GetNegative("other"); negative || c.Len() != 0 {
+		t.Fatalf("Delete did not remove the negative entry; negative=%t, len=%d", negative, c.Len())
+	}
+}
+
+func TestSaveLoadKeepsNegative(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("found", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.SetNegative("missing", time.Hour); err != nil {
+		t.Fatalf("SetNegative error: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatalf("SaveTo error: %s", err)
+	}
+	c2, err := LoadFrom[string, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFrom error: %s", err)
+	}
+	defer c2.Reset()
+
+	if _, found, negative := c2.GetNegative("missing"); found || !negative {
+		t.Fatalf("unexpected GetNegative result after load; got found=%t, negative=%t; want false, true", found, negative)
+	}
+	if v, ok := c2.Get("found"); !ok || v != 1 {
+		t.Fatalf("unexpected value after load; got (%d, %t); want (1, true)", v, ok)
+	}
+
+	buf.Reset()
+	if err := c.SaveToJSON(&buf); err != nil {
+		t.Fatalf("SaveToJSON error: %s", err)
+	}
+	c3, err := LoadFromJSON[string, int](&buf)
+	if err != nil {
+		t.Fatalf("LoadFromJSON error: %s", err)
+	}
+	defer c3.Reset()
+
+	if _, found, negative := c3.GetNegative("missing"); found || !negative {
+		t.Fatalf("unexpected GetNegative result after JSON load; got found=%t, negative=%t; want false, true", found, negative)
+	}
+}
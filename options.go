@@ -0,0 +1,363 @@
+package fastcache
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Policy selects which entry is evicted when the cache is full.
+type Policy uint8
+
+const (
+	// PolicyFIFO evicts the oldest inserted entry first. This is the default.
+	PolicyFIFO Policy = iota
+
+	// PolicyLRU evicts the least recently used entry first.
+	//
+	// Reads that find the key move it to the most recent position. This
+	// requires taking the cache-wide eviction lock on every hit, so LRU trades
+	// some read throughput for better hit ratios on skewed workloads.
+	PolicyLRU
+)
+
+// String returns the policy name.
+func (p Policy) String() string {
+	switch p {
+	case PolicyFIFO:
+		return "fifo"
+	case PolicyLRU:
+		return "lru"
+	default:
+		return fmt.Sprintf("Policy(%d)", uint8(p))
+	}
+}
+
+// Option configures a [Cache] created by [New].
+type Option func(*options)
+
+type options struct {
+	policy   Policy
+	maxBytes int64
+	maxCost  int64
+	sizeOf   any // func(K, V) int64
+
+	janitorInterval time.Duration
+	statsDisabled   bool
+	negativeTTL     time.Duration
+	clock           Clock
+	hashSeed        uint64
+	serveStale      bool
+	strictCapacity  bool
+	evictionBatch   int
+	leakCheck       bool
+	sampleRate      float64
+	valueCopy       bool
+	valueCopyFunc   any // func(V) V
+
+	evictionChannel  any // chan<- Evicted[K, V]
+	evictionBlocking bool
+
+	initialCapacity    int
+	hasInitialCapacity bool
+
+	lockStripes int
+}
+
+// WithPolicy sets the eviction policy. The default is [PolicyFIFO].
+func WithPolicy(p Policy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithMaxBytes caps the estimated size of all entries at n bytes.
+//
+// When a set would exceed the limit, the oldest entries are evicted until the
+// new entry fits. If maxEntries is reached as well, eviction happens when
+// either limit is exceeded. Entry sizes are estimated with the function given
+// to [WithSizeOf], or with a default estimate of the entry overhead plus the
+// length of string and []byte keys and values.
+//
+// A zero n disables the byte limit. This is the default.
+func WithMaxBytes(n int64) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// WithMaxCost caps the total cost of all entries.
+//
+// Entries stored with [Cache.SetWithCost] carry the given cost; all other
+// entries cost 1. When a set would exceed the limit, the oldest entries are
+// evicted until the new entry fits, so a few expensive entries cannot
+// monopolize the cache. maxEntries and [WithMaxBytes] still apply.
+//
+// A zero total disables the cost limit. This is the default.
+func WithMaxCost(total int64) Option {
+	return func(o *options) {
+		o.maxCost = total
+	}
+}
+
+// WithSizeOf sets the function used to estimate the size of an entry in
+// bytes, enabling [Cache.Bytes] even without [WithMaxBytes].
+//
+// The K and V type parameters must match those of the cache passed to [New].
+func WithSizeOf[K comparable, V any](fn func(K, V) int64) Option {
+	return func(o *options) {
+		o.sizeOf = fn
+	}
+}
+
+// WithValueCopy makes the cache store a copy of every value it is given, so
+// that the caller may modify the original afterwards without affecting the
+// cache. By default, values are stored as is: a []byte value shares its
+// backing array with the caller, and so do maps and pointers.
+//
+// WithValueCopy copies []byte values with [bytes.Clone]. String values are
+// immutable and are not copied. Other value types need a copy function set
+// with [WithValueCopyFunc]; otherwise [New] returns [ErrInvalidValueCopy].
+//
+// Only the stored value is copied: the values returned by [Cache.Get] and
+// other reads are still shared with the cache and must not be modified.
+func WithValueCopy() Option {
+	return func(o *options) {
+		o.valueCopy = true
+	}
+}
+
+// WithValueCopyFunc sets the function used to copy the values stored in the
+// cache, like [WithValueCopy] does for []byte values, e.g. to deep-copy a
+// struct holding slices. It implies WithValueCopy.
+//
+// The V type parameter must match that of the cache passed to [New].
+func WithValueCopyFunc[V any](fn func(V) V) Option {
+	return func(o *options) {
+		o.valueCopyFunc = fn
+	}
+}
+
+// WithEvictionChannel sends every evicted entry to ch, e.g. to hand it to a
+// downstream processor. Entries evicted by [Cache.Trim] and
+// [Cache.EvictOldest] are sent as well; expired and deleted entries are not.
+//
+// Entries are sent after the cache locks are released, so a slow receiver
+// never holds up other cache operations, only the call that evicted them.
+// If blocking is true, that call waits until ch accepts every entry it
+// evicted. Otherwise entries that do not fit into ch are dropped and counted
+// in [Stats].EvictionDrops. Entries evicted by concurrent calls may arrive
+// out of eviction order.
+//
+// The K and V type parameters must match those of the cache passed to [New].
+// A nil ch disables the channel. This is the default.
+func WithEvictionChannel[K comparable, V any](ch chan<- Evicted[K, V], blocking bool) Option {
+	return func(o *options) {
+		o.evictionChannel = ch
+		o.evictionBlocking = blocking
+	}
+}
+
+// WithAccessSampling samples the given fraction of Get calls, from 0 to 1,
+// to track the most accessed keys for [Cache.TopKeys], e.g. for capacity
+// planning.
+//
+// Sampled keys are counted under the shard lock that the Get takes anyway,
+// and each shard tracks at most 16 keys, so sampling costs little time and
+// memory; lower rates cost less but make the counts coarser. A zero rate
+// disables sampling. This is the default.
+func WithAccessSampling(rate float64) Option {
+	return func(o *options) {
+		o.sampleRate = rate
+	}
+}
+
+// WithInitialCapacity sizes the shard maps for n entries instead of
+// maxEntries.
+//
+// By default [New] preallocates the maps for up to maxEntries entries, which
+// wastes memory while a large cache warms up. With a smaller n the maps start
+// small and grow as entries are added. n is only a hint and does not change the
+// capacity; [Cache.Clone] keeps using it.
+//
+// [New] returns [ErrInvalidInitialCapacity] unless 0 < n <= maxEntries.
+func WithInitialCapacity(n int) Option {
+	return func(o *options) {
+		o.initialCapacity = n
+		o.hasInitialCapacity = true
+	}
+}
+
+// WithJanitor starts a background goroutine that removes expired entries
+// every interval.
+//
+// Without a janitor, entries set with [Cache.SetWithTTL] are only removed when
+// they are accessed after expiring. The janitor locks one shard at a time and
+// skips shards without expiring entries.
+//
+// The janitor stops on [Cache.Stop], [Cache.Reset], or when the cache is
+// garbage collected. A non-positive interval disables the janitor. This is
+// the default.
+func WithJanitor(interval time.Duration) Option {
+	return func(o *options) {
+		o.janitorInterval = interval
+	}
+}
+
+// WithClock sets the clock used to expire entries.
+//
+// [Cache.SetWithTTL], [Cache.SetNegative], lazy expiration on access and the
+// janitor all read the current time from clock, so tests can advance a fake
+// clock instead of sleeping. The janitor still runs at its real-time
+// interval, but uses clock to decide which entries have expired.
+//
+// A nil clock selects the real-time clock. This is the default.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithHashSeed sets the seed of the hash function that distributes keys
+// across shards.
+//
+// The default seed is a fixed 0, so a key hashes identically in every
+// process, and WithHashSeed(0) is the same as the default. Keys of types
+// containing pointers, channels or interfaces holding them hash by address,
+// and NaN floats hash randomly, so such keys never hash identically across
+// runs.
+//
+// A fixed seed is public, so callers that control the keys can craft many
+// keys with the same hash, which land in the same shard and degrade lookups
+// for that hash to a linear scan. Caches holding untrusted keys should pass
+// a random seed, e.g. from [crypto/rand], and keep it secret. Persisted data
+// does not depend on the seed, so it may differ between processes.
+func WithHashSeed(seed uint64) Option {
+	return func(o *options) {
+		o.hashSeed = seed
+	}
+}
+
+// WithStaleWhileRevalidate makes [Cache.GetOrRefresh] return the expired
+// value of a key to callers that find it being refreshed, instead of
+// blocking them until the refresh completes.
+//
+// Only the caller that triggered the refresh waits for it. Callers that find
+// no expired value, e.g. because it was purged by the janitor, still wait.
+func WithStaleWhileRevalidate() Option {
+	return func(o *options) {
+		o.serveStale = true
+	}
+}
+
+// WithStrictCapacity makes the cache reject new keys instead of evicting
+// entries when it is full.
+//
+// A Set of a new key that would exceed maxEntries, [WithMaxBytes] or
+// [WithMaxCost] then fails with an error wrapping [ErrCacheFull], as does
+// [Cache.GetOrSet], and [Cache.TrySet] returns false. Existing keys can
+// still be overwritten, but an overwrite that grows the entry beyond the room
+// left under [WithMaxBytes] or [WithMaxCost] fails with [ErrCacheFull] too,
+// and [Cache.Replace] and [Cache.GetAndSet] return false for it. This suits
+// caches used as bounded registries, where silently dropping an entry would
+// be a bug. [Cache.Trim] still evicts entries when asked to.
+func WithStrictCapacity() Option {
+	return func(o *options) {
+		o.strictCapacity = true
+	}
+}
+
+// WithEvictionBatch limits how many slots of deleted entries a single insert
+// skips while looking for an entry to evict.
+//
+// Deleted entries leave their slots in the eviction order until an eviction
+// reaches them, so after many deletes one insert into a full cache may skip
+// a long run of them, stalling that insert. With a positive n, an insert that
+// has skipped n slots stops looking and stores its entry anyway, leaving the
+// cache over its limits by that entry. Later inserts continue the cleanup
+// and evict down to the limits again once they reach live entries. A
+// non-positive n, the default, skips as many slots as needed.
+func WithEvictionBatch(n int) Option {
+	return func(o *options) {
+		o.evictionBatch = n
+	}
+}
+
+// WithLockStripes sets the number of locks guarding the 512 shards of the
+// cache. n must be a power of two between 1 and 512.
+//
+// Shard i is guarded by lock i%n, so with fewer locks than shards, several
+// shards share one lock and operations on keys of different shards may
+// contend. This trades write throughput on many cores for fewer locks, which
+// matters for processes holding many small caches. The default is one lock
+// per shard. A shard holds a single map, so it cannot be split across more
+// locks than that.
+func WithLockStripes(n int) Option {
+	return func(o *options) {
+		o.lockStripes = n
+	}
+}
+
+// WithLeakCheck logs a warning with [log.Printf] if the cache is garbage
+// collected without [Cache.Reset] having been called.
+//
+// Each cache allocates maps for all of its shards, so forgetting Reset on
+// many short-lived caches holds on to memory until they are collected. The
+// warning names the file and line that created the cache, which helps to
+// find such caches in tests or during development. The check registers a
+// [runtime.AddCleanup] cleanup per cache, so it is off by default.
+func WithLeakCheck() Option {
+	return func(o *options) {
+		o.leakCheck = true
+	}
+}
+
+// WithStatsDisabled disables the per-shard counters behind [Stats].
+//
+// Get, Set, Delete and the other operations then skip counter updates, which
+// saves a little work per call in hot loops. [Cache.UpdateStats] reports
+// zero for all counters, but still reports the entry and byte counts and
+// limits.
+func WithStatsDisabled() Option {
+	return func(o *options) {
+		o.statsDisabled = true
+	}
+}
+
+// WithNegativeCache makes a [Loading] cache remember loader errors for ttl.
+//
+// A failed load stores a negative entry with [Cache.SetNegative], so further
+// misses for the key fail fast with [ErrNegativeCached] instead of calling
+// the loader again until ttl elapses. [New] ignores this option. A
+// non-positive ttl disables negative caching. This is the default.
+func WithNegativeCache(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+func (o *options) validate() error {
+	switch o.policy {
+	case PolicyFIFO, PolicyLRU:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidPolicy, o.policy)
+	}
+
+	if o.maxBytes < 0 {
+		return fmt.Errorf("%w: got %d", ErrInvalidMaxBytes, o.maxBytes)
+	}
+
+	if o.maxCost < 0 {
+		return fmt.Errorf("%w: got %d", ErrInvalidMaxCost, o.maxCost)
+	}
+
+	if o.sampleRate < 0 || o.sampleRate > 1 || math.IsNaN(o.sampleRate) {
+		return fmt.Errorf("%w: got %v", ErrInvalidSampleRate, o.sampleRate)
+	}
+
+	if o.lockStripes != 0 && (o.lockStripes < 0 || o.lockStripes > shardsCount || o.lockStripes&(o.lockStripes-1) != 0) {
+		return fmt.Errorf("%w: got %d", ErrInvalidLockStripes, o.lockStripes)
+	}
+
+	return nil
+}
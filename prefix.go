@@ -0,0 +1,25 @@
+package fastcache
+
+import "strings"
+
+// DeletePrefix removes the entries whose keys start with prefix and returns
+// how many it removed, e.g. to invalidate all keys of a namespace such as
+// "user:123:".
+//
+// Keys are spread over the shards by hash, so DeletePrefix scans every entry
+// of the cache, locking one shard at a time like [Cache.UpdateEach]. It is
+// O(n) in the number of entries and meant for occasional invalidation, not
+// for hot paths. Each removed entry counts as a Delete call in [Stats].
+func DeletePrefix[V any](c *Cache[string, V], prefix string) int {
+	deleted := 0
+	c.UpdateEach(func(k string, v V) (V, UpdateAction) {
+		if !strings.HasPrefix(k, prefix) {
+			return v, UpdateKeep
+		}
+		deleted++
+
+		return v, UpdateDelete
+	})
+
+	return deleted
+}
@@ -0,0 +1,50 @@
+package fastcache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDeletePrefix(t *testing.T) {
+	c, err := New[string, int](1000)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for user := range 3 {
+		for i := range 100 {
+			if err := c.Set(fmt.Sprintf("user:%d:session:%d", user, i), i); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+	}
+	if err := c.Set("user:1", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	if n := DeletePrefix(c, "user:1:"); n != 100 {
+		t.Fatalf("unexpected number of deleted entries; got %d; want 100", n)
+	}
+	if n := c.Len(); n != 201 {
+		t.Fatalf("unexpected len; got %d; want 201", n)
+	}
+	if _, ok := c.Peek("user:1:session:42"); ok {
+		t.Fatal("entry under the prefix was not deleted")
+	}
+	for _, k := range []string{"user:1", "user:0:session:42", "user:2:session:42"} {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("entry %q outside the prefix was deleted", k)
+		}
+	}
+	if s := c.Stats(); s.Deletes != 100 {
+		t.Fatalf("unexpected Deletes; got %d; want 100", s.Deletes)
+	}
+
+	if n := DeletePrefix(c, "user:1:"); n != 0 {
+		t.Fatalf("unexpected number of deleted entries on the second call; got %d; want 0", n)
+	}
+	if n := DeletePrefix(c, ""); n != 201 {
+		t.Fatalf("unexpected number of deleted entries for an empty prefix; got %d; want 201", n)
+	}
+}
@@ -0,0 +1,46 @@
+package fastcache
+
+import "time"
+
+// Eviction pressure is computed from snapshots of the per-shard counters,
+// which are taken by [Cache.UpdateStats] at most once per
+// pressureSnapshotInterval and kept in a small ring per shard. The pressure
+// is then the rate between the current counters and the newest snapshot that
+// is at least pressureWindow old, so Set and eviction paths stay untouched.
+const (
+	pressureWindow           = time.Minute
+	pressureSnapshotInterval = 10 * time.Second
+
+	// pressureSnapshots covers pressureWindow plus one interval, so that a
+	// snapshot old enough is kept while UpdateStats is called regularly.
+	pressureSnapshots = int(pressureWindow/pressureSnapshotInterval) + 2
+)
+
+// pressureSnapshot holds the counters of a shard at a point in time.
+type pressureSnapshot struct {
+	at        int64 // Unix nanoseconds
+	setCalls  uint64
+	evictions uint64
+	valid     bool
+}
+
+// recordPressureLocked records the current counters of the shard if the last
+// snapshot is older than pressureSnapshotInterval, and returns the Set calls
+// and evictions within the last pressureWindow, or since the counters were
+// reset if that is more recent. s.mu must be held.
+func (s *shard[K, V]) recordPressureLocked(now int64, setCalls, evictions uint64) (recentSetCalls, recentEvictions uint64) {
+	var base pressureSnapshot
+	for _, p := range s.pressure {
+		if p.valid && now-p.at >= int64(pressureWindow) && (!base.valid || p.at > base.at) {
+			base = p
+		}
+	}
+
+	last := s.pressure[(s.pressureNext+pressureSnapshots-1)%pressureSnapshots]
+	if !last.valid || now-last.at >= int64(pressureSnapshotInterval) {
+		s.pressure[s.pressureNext] = pressureSnapshot{at: now, setCalls: setCalls, evictions: evictions, valid: true}
+		s.pressureNext = (s.pressureNext + 1) % pressureSnapshots
+	}
+
+	return setCalls - base.setCalls, evictions - base.evictions
+}
@@ -0,0 +1,58 @@
+package fastcache
+
+import "iter"
+
+// ReadOnlyCache is a read-only view of a [Cache], as returned by
+// [Cache.ReadOnly]. It forwards its methods to the cache, so it sees every
+// change made through the cache, but it has no methods that store or remove
+// entries.
+//
+// Reads still have their usual side effects: Get counts in [Stats] and
+// promotes the key under [PolicyLRU], and an expired entry found by a read
+// is removed.
+type ReadOnlyCache[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+// ReadOnly returns a read-only view of c, for code that must not modify the
+// cache.
+func (c *Cache[K, V]) ReadOnly() ReadOnlyCache[K, V] {
+	return ReadOnlyCache[K, V]{c: c}
+}
+
+// Get returns the value for the given key. See [Cache.Get].
+func (r ReadOnlyCache[K, V]) Get(k K) (V, bool) {
+	return r.c.Get(k)
+}
+
+// Has reports whether an entry for the given key exists. See [Cache.Has].
+func (r ReadOnlyCache[K, V]) Has(k K) bool {
+	return r.c.Has(k)
+}
+
+// Len returns the number of entries in the cache.
+func (r ReadOnlyCache[K, V]) Len() int {
+	return r.c.Len()
+}
+
+// All returns an iterator over all key-value pairs in the cache. See
+// [Cache.All].
+func (r ReadOnlyCache[K, V]) All() iter.Seq2[K, V] {
+	return r.c.All()
+}
+
+// Keys returns an iterator over all keys in the cache. See [Cache.Keys].
+func (r ReadOnlyCache[K, V]) Keys() iter.Seq[K] {
+	return r.c.Keys()
+}
+
+// Values returns an iterator over all values in the cache. See
+// [Cache.Values].
+func (r ReadOnlyCache[K, V]) Values() iter.Seq[V] {
+	return r.c.Values()
+}
+
+// UpdateStats adds cache stats to s. See [Cache.UpdateStats].
+func (r ReadOnlyCache[K, V]) UpdateStats(s *Stats) {
+	r.c.UpdateStats(s)
+}
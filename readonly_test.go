@@ -0,0 +1,50 @@
+package fastcache
+
+import "testing"
+
+func TestCacheReadOnly(t *testing.T) {
+	c, err := New[string, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	r := c.ReadOnly()
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	// The view sees changes made through the cache.
+	if v, ok := r.Get("a"); !ok || v != 1 {
+		t.Fatalf("unexpected Get result; got %d, %v; want 1, true", v, ok)
+	}
+	if !r.Has("a") || r.Has("b") {
+		t.Fatal("unexpected Has result")
+	}
+	if n := r.Len(); n != 1 {
+		t.Fatalf("unexpected Len; got %d; want 1", n)
+	}
+
+	var n int
+	for k, v := range r.All() {
+		if k != "a" || v != 1 {
+			t.Fatalf("unexpected entry; got %q: %d; want \"a\": 1", k, v)
+		}
+		n++
+	}
+	for range r.Keys() {
+		n++
+	}
+	for range r.Values() {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("unexpected number of iterated items; got %d; want 3", n)
+	}
+
+	var s Stats
+	r.UpdateStats(&s)
+	if s.GetCalls != 1 || s.EntriesCount != 1 {
+		t.Fatalf("unexpected stats; got GetCalls=%d, EntriesCount=%d; want 1, 1", s.GetCalls, s.EntriesCount)
+	}
+}
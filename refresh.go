@@ -0,0 +1,110 @@
+package fastcache
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetOrRefresh returns the value for k if it is stored and not expired.
+// Otherwise it calls fn, stores its result for ttl and returns it.
+//
+// Concurrent callers that find k missing or expired share a single fn call:
+// exactly one of them calls fn, while the others wait for it and receive
+// its result, so a popular key expiring does not cause a stampede of
+// reloads. With [WithStaleWhileRevalidate], callers that find an expired
+// value being refreshed receive that value immediately instead of waiting.
+//
+// fn errors are returned to the callers waiting for the refresh and are not
+// cached. GetOrRefresh returns an error wrapping [ErrInvalidTTL] if ttl is
+// not positive, and an error if the refreshed value cannot be stored because
+// the cache cannot evict an existing entry while full.
+func (c *Cache[K, V]) GetOrRefresh(k K, ttl time.Duration, fn func() (V, error)) (V, error) {
+	var zero V
+
+	if ttl <= 0 {
+		return zero, fmt.Errorf("%w: got %s", ErrInvalidTTL, ttl)
+	}
+
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+	s := &c.shards[idx]
+
+	v, live, stale := s.getStale(c, h, k)
+	if live {
+		return v, nil
+	}
+
+	g := &s.refreshes
+	g.mu.Lock()
+	if f, ok := g.calls[k]; ok {
+		g.mu.Unlock()
+		if c.serveStale && f.hasStale {
+			return f.stale, nil
+		}
+		<-f.done
+
+		return f.v, f.err
+	}
+
+	// A refresh may have completed between the lookup above and taking g.mu.
+	if v, found, _ := s.probe(c, h, k); found {
+		g.mu.Unlock()
+
+		return v, nil
+	}
+
+	f := g.start(k)
+	f.stale, f.hasStale = v, stale
+	g.mu.Unlock()
+
+	// The flight is completed even if fn panics, so that waiters are not
+	// blocked forever.
+	defer func() {
+		if r := recover(); r != nil {
+			f.v, f.err = zero, fmt.Errorf("fastcache: refresh panicked: %v", r)
+			g.finish(k, f)
+			panic(r)
+		}
+		g.finish(k, f)
+	}()
+
+	f.v, f.err = fn()
+	if f.err == nil {
+		f.err = c.SetWithTTL(k, f.v, ttl)
+	}
+	if f.err != nil {
+		f.v = zero
+	}
+
+	return f.v, f.err
+}
+
+// getStale returns the value for k like get, and reports whether it is live
+// or expired. Unlike get, it keeps an expired entry, so that its value can
+// be served while it is refreshed; an expired entry counts as a miss.
+func (s *shard[K, V]) getStale(c *Cache[K, V], hash uint64, k K) (v V, live, stale bool) {
+	c.lockShard(s)
+	defer c.unlockShard(s)
+
+	if !c.noStats {
+		s.getCalls++
+	}
+
+	bucket := s.entries[hash]
+	pos := findEntry(bucket, k)
+	switch {
+	case pos < 0 || bucket[pos].negative:
+	case c.expired(&bucket[pos]):
+		v, stale = bucket[pos].Value, true
+	default:
+		c.touchLocked(bucket[pos].node)
+
+		return bucket[pos].Value, true, false
+	}
+
+	if !c.noStats {
+		s.misses++
+	}
+
+	return v, false, stale
+}
@@ -0,0 +1,191 @@
+package fastcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheGetOrRefresh(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	var calls atomic.Int64
+	refresh := func() (int, error) {
+		return int(calls.Add(1)), nil
+	}
+
+	for range 2 {
+		v, err := c.GetOrRefresh("key", time.Minute, refresh)
+		if err != nil {
+			t.Fatalf("GetOrRefresh error: %s", err)
+		}
+		if v != 1 {
+			t.Fatalf("unexpected value; got %d; want 1", v)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("unexpected refresh calls for a live key; got %d; want 1", n)
+	}
+	if _, exp, _ := c.GetWithExpiry("key"); !exp.Equal(time.Unix(1060, 0)) {
+		t.Fatalf("unexpected expiry; got %s; want %s", exp, time.Unix(1060, 0))
+	}
+
+	clock.Advance(time.Minute)
+	v, err := c.GetOrRefresh("key", time.Minute, refresh)
+	if err != nil {
+		t.Fatalf("GetOrRefresh error: %s", err)
+	}
+	if v != 2 {
+		t.Fatalf("unexpected value after expiry; got %d; want 2", v)
+	}
+
+	if _, err := c.GetOrRefresh("key", 0, refresh); !errors.Is(err, ErrInvalidTTL) {
+		t.Fatalf("GetOrRefresh returned error %v; want %v", err, ErrInvalidTTL)
+	}
+}
+
+func TestCacheGetOrRefreshSingleFlight(t *testing.T) {
+	for _, serveStale := range []bool{false, true} {
+		name := "blocking"
+		var opts []Option
+		if serveStale {
+			name = "stale while revalidate"
+			opts = append(opts, WithStaleWhileRevalidate())
+		}
+
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(1000, 0)}
+			c, err := New[string, string](100, append(opts, WithClock(clock))...)
+			if err != nil {
+				t.Fatalf("New error: %s", err)
+			}
+			defer c.Reset()
+
+			if err := c.SetWithTTL("key", "old", time.Minute); err != nil {
+				t.Fatalf("SetWithTTL error: %s", err)
+			}
+			clock.Advance(time.Minute)
+
+			var calls atomic.Int64
+			started := make(chan struct{})
+			release := make(chan struct{})
+			refresh := func() (string, error) {
+				calls.Add(1)
+				close(started)
+				<-release
+
+				return "new", nil
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if v, err := c.GetOrRefresh("key", time.Minute, refresh); err != nil || v != "new" {
+					t.Errorf("unexpected refreshing result; got (%q, %v); want (%q, nil)", v, err, "new")
+				}
+			}()
+			<-started
+
+			const workers = 16
+
+			results := make(chan string, workers)
+			for range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					v, err := c.GetOrRefresh("key", time.Minute, refresh)
+					if err != nil {
+						t.Errorf("GetOrRefresh error: %s", err)
+
+						return
+					}
+					results <- v
+				}()
+			}
+
+			want := "new"
+			if serveStale {
+				// Waiters return the stale value without waiting for the
+				// refresh.
+				want = "old"
+				for range workers {
+					if v := <-results; v != want {
+						t.Fatalf("unexpected value while refreshing; got %q; want %q", v, want)
+					}
+				}
+			} else {
+				time.Sleep(10 * time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+			close(results)
+
+			for v := range results {
+				if v != want {
+					t.Fatalf("unexpected value; got %q; want %q", v, want)
+				}
+			}
+			if n := calls.Load(); n != 1 {
+				t.Fatalf("concurrent refreshes must share one call; got %d calls", n)
+			}
+			if v, ok := c.Get("key"); !ok || v != "new" {
+				t.Fatalf("unexpected value after refresh; got (%q, %t); want (%q, true)", v, ok, "new")
+			}
+		})
+	}
+}
+
+func TestCacheGetOrRefreshErrors(t *testing.T) {
+	c, err := New[int, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	errRefresh := errors.New("refresh failed")
+	var calls atomic.Int64
+	for range 2 {
+		_, err := c.GetOrRefresh(1, time.Minute, func() (int, error) {
+			calls.Add(1)
+
+			return 1, errRefresh
+		})
+		if !errors.Is(err, errRefresh) {
+			t.Fatalf("GetOrRefresh must return the refresh error; got: %v", err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("errors must not be cached; got %d refresh calls; want 2", n)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("unexpected len; got %d; want 0", c.Len())
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("unexpected panic; got %v; want boom", r)
+			}
+		}()
+		_, _ = c.GetOrRefresh(1, time.Minute, func() (int, error) {
+			panic("boom")
+		})
+	}()
+
+	// The failed flight must not block later refreshes.
+	g := &c.shards[c.ShardIndex(1)].refreshes
+	g.mu.Lock()
+	n := len(g.calls)
+	g.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("unexpected flights in progress after panic; got %d; want 0", n)
+	}
+}
@@ -0,0 +1,94 @@
+package fastcache
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+)
+
+// hotKeysPerShard is the number of keys each shard tracks for
+// [Cache.TopKeys].
+const hotKeysPerShard = 16
+
+// KeyCount is a key and its estimated number of Get calls, as returned by
+// [Cache.TopKeys].
+type KeyCount[K comparable] struct {
+	Key   K
+	Count uint64
+}
+
+// hotKeys tracks the most sampled keys of a shard with the Space-Saving
+// algorithm: it counts up to hotKeysPerShard keys, and a sample of an
+// untracked key replaces the least counted key, inheriting its count. The
+// count of a key therefore overestimates its samples by at most the count
+// it inherited, and a key sampled more often than that is always tracked.
+type hotKeys[K comparable] struct {
+	counts map[K]uint64
+}
+
+func (h *hotKeys[K]) add(k K) {
+	if _, ok := h.counts[k]; ok || len(h.counts) < hotKeysPerShard {
+		h.counts[k]++
+
+		return
+	}
+
+	var (
+		minKey   K
+		minCount uint64
+		found    bool
+	)
+	for key, n := range h.counts {
+		if !found || n < minCount {
+			minKey, minCount, found = key, n, true
+		}
+	}
+	delete(h.counts, minKey)
+	h.counts[k] = minCount + 1
+}
+
+// sampleLocked counts an access to k for TopKeys if it is sampled; see
+// WithAccessSampling. s.mu must be held.
+func (s *shard[K, V]) sampleLocked(c *Cache[K, V], k K) {
+	if c.sampleRate == 0 || (c.sampleRate < 1 && rand.Float64() >= c.sampleRate) {
+		return
+	}
+
+	if s.hot == nil {
+		s.hot = &hotKeys[K]{counts: make(map[K]uint64, hotKeysPerShard)}
+	}
+	s.hot.add(k)
+}
+
+// TopKeys returns up to n of the most accessed keys with their estimated
+// number of Get calls, most accessed first. It returns nil unless the cache
+// was created with [WithAccessSampling].
+//
+// The counts are approximate: they are scaled up from the sampled Get calls
+// and may overestimate keys that were tracked late, and each shard tracks
+// only its 16 most sampled keys. The keys accessed most often are reported
+// reliably, which is what capacity planning needs. Keys need not be stored
+// in the cache, since misses are sampled as well.
+func (c *Cache[K, V]) TopKeys(n int) []KeyCount[K] {
+	if c.sampleRate == 0 || n <= 0 {
+		return nil
+	}
+
+	var top []KeyCount[K]
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		if s.hot != nil {
+			for k, count := range s.hot.counts {
+				top = append(top, KeyCount[K]{Key: k, Count: uint64(float64(count) / c.sampleRate)})
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	slices.SortFunc(top, func(a, b KeyCount[K]) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+
+	return top[:min(n, len(top))]
+}
@@ -0,0 +1,104 @@
+package fastcache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestCacheTopKeys(t *testing.T) {
+	c, err := New[string, int](1000, WithAccessSampling(1))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("hot", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	for range 100 {
+		c.Get("hot")
+	}
+	for range 50 {
+		c.GetMany([]string{"warm"})
+	}
+	for i := range 200 {
+		c.Get(fmt.Sprintf("cold-%d", i))
+	}
+
+	top := c.TopKeys(2)
+	want := []KeyCount[string]{{Key: "hot", Count: 100}, {Key: "warm", Count: 50}}
+	if len(top) != len(want) || top[0] != want[0] || top[1] != want[1] {
+		t.Fatalf("unexpected top keys; got %v; want %v", top, want)
+	}
+
+	c.Reset()
+	if top := c.TopKeys(2); len(top) != 0 {
+		t.Fatalf("unexpected top keys after Reset; got %v; want none", top)
+	}
+}
+
+func TestCacheTopKeysSampled(t *testing.T) {
+	c, err := New[int, int](1000, WithAccessSampling(0.25))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for range 10000 {
+		c.Get(1)
+	}
+
+	top := c.TopKeys(10)
+	if len(top) != 1 || top[0].Key != 1 {
+		t.Fatalf("unexpected top keys; got %v; want key 1 only", top)
+	}
+	// The count is scaled up from about 2500 samples.
+	if top[0].Count < 8000 || top[0].Count > 12000 {
+		t.Fatalf("unexpected estimated count; got %d; want about 10000", top[0].Count)
+	}
+}
+
+func TestCacheTopKeysDisabled(t *testing.T) {
+	c, err := New[int, int](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	c.Get(1)
+	if top := c.TopKeys(10); top != nil {
+		t.Fatalf("unexpected top keys without sampling; got %v; want nil", top)
+	}
+}
+
+func TestWithAccessSamplingInvalid(t *testing.T) {
+	for _, rate := range []float64{-0.1, 1.5, math.NaN()} {
+		if _, err := New[int, int](10, WithAccessSampling(rate)); !errors.Is(err, ErrInvalidSampleRate) {
+			t.Fatalf("unexpected error for rate %v; got %v; want %v", rate, err, ErrInvalidSampleRate)
+		}
+	}
+}
+
+func TestHotKeysReplacesLeastCounted(t *testing.T) {
+	h := &hotKeys[int]{counts: make(map[int]uint64)}
+	for k := range hotKeysPerShard {
+		for range k + 1 {
+			h.add(k)
+		}
+	}
+
+	// Key 0 has the lowest count, 1, so the new key replaces it and
+	// inherits that count.
+	h.add(-1)
+	if _, ok := h.counts[0]; ok {
+		t.Fatal("least counted key was not replaced")
+	}
+	if n := h.counts[-1]; n != 2 {
+		t.Fatalf("unexpected count of the new key; got %d; want 2", n)
+	}
+	if len(h.counts) != hotKeysPerShard {
+		t.Fatalf("unexpected number of tracked keys; got %d; want %d", len(h.counts), hotKeysPerShard)
+	}
+}
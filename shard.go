@@ -1,30 +1,70 @@
 package fastcache
 
 import (
+	"fmt"
 	"sync"
+	"time"
 )
 
 const shardsCount = 512
 
+// maxShardSizeHint caps the default size hint of each shard map, so that
+// caches with a huge maxEntries preallocate at most about 2M entries.
+const maxShardSizeHint = 1 << 12
+
 type shard[K comparable, V any] struct {
-	mu sync.Mutex
+	// mu guards the shard. It is not a sync.RWMutex because lookups write
+	// too: they update stats, remove expired entries and sample keys. It
+	// points into Cache.stripes and may be shared with other shards; see
+	// WithLockStripes.
+	mu *sync.Mutex
+
+	// computes tracks the GetOrCompute calls in progress on the shard.
+	computes flightGroup[K, V]
+
+	// refreshes tracks the GetOrRefresh calls in progress on the shard.
+	refreshes flightGroup[K, V]
 
 	// stats (hits computed as getCalls - misses)
-	getCalls  uint64
-	setCalls  uint64
-	misses    uint64
-	deletes   uint64
-	evictions uint64
+	getCalls    uint64
+	setCalls    uint64
+	misses      uint64
+	deletes     uint64
+	evictions   [evictReasons]uint64 // indexed by evictReason
+	expirations uint64
+
+	// pressure is a ring of counter snapshots for Stats.EvictionPressure;
+	// pressureNext is the slot to be overwritten next.
+	pressure     [pressureSnapshots]pressureSnapshot
+	pressureNext int
 
 	// entries maps a secure hash to one or more entries that share it.
 	entries    map[uint64][]entry[K, V]
 	entryCount int
+
+	// expiring is the number of entries with a TTL, so that the janitor can
+	// skip shards without them.
+	expiring int
+
+	// waiters holds the channels of WaitFor calls by key; nil if none.
+	waiters map[K][]chan V
+
+	// hot tracks the most sampled keys; nil until a Get is sampled. See
+	// WithAccessSampling.
+	hot *hotKeys[K]
 }
 
 // entry is used for serializing key-value pairs.
 type entry[K comparable, V any] struct {
 	Key   K
 	Value V
+
+	node     *node[K] // position in the eviction list; not serialized
+	size     int64    // estimated size; 0 if byte usage is not tracked
+	negative bool     // set with SetNegative; Value is the zero value
+	cost     int64    // cost set with SetWithCost; 1 by default
+	expireAt int64    // expiration time in Unix nanoseconds; 0 if none
+	ttl      int64    // TTL in nanoseconds the entry was stored with; 0 if none
 }
 
 func findEntry[K comparable, V any](bucket []entry[K, V], key K) int {
@@ -37,6 +77,16 @@ func findEntry[K comparable, V any](bucket []entry[K, V], key K) int {
 	return -1
 }
 
+func findNode[K comparable, V any](bucket []entry[K, V], n *node[K]) int {
+	for i := range bucket {
+		if bucket[i].node == n {
+			return i
+		}
+	}
+
+	return -1
+}
+
 func deleteEntry[K comparable, V any](bucket []entry[K, V], idx int) []entry[K, V] {
 	last := len(bucket) - 1
 	bucket[idx] = bucket[last]
@@ -45,59 +95,251 @@ func deleteEntry[K comparable, V any](bucket []entry[K, V], idx int) []entry[K,
 	return bucket[:last]
 }
 
-func (s *shard[K, V]) set(c *Cache[K, V], idx int, hash uint64, k K, v V) error {
+// lookupLocked returns the bucket for hash and the position of the live
+// entry for k in it, or -1 if there is none.
+//
+// An expired entry is removed and reported as missing. A negative entry is
+// kept, but reported as missing too. s.mu must be held.
+func (s *shard[K, V]) lookupLocked(c *Cache[K, V], hash uint64, k K) ([]entry[K, V], int) {
+	bucket := s.entries[hash]
+	pos := findEntry(bucket, k)
+	if pos >= 0 && c.expired(&bucket[pos]) {
+		s.expireLocked(c, hash, bucket, pos)
+
+		return s.entries[hash], -1
+	}
+	if pos >= 0 && bucket[pos].negative {
+		return bucket, -1
+	}
+
+	return bucket, pos
+}
+
+// replaceLocked stores v in e with the given expiration and cost. It leaves
+// e unchanged and returns an error if v or cost exceeds the byte or cost
+// limit on its own, or under strict capacity if the grown entry would not
+// fit. s.mu must be held.
+func (s *shard[K, V]) replaceLocked(c *Cache[K, V], e *entry[K, V], v V, exp expiry, cost int64) error {
+	size := c.entrySize(e.Key, v)
+	if err := c.checkEntry(size, cost); err != nil {
+		return err
+	}
+	if err := c.checkGrowth(e, size, cost); err != nil {
+		return err
+	}
+
+	c.storeValue(e, v, size)
+	s.setExpiryLocked(e, exp)
+	c.cost.Add(cost - e.cost)
+	e.cost = cost
+
+	return nil
+}
+
+// setExpiryLocked sets the expiration of e. s.mu must be held.
+func (s *shard[K, V]) setExpiryLocked(e *entry[K, V], exp expiry) {
+	switch {
+	case e.expireAt == 0 && exp.at != 0:
+		s.expiring++
+	case e.expireAt != 0 && exp.at == 0:
+		s.expiring--
+	}
+	e.expireAt = exp.at
+	e.ttl = exp.ttl
+}
+
+// setExpiry replaces the expiration of the live entry for k with the one
+// returned by fn, and reports whether the entry exists.
+func (s *shard[K, V]) setExpiry(c *Cache[K, V], hash uint64, k K, fn func(e *entry[K, V]) expiry) bool {
 	s.mu.Lock()
-	s.setCalls++
+	defer s.mu.Unlock()
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos < 0 {
+		return false
+	}
+	s.setExpiryLocked(&bucket[pos], fn(&bucket[pos]))
+
+	return true
+}
+
+func (s *shard[K, V]) set(c *Cache[K, V], idx int, hash uint64, k K, v V, exp expiry, cost int64) error {
+	c.lockShard(s)
+	if !c.noStats {
+		s.setCalls++
+	}
 
 	// Update existing key - no count change
-	bucket := s.entries[hash]
-	if pos := findEntry(bucket, k); pos >= 0 {
-		bucket[pos].Value = v
-		s.mu.Unlock()
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		if err := s.replaceLocked(c, &bucket[pos], v, exp, cost); err != nil {
+			c.unlockShard(s)
+
+			return err
+		}
+		c.touchLocked(bucket[pos].node)
+		c.unlockShard(s)
+		c.enforceLimits()
 
 		return nil
 	}
-	s.mu.Unlock()
+	c.unlockShard(s)
 
-	_, err := c.runInsert(opSet, idx, hash, k, v)
+	_, err := c.runInsert(opSet, idx, hash, k, v, exp, cost)
 
 	return err
 }
 
-func (s *shard[K, V]) get(hash uint64, k K) (V, bool) {
-	s.mu.Lock()
-	s.getCalls++
-	bucket := s.entries[hash]
-	if pos := findEntry(bucket, k); pos >= 0 {
-		v := bucket[pos].Value
-		s.mu.Unlock()
+func (s *shard[K, V]) swap(c *Cache[K, V], idx int, hash uint64, k K, v V) (V, bool, error) {
+	c.lockShard(s)
+	if !c.noStats {
+		s.setCalls++
+	}
 
-		return v, true
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		prev := bucket[pos].Value
+		if err := s.replaceLocked(c, &bucket[pos], v, expiry{}, 1); err != nil {
+			c.unlockShard(s)
+
+			var zero V
+
+			return zero, false, err
+		}
+		c.touchLocked(bucket[pos].node)
+		c.unlockShard(s)
+		c.enforceLimits()
+
+		return prev, true, nil
 	}
+	c.unlockShard(s)
 
-	s.misses++
-	s.mu.Unlock()
+	result, err := c.runInsert(opSwap, idx, hash, k, v, expiry{}, 1)
+	if err != nil {
+		var zero V
+
+		return zero, false, err
+	}
+
+	return result.value, result.loaded, nil
+}
+
+// get returns the value for k and its expiration time in Unix nanoseconds,
+// or 0 if it has no TTL.
+func (s *shard[K, V]) get(c *Cache[K, V], hash uint64, k K) (V, int64, bool) {
+	c.lockShard(s)
+	if !c.noStats {
+		s.getCalls++
+	}
+	s.sampleLocked(c, k)
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		v, expireAt := bucket[pos].Value, bucket[pos].expireAt
+		c.touchLocked(bucket[pos].node)
+		c.unlockShard(s)
+
+		return v, expireAt, true
+	}
+
+	if !c.noStats {
+		s.misses++
+	}
+	c.unlockShard(s)
 	// NOTE(dwisiswant0): hits = getCalls - misses (computed in [UpdateStats]).
 
 	var zero V
 
-	return zero, false
+	return zero, 0, false
 }
 
-func (s *shard[K, V]) getOrSet(c *Cache[K, V], idx int, hash uint64, k K, v V) (V, bool, error) {
+func (s *shard[K, V]) getAndTouch(c *Cache[K, V], hash uint64, k K, ttl time.Duration) (V, bool) {
+	c.lockShard(s)
+	defer c.unlockShard(s)
+
+	if !c.noStats {
+		s.getCalls++
+	}
+	s.sampleLocked(c, k)
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos < 0 {
+		if !c.noStats {
+			s.misses++
+		}
+
+		var zero V
+
+		return zero, false
+	}
+
+	if ttl > 0 {
+		s.setExpiryLocked(&bucket[pos], c.expiryAfter(ttl))
+	}
+	c.touchLocked(bucket[pos].node)
+
+	return bucket[pos].Value, true
+}
+
+func (s *shard[K, V]) getWithin(c *Cache[K, V], hash uint64, k K, maxStale time.Duration) (v V, fresh, ok bool) {
+	c.lockShard(s)
+	defer c.unlockShard(s)
+
+	if !c.noStats {
+		s.getCalls++
+	}
+	s.sampleLocked(c, k)
+
+	bucket := s.entries[hash]
+	pos := findEntry(bucket, k)
+	switch {
+	case pos < 0 || bucket[pos].negative:
+	case !c.expired(&bucket[pos]):
+		c.touchLocked(bucket[pos].node)
+
+		return bucket[pos].Value, true, true
+	case c.now() < bucket[pos].expireAt+int64(maxStale):
+		v, ok = bucket[pos].Value, true
+	default:
+		s.expireLocked(c, hash, bucket, pos)
+	}
+
+	if !c.noStats {
+		s.misses++
+	}
+
+	return v, false, ok
+}
+
+func (s *shard[K, V]) peek(c *Cache[K, V], hash uint64, k K) (V, bool) {
 	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	bucket := s.entries[hash]
-	if pos := findEntry(bucket, k); pos >= 0 {
-		s.getCalls++
+	if pos := findEntry(bucket, k); pos >= 0 && c.visible(&bucket[pos]) {
+		return bucket[pos].Value, true
+	}
+
+	var zero V
+
+	return zero, false
+}
+
+func (s *shard[K, V]) getOrSet(c *Cache[K, V], idx int, hash uint64, k K, v V) (V, bool, error) {
+	c.lockShard(s)
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		if !c.noStats {
+			s.getCalls++
+		}
 		existing := bucket[pos].Value
-		s.mu.Unlock()
+		c.touchLocked(bucket[pos].node)
+		c.unlockShard(s)
 
 		return existing, true, nil
 	}
-	s.mu.Unlock()
+	c.unlockShard(s)
 
-	result, err := c.runInsert(opGetOrSet, idx, hash, k, v)
+	result, err := c.runInsert(opGetOrSet, idx, hash, k, v, expiry{}, 1)
 	if err != nil {
 		var zero V
 
@@ -107,84 +349,398 @@ func (s *shard[K, V]) getOrSet(c *Cache[K, V], idx int, hash uint64, k K, v V) (
 	return result.value, result.loaded, nil
 }
 
-func (s *shard[K, V]) setIfAbsent(c *Cache[K, V], idx int, hash uint64, k K, v V) (bool, error) {
+func (s *shard[K, V]) getOrCompute(c *Cache[K, V], idx int, hash uint64, k K, fn func() (V, error)) (V, bool, error) {
+	g := &s.computes
+	for {
+		g.mu.Lock()
+		if f, ok := g.calls[k]; ok {
+			g.mu.Unlock()
+			<-f.done
+			if f.err == nil {
+				return f.v, true, nil
+			}
+
+			// A failed computation is not shared: nothing was stored, so
+			// try again, computing the value if no other caller does.
+			continue
+		}
+
+		c.lockShard(s)
+		bucket, pos := s.lookupLocked(c, hash, k)
+		if pos >= 0 {
+			if !c.noStats {
+				s.getCalls++
+			}
+			existing := bucket[pos].Value
+			c.touchLocked(bucket[pos].node)
+			c.unlockShard(s)
+			g.mu.Unlock()
+
+			return existing, true, nil
+		}
+		c.unlockShard(s)
+
+		f := g.start(k)
+		g.mu.Unlock()
+
+		return s.compute(c, idx, hash, k, fn, f)
+	}
+}
+
+// compute runs fn for the flight f started by getOrCompute and stores its
+// result.
+func (s *shard[K, V]) compute(c *Cache[K, V], idx int, hash uint64, k K, fn func() (V, error), f *flight[V]) (v V, loaded bool, err error) {
+	// The flight is completed even if fn panics, so that waiters are not
+	// blocked forever.
+	defer func() {
+		if r := recover(); r != nil {
+			f.err = fmt.Errorf("fastcache: compute panicked: %v", r)
+			s.computes.finish(k, f)
+			panic(r)
+		}
+		f.v, f.err = v, err
+		s.computes.finish(k, f)
+	}()
+
+	var zero V
+
+	v, err = fn()
+	if err != nil {
+		return zero, false, err
+	}
+
+	result, err := c.runInsert(opGetOrSet, idx, hash, k, v, expiry{}, 1)
+	if err != nil {
+		return zero, false, err
+	}
+
+	return result.value, result.loaded, nil
+}
+
+func (s *shard[K, V]) setIfAbsent(c *Cache[K, V], idx int, hash uint64, k K, v V) (stored, evicted bool, err error) {
 	s.mu.Lock()
 
-	if findEntry(s.entries[hash], k) >= 0 {
+	if _, pos := s.lookupLocked(c, hash, k); pos >= 0 {
 		s.mu.Unlock()
 
-		return false, nil
+		return false, false, nil
 	}
 	s.mu.Unlock()
 
-	result, err := c.runInsert(opSetIfAbsent, idx, hash, k, v)
+	result, err := c.runInsert(opSetIfAbsent, idx, hash, k, v, expiry{}, 1)
 	if err != nil {
-		return false, err
+		return false, false, err
+	}
+
+	return result.stored, result.stored && result.evicted, nil
+}
+
+// replace stores v for an existing k and returns the previous value. It
+// reports false if k is missing or v cannot be stored.
+func (s *shard[K, V]) replace(c *Cache[K, V], hash uint64, k K, v V) (V, bool) {
+	var zero V
+
+	c.lockShard(s)
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos < 0 {
+		c.unlockShard(s)
+
+		return zero, false
+	}
+
+	if !c.noStats {
+		s.setCalls++
 	}
+	prev := bucket[pos].Value
+	if err := s.replaceLocked(c, &bucket[pos], v, expiry{}, 1); err != nil {
+		c.unlockShard(s)
 
-	return result.stored, nil
+		return zero, false
+	}
+	c.touchLocked(bucket[pos].node)
+	c.unlockShard(s)
+	c.enforceLimits()
+
+	return prev, true
 }
 
-func (s *shard[K, V]) delete(c *Cache[K, V], hash uint64, k K) {
+// update replaces the value for k with fn applied to it, or to the zero value
+// if k is missing, and returns the new value.
+func (s *shard[K, V]) update(c *Cache[K, V], idx int, hash uint64, k K, fn func(V) V) (V, error) {
+	c.lockShard(s)
+	if !c.noStats {
+		s.setCalls++
+	}
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		v := fn(bucket[pos].Value)
+		c.replaceValue(&bucket[pos], v)
+		c.touchLocked(bucket[pos].node)
+		c.unlockShard(s)
+		c.enforceLimits()
+
+		return v, nil
+	}
+	c.unlockShard(s)
+
+	// Inserts hold c.orderMu, so once it is taken the key cannot appear
+	// between the lookup below and insertLocked.
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
 	s.mu.Lock()
-	s.deletes++
-	bucket := s.entries[hash]
-	if pos := findEntry(bucket, k); pos >= 0 {
-		bucket = deleteEntry(bucket, pos)
-		if len(bucket) == 0 {
-			delete(s.entries, hash)
-		} else {
-			s.entries[hash] = bucket
-		}
-		s.entryCount--
-		c.entryCount.Add(-1)
+	bucket, pos = s.lookupLocked(c, hash, k)
+	if pos >= 0 {
+		v := fn(bucket[pos].Value)
+		c.replaceValue(&bucket[pos], v)
+		c.touchLocked(bucket[pos].node)
+		s.mu.Unlock()
+		c.evictOverLimitsLocked()
+
+		return v, nil
 	}
 	s.mu.Unlock()
+
+	var zero V
+	v := fn(zero)
+	if _, err := c.insertLocked(opSet, idx, hash, k, v, expiry{}, 1); err != nil {
+		return zero, err
+	}
+
+	return v, nil
+}
+
+func (s *shard[K, V]) delete(c *Cache[K, V], hash uint64, k K) bool {
+	s.mu.Lock()
+	_, ok := s.deleteLocked(c, hash, k)
+	s.mu.Unlock()
+
+	return ok
 }
 
 func (s *shard[K, V]) getAndDelete(c *Cache[K, V], hash uint64, k K) (V, bool) {
 	s.mu.Lock()
-	s.deletes++
+	v, ok := s.deleteLocked(c, hash, k)
+	s.mu.Unlock()
 
-	bucket := s.entries[hash]
-	if pos := findEntry(bucket, k); pos >= 0 {
+	return v, ok
+}
+
+// compareAndDelete deletes k if equal reports true for its value.
+func (s *shard[K, V]) compareAndDelete(c *Cache[K, V], hash uint64, k K, equal func(V) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos < 0 || !equal(bucket[pos].Value) {
+		return false
+	}
+	s.deleteLocked(c, hash, k)
+
+	return true
+}
+
+// deleteLocked removes k from the shard and returns its value, if any.
+// s.mu must be held.
+func (s *shard[K, V]) deleteLocked(c *Cache[K, V], hash uint64, k K) (V, bool) {
+	if !c.noStats {
+		s.deletes++
+	}
+
+	bucket, pos := s.lookupLocked(c, hash, k)
+	if pos >= 0 {
 		v := bucket[pos].Value
-		bucket = deleteEntry(bucket, pos)
-		if len(bucket) == 0 {
-			delete(s.entries, hash)
-		} else {
-			s.entries[hash] = bucket
-		}
-		s.entryCount--
-		c.entryCount.Add(-1)
-		s.mu.Unlock()
+		s.unlinkLocked(c, hash, bucket, pos)
 
 		return v, true
 	}
-	s.mu.Unlock()
+	s.dropNegativeLocked(c, hash, bucket, k)
 
 	var zero V
 
 	return zero, false
 }
 
-func (s *shard[K, V]) reset() {
+// expireLocked removes the expired entry at pos. s.mu must be held.
+func (s *shard[K, V]) expireLocked(c *Cache[K, V], hash uint64, bucket []entry[K, V], pos int) {
+	if !c.noStats {
+		s.expirations++
+	}
+	s.unlinkLocked(c, hash, bucket, pos)
+}
+
+// unlinkLocked removes the entry at pos whose node is still in the eviction
+// list. s.mu must be held.
+func (s *shard[K, V]) unlinkLocked(c *Cache[K, V], hash uint64, bucket []entry[K, V], pos int) {
+	if bucket[pos].node != nil {
+		// The node is unlinked lazily; see [Cache.compactOrderLocked].
+		c.staleNodes.Add(1)
+	}
+	s.removeLocked(c, hash, bucket, pos)
+}
+
+// removeLocked removes the entry at pos from the shard and the cache-wide
+// counters. s.mu must be held.
+func (s *shard[K, V]) removeLocked(c *Cache[K, V], hash uint64, bucket []entry[K, V], pos int) {
+	c.bytes.Add(-bucket[pos].size)
+	c.cost.Add(-bucket[pos].cost)
+	if bucket[pos].expireAt != 0 {
+		s.expiring--
+	}
+
+	bucket = deleteEntry(bucket, pos)
+	if len(bucket) == 0 {
+		delete(s.entries, hash)
+	} else {
+		s.entries[hash] = bucket
+	}
+	s.entryCount--
+	c.entryCount.Add(-1)
+}
+
+// purgeExpired removes all expired entries from the shard.
+func (s *shard[K, V]) purgeExpired(c *Cache[K, V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.expiring == 0 {
+		return
+	}
+
+	now := c.now()
+	for hash, bucket := range s.entries {
+		// Iterate backwards, since deleteEntry moves the last entry into the
+		// removed position.
+		for i := len(bucket) - 1; i >= 0; i-- {
+			if bucket[i].expireAt != 0 && now >= bucket[i].expireAt {
+				s.expireLocked(c, hash, bucket, i)
+				bucket = bucket[:len(bucket)-1]
+			}
+		}
+	}
+}
+
+// take removes an arbitrary entry from the shard and returns it. Expired and
+// negative entries found on the way are removed without being returned.
+func (s *shard[K, V]) take(c *Cache[K, V]) (K, V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, bucket := range s.entries {
+		// Iterate backwards, since deleteEntry moves the last entry into the
+		// removed position.
+		for i := len(bucket) - 1; i >= 0; i-- {
+			e := bucket[i]
+			switch {
+			case c.expired(&e):
+				s.expireLocked(c, hash, bucket, i)
+			case e.negative:
+				s.unlinkLocked(c, hash, bucket, i)
+			default:
+				s.unlinkLocked(c, hash, bucket, i)
+
+				return e.Key, e.Value, true
+			}
+			bucket = bucket[:len(bucket)-1]
+		}
+	}
+
+	var (
+		zeroK K
+		zeroV V
+	)
+
+	return zeroK, zeroV, false
+}
+
+// shardStats is a consistent snapshot of the counters of a shard.
+type shardStats struct {
+	getCalls    uint64
+	setCalls    uint64
+	misses      uint64
+	deletes     uint64
+	evictions   [evictReasons]uint64
+	expirations uint64
+	entries     int
+
+	// recentSetCalls and recentEvictions are the counts within the pressure
+	// window.
+	recentSetCalls  uint64
+	recentEvictions uint64
+}
+
+// totalEvictions returns the number of evictions for all reasons.
+func (ss shardStats) totalEvictions() uint64 {
+	var n uint64
+	for _, v := range ss.evictions {
+		n += v
+	}
+
+	return n
+}
+
+func (s *shard[K, V]) stats(now int64) shardStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ss := shardStats{
+		getCalls:    s.getCalls,
+		setCalls:    s.setCalls,
+		misses:      s.misses,
+		deletes:     s.deletes,
+		evictions:   s.evictions,
+		expirations: s.expirations,
+		entries:     s.entryCount,
+	}
+	ss.recentSetCalls, ss.recentEvictions = s.recordPressureLocked(now, ss.setCalls, ss.totalEvictions())
+
+	return ss
+}
+
+func (s *shard[K, V]) reset(keepMap bool) {
 	s.mu.Lock()
-	s.entries = make(map[uint64][]entry[K, V])
+	if keepMap {
+		clear(s.entries)
+	} else {
+		s.entries = make(map[uint64][]entry[K, V])
+	}
 	s.entryCount = 0
+	s.expiring = 0
+	s.hot = nil
+	s.resetStatsLocked()
+	s.mu.Unlock()
+}
+
+// resetStatsLocked zeroes the stats counters of s. s.mu must be held.
+func (s *shard[K, V]) resetStatsLocked() {
 	s.getCalls = 0
 	s.setCalls = 0
 	s.misses = 0
 	s.deletes = 0
-	s.evictions = 0
-	s.mu.Unlock()
+	s.evictions = [evictReasons]uint64{}
+	s.expirations = 0
+	s.pressure = [pressureSnapshots]pressureSnapshot{}
+	s.pressureNext = 0
 }
 
-func (s *shard[K, V]) rangeEntries(f func(k K, v V) bool) bool {
+// rangeEntries snapshots the entries of s that match pred, or all entries if
+// pred is nil, and calls f for each of them after unlocking s.
+func (s *shard[K, V]) rangeEntries(c *Cache[K, V], pred func(K, V) bool, f func(k K, v V) bool) bool {
 	s.mu.Lock()
-	entries := make([]entry[K, V], 0, s.entryCount)
+	var entries []entry[K, V]
+	if pred == nil {
+		entries = make([]entry[K, V], 0, s.entryCount)
+	}
 	for _, bucket := range s.entries {
 		for _, e := range bucket {
+			if !c.visible(&e) {
+				continue
+			}
+			if pred != nil && !pred(e.Key, e.Value) {
+				continue
+			}
 			entries = append(entries, entry[K, V]{Key: e.Key, Value: e.Value})
 		}
 	}
@@ -199,11 +755,81 @@ func (s *shard[K, V]) rangeEntries(f func(k K, v V) bool) bool {
 	return true
 }
 
-func (s *shard[K, V]) rangeKeys(f func(k K) bool) bool {
+func (s *shard[K, V]) forEach(c *Cache[K, V], fn func(K, V) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, bucket := range s.entries {
+		for i := range bucket {
+			if !c.visible(&bucket[i]) {
+				continue
+			}
+			if err := fn(bucket[i].Key, bucket[i].Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *shard[K, V]) updateEach(c *Cache[K, V], fn func(K, V) (V, UpdateAction)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, bucket := range s.entries {
+		// Iterate backwards, since deleteEntry moves the last entry into the
+		// removed position.
+		for i := len(bucket) - 1; i >= 0; i-- {
+			e := &bucket[i]
+			if !c.visible(e) {
+				continue
+			}
+
+			v, action := fn(e.Key, e.Value)
+			switch action {
+			case UpdateStore:
+				if !c.noStats {
+					s.setCalls++
+				}
+				c.replaceValue(e, v)
+			case UpdateDelete:
+				if !c.noStats {
+					s.deletes++
+				}
+				s.unlinkLocked(c, hash, bucket, i)
+				bucket = bucket[:len(bucket)-1]
+			}
+		}
+	}
+}
+
+func (s *shard[K, V]) walk(c *Cache[K, V], fn func(K, V) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, bucket := range s.entries {
+		for i := range bucket {
+			if !c.visible(&bucket[i]) {
+				continue
+			}
+			if !fn(bucket[i].Key, bucket[i].Value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (s *shard[K, V]) rangeKeys(c *Cache[K, V], f func(k K) bool) bool {
 	s.mu.Lock()
 	keys := make([]K, 0, s.entryCount)
 	for _, bucket := range s.entries {
 		for _, entry := range bucket {
+			if !c.visible(&entry) {
+				continue
+			}
 			keys = append(keys, entry.Key)
 		}
 	}
@@ -218,11 +844,14 @@ func (s *shard[K, V]) rangeKeys(f func(k K) bool) bool {
 	return true
 }
 
-func (s *shard[K, V]) rangeValues(f func(v V) bool) bool {
+func (s *shard[K, V]) rangeValues(c *Cache[K, V], f func(v V) bool) bool {
 	s.mu.Lock()
 	values := make([]V, 0, s.entryCount)
 	for _, bucket := range s.entries {
 		for _, entry := range bucket {
+			if !c.visible(&entry) {
+				continue
+			}
 			values = append(values, entry.Value)
 		}
 	}
@@ -0,0 +1,157 @@
+package fastcache
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Bytes returns the estimated size of all entries in the cache in bytes.
+//
+// Returns 0 unless byte usage is tracked with [WithMaxBytes] or [WithSizeOf].
+func (c *Cache[K, V]) Bytes() int64 {
+	return c.bytes.Load()
+}
+
+func sizeOfFromOptions[K comparable, V any](o *options) (func(K, V) int64, error) {
+	if o.sizeOf != nil {
+		fn, ok := o.sizeOf.(func(K, V) int64)
+		if !ok {
+			var zero func(K, V) int64
+
+			return nil, fmt.Errorf("%w: got %T; want %T", ErrInvalidSizeOf, o.sizeOf, zero)
+		}
+
+		return fn, nil
+	}
+
+	if o.maxBytes > 0 {
+		return defaultSizeOf[K, V], nil
+	}
+
+	return nil, nil
+}
+
+// defaultSizeOf estimates the memory held by an entry: the fixed size of the
+// entry and its eviction list node, plus the backing data of string and []byte
+// keys and values.
+func defaultSizeOf[K comparable, V any](k K, v V) int64 {
+	size := int64(unsafe.Sizeof(entry[K, V]{}) + unsafe.Sizeof(node[K]{}))
+
+	switch k := any(k).(type) {
+	case string:
+		size += int64(len(k))
+	}
+
+	switch v := any(v).(type) {
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(cap(v))
+	}
+
+	return size
+}
+
+func (c *Cache[K, V]) entrySize(k K, v V) int64 {
+	if c.sizeOf == nil {
+		return 0
+	}
+
+	return c.sizeOf(k, v)
+}
+
+// replaceValue stores v in e and accounts for the size difference.
+// The shard lock of e must be held.
+func (c *Cache[K, V]) replaceValue(e *entry[K, V], v V) {
+	c.storeValue(e, v, c.entrySize(e.Key, v))
+}
+
+// storeValue is replaceValue for callers that already computed the size of
+// v with entrySize.
+func (c *Cache[K, V]) storeValue(e *entry[K, V], v V, size int64) {
+	e.Value = c.ownValue(v)
+	if c.sizeOf == nil {
+		return
+	}
+
+	c.bytes.Add(size - e.size)
+	e.size = size
+}
+
+// checkGrowth returns an error wrapping [ErrCacheFull] if the cache has strict
+// capacity and replacing the value of e with one of the given size and cost
+// would exceed the byte or cost limit. Shrinking entries always pass.
+func (c *Cache[K, V]) checkGrowth(e *entry[K, V], size, cost int64) error {
+	if !c.strict {
+		return nil
+	}
+
+	// Compare against the remaining room, like limitReachedBy.
+	if grow := size - e.size; c.maxBytes > 0 && grow > 0 && grow > c.maxBytes-c.bytes.Load() {
+		return c.errCacheFull()
+	}
+	if grow := cost - e.cost; c.maxCost > 0 && grow > 0 && grow > c.maxCost-c.cost.Load() {
+		return c.errCacheFull()
+	}
+
+	return nil
+}
+
+// errCacheFull returns an error wrapping [ErrCacheFull] with the current
+// usage and limits of the cache.
+func (c *Cache[K, V]) errCacheFull() error {
+	return fmt.Errorf("%w: entry count=%d, max entries=%d, bytes=%d, max bytes=%d, cost=%d, max cost=%d", ErrCacheFull, c.entryCount.Load(), c.maxEntries, c.bytes.Load(), c.maxBytes, c.cost.Load(), c.maxCost)
+}
+
+// checkEntry returns an error if an entry of the given size or cost exceeds
+// the byte or cost limit on its own, so that it can never be stored.
+func (c *Cache[K, V]) checkEntry(size, cost int64) error {
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return fmt.Errorf("%w: entry size=%d, max bytes=%d", ErrEntryTooLarge, size, c.maxBytes)
+	}
+	if c.maxCost > 0 && cost > c.maxCost {
+		return fmt.Errorf("%w: entry cost=%d, max cost=%d", ErrCostTooLarge, cost, c.maxCost)
+	}
+
+	return nil
+}
+
+// enforceLimits evicts the oldest entries while the byte or cost limit is
+// exceeded, which may happen after an existing entry grows in place.
+func (c *Cache[K, V]) enforceLimits() {
+	if _, over := c.overLimits(); !over {
+		return
+	}
+
+	c.orderMu.Lock()
+	c.evictOverLimitsLocked()
+	c.unlockOrder()
+}
+
+// evictOverLimitsLocked is enforceLimits for callers that already hold
+// c.orderMu.
+func (c *Cache[K, V]) evictOverLimitsLocked() {
+	if c.strict {
+		return
+	}
+
+	for {
+		reason, over := c.overLimits()
+		if !over || !c.evictOldestLocked(reason) {
+			return
+		}
+	}
+}
+
+// overLimits reports whether the byte or cost limit is exceeded, and which
+// one.
+func (c *Cache[K, V]) overLimits() (evictReason, bool) {
+	if c.maxCost > 0 && c.cost.Load() > c.maxCost {
+		return evictCost, true
+	}
+	if c.maxBytes > 0 && c.bytes.Load() > c.maxBytes {
+		return evictBytes, true
+	}
+
+	return 0, false
+}
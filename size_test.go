@@ -0,0 +1,167 @@
+package fastcache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func valueLen(_ string, v []byte) int64 {
+	return int64(len(v))
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	c, err := New[string, []byte](100, WithMaxBytes(100), WithSizeOf(valueLen))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 4 {
+		if err := c.Set(fmt.Sprintf("key %d", i), make([]byte, 30)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// 4 * 30 bytes exceed the limit, so the oldest entry must be gone
+	if c.Has("key 0") {
+		t.Fatal("oldest entry was not evicted when the byte limit was exceeded")
+	}
+	if got := c.Bytes(); got != 90 {
+		t.Fatalf("unexpected bytes; got %d; want 90", got)
+	}
+
+	// Growing an existing entry in place evicts older entries
+	if err := c.Set("key 3", make([]byte, 80)); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if c.Has("key 1") || c.Has("key 2") {
+		t.Fatal("older entries were not evicted after an in-place update exceeded the byte limit")
+	}
+	if got := c.Bytes(); got != 80 {
+		t.Fatalf("unexpected bytes after in-place update; got %d; want 80", got)
+	}
+
+	c.Delete("key 3")
+	if got := c.Bytes(); got != 0 {
+		t.Fatalf("unexpected bytes after delete; got %d; want 0", got)
+	}
+
+	err = c.Set("huge", make([]byte, 101))
+	if !errors.Is(err, ErrEntryTooLarge) {
+		t.Fatalf("Set returned error %v; want %v", err, ErrEntryTooLarge)
+	}
+	if c.Has("huge") {
+		t.Fatal("Set stored an entry larger than maxBytes")
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.BytesSize != 0 || s.MaxBytes != 100 {
+		t.Fatalf("unexpected byte stats; got BytesSize=%d, MaxBytes=%d; want 0, 100", s.BytesSize, s.MaxBytes)
+	}
+	if s.EvictionsBytes != 3 || s.EvictionsCapacity != 0 || s.Evictions != 3 {
+		t.Fatalf("unexpected eviction stats; got EvictionsBytes=%d, EvictionsCapacity=%d, Evictions=%d; want 3, 0, 3", s.EvictionsBytes, s.EvictionsCapacity, s.Evictions)
+	}
+}
+
+func TestCacheOverwriteTooLarge(t *testing.T) {
+	c, err := New[string, []byte](100, WithMaxBytes(100), WithSizeOf(valueLen))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for i := range 3 {
+		if err := c.Set(fmt.Sprintf("key %d", i), make([]byte, 10)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+
+	// Overwriting a key with a value larger than maxBytes must fail without
+	// evicting anything, rather than flush the cache.
+	huge := make([]byte, 101)
+	if err := c.Set("key 0", huge); !errors.Is(err, ErrEntryTooLarge) {
+		t.Fatalf("Set returned error %v; want %v", err, ErrEntryTooLarge)
+	}
+	if _, _, err := c.Swap("key 0", huge); !errors.Is(err, ErrEntryTooLarge) {
+		t.Fatalf("Swap returned error %v; want %v", err, ErrEntryTooLarge)
+	}
+	if c.Replace("key 0", huge) {
+		t.Fatal("Replace stored a value larger than maxBytes")
+	}
+	if _, ok := c.GetAndSet("key 0", huge); ok {
+		t.Fatal("GetAndSet stored a value larger than maxBytes")
+	}
+
+	if n := c.Len(); n != 3 {
+		t.Fatalf("unexpected number of entries; got %d; want 3", n)
+	}
+	if v, _ := c.Get("key 0"); len(v) != 10 {
+		t.Fatalf("unexpected value length; got %d; want 10", len(v))
+	}
+	if got := c.Bytes(); got != 30 {
+		t.Fatalf("unexpected bytes; got %d; want 30", got)
+	}
+}
+
+func TestCacheMaxBytesAndMaxEntries(t *testing.T) {
+	c, err := New[string, []byte](2, WithMaxBytes(1000), WithSizeOf(valueLen))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(key, []byte(key)); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}
+	if c.Has("a") {
+		t.Fatal("entry limit was not enforced alongside the byte limit")
+	}
+	if got := c.Bytes(); got != 2 {
+		t.Fatalf("unexpected bytes; got %d; want 2", got)
+	}
+	if s := c.Stats(); s.EvictionsCapacity != 1 || s.EvictionsBytes != 0 {
+		t.Fatalf("unexpected eviction stats; got EvictionsCapacity=%d, EvictionsBytes=%d; want 1, 0", s.EvictionsCapacity, s.EvictionsBytes)
+	}
+}
+
+func TestCacheDefaultSizeOf(t *testing.T) {
+	c, err := New[string, string](10, WithMaxBytes(1<<20))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("key", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if got, want := c.Bytes(), defaultSizeOf("key", "value"); got != want {
+		t.Fatalf("unexpected bytes; got %d; want %d", got, want)
+	}
+	if got := defaultSizeOf("key", "value") - defaultSizeOf("", ""); got != 8 {
+		t.Fatalf("unexpected dynamic size; got %d; want 8", got)
+	}
+
+	untracked, err := New[string, string](10)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	if err := untracked.Set("key", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if got := untracked.Bytes(); got != 0 {
+		t.Fatalf("unexpected bytes without tracking; got %d; want 0", got)
+	}
+}
+
+func TestNewReturnsErrorForInvalidByteOptions(t *testing.T) {
+	if _, err := New[string, string](1, WithMaxBytes(-1)); !errors.Is(err, ErrInvalidMaxBytes) {
+		t.Fatalf("New returned error %v; want %v", err, ErrInvalidMaxBytes)
+	}
+	if _, err := New[string, string](1, WithSizeOf(valueLen)); !errors.Is(err, ErrInvalidSizeOf) {
+		t.Fatalf("New returned error %v; want %v", err, ErrInvalidSizeOf)
+	}
+}
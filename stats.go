@@ -1,52 +1,365 @@
 package fastcache
 
+import (
+	"encoding/json"
+	"math"
+)
+
 // Stats represents cache stats.
 //
-// Use [Cache.UpdateStats] for obtaining fresh stats from the cache.
+// Use [Cache.Stats] or [Cache.UpdateStats] for obtaining fresh stats from the
+// cache. The counters stay zero if the cache was created with
+// [WithStatsDisabled].
+//
+// Stats marshal to JSON with snake_case field names, plus the derived
+// hit_ratio and eviction_rate; see [Stats.MarshalJSON].
 type Stats struct {
 	// GetCalls is the number of Get calls.
-	GetCalls uint64
+	GetCalls uint64 `json:"get_calls"`
 
 	// SetCalls is the number of Set calls.
-	SetCalls uint64
+	SetCalls uint64 `json:"set_calls"`
 
 	// Misses is the number of cache misses.
-	Misses uint64
+	Misses uint64 `json:"misses"`
 
 	// Hits is the number of cache hits.
-	Hits uint64
+	Hits uint64 `json:"hits"`
 
-	// Deletes is the number of Delete calls.
-	Deletes uint64
+	// Deletes is the number of Delete calls, including those for missing
+	// keys.
+	Deletes uint64 `json:"deletes"`
 
-	// Evictions is the number of entries evicted due to capacity limits.
-	Evictions uint64
+	// Evictions is the number of entries evicted due to capacity limits. It
+	// is the sum of EvictionsCapacity, EvictionsBytes and EvictionsCost.
+	//
+	// Entries removed after their TTL elapsed are counted in Expirations
+	// instead.
+	Evictions uint64 `json:"evictions"`
+
+	// EvictionsCapacity is the number of entries evicted because the cache
+	// held maxEntries entries, including those evicted by [Cache.Trim].
+	EvictionsCapacity uint64 `json:"evictions_capacity"`
+
+	// EvictionsBytes is the number of entries evicted to stay within the byte
+	// limit set with [WithMaxBytes].
+	EvictionsBytes uint64 `json:"evictions_bytes"`
+
+	// EvictionsCost is the number of entries evicted to stay within the cost
+	// limit set with [WithMaxCost].
+	EvictionsCost uint64 `json:"evictions_cost"`
+
+	// Expirations is the number of entries removed after their TTL elapsed.
+	Expirations uint64 `json:"expirations"`
+
+	// EvictionDrops is the number of evicted entries that were not sent to
+	// the channel set with [WithEvictionChannel] because it was full.
+	EvictionDrops uint64 `json:"eviction_drops"`
 
 	// EntriesCount is the current number of entries in the cache.
-	EntriesCount uint64
+	EntriesCount uint64 `json:"entries_count"`
 
 	// MaxEntries is the maximum number of entries allowed in the cache.
-	MaxEntries uint64
+	MaxEntries uint64 `json:"max_entries"`
+
+	// BytesSize is the estimated size of all entries in bytes.
+	//
+	// It is 0 unless byte usage is tracked with [WithMaxBytes] or [WithSizeOf].
+	BytesSize uint64 `json:"bytes_size"`
+
+	// MaxBytes is the maximum estimated size of all entries, or 0 if unlimited.
+	MaxBytes uint64 `json:"max_bytes"`
+
+	// TotalCost is the total cost of all entries. Entries not stored with
+	// [Cache.SetWithCost] cost 1.
+	TotalCost uint64 `json:"total_cost"`
+
+	// MaxCost is the maximum total cost of all entries, or 0 if unlimited.
+	MaxCost uint64 `json:"max_cost"`
+
+	// AtCapacity reports whether the cache holds maxEntries entries, so that
+	// inserting a new key evicts an entry.
+	AtCapacity bool `json:"at_capacity"`
+
+	// EvictionPressure is the number of evictions per Set call over roughly
+	// the last minute, or since the cache was created or reset if that is
+	// more recent. A value close to 1 means that nearly every Set evicts an
+	// entry, i.e. the cache is thrashing.
+	//
+	// The window is tracked by snapshotting counters in UpdateStats, so the
+	// value is most accurate when stats are collected regularly, e.g. by a
+	// metrics scraper. If stats are collected less often than every minute,
+	// the window spans the time since the previous collection.
+	EvictionPressure float64 `json:"eviction_pressure"`
 }
 
 // UpdateStats adds cache stats to s.
 //
 // Call [Stats.Reset] before calling UpdateStats if s is re-used.
 func (c *Cache[K, V]) UpdateStats(s *Stats) {
+	now := c.now()
+
+	var recentSetCalls, recentEvictions uint64
 	for i := range c.shards {
-		shard := &c.shards[i]
-		shard.mu.Lock()
-		s.GetCalls += shard.getCalls
-		s.SetCalls += shard.setCalls
-		s.Misses += shard.misses
-		s.Deletes += shard.deletes
-		s.Evictions += shard.evictions
-		shard.mu.Unlock()
+		// Each shard is snapshotted under its lock, so its misses never
+		// exceed its Get calls even if it is reset concurrently.
+		ss := c.shards[i].stats(now)
+		recentSetCalls += ss.recentSetCalls
+		recentEvictions += ss.recentEvictions
+		s.GetCalls += ss.getCalls
+		s.SetCalls += ss.setCalls
+		s.Misses += ss.misses
+		s.Deletes += ss.deletes
+		s.EvictionsCapacity += ss.evictions[evictCapacity]
+		s.EvictionsBytes += ss.evictions[evictBytes]
+		s.EvictionsCost += ss.evictions[evictCost]
+		s.Expirations += ss.expirations
 	}
 
+	if saved := c.restored.Load(); saved != nil {
+		saved.addTo(s)
+	}
+
+	s.Evictions = s.EvictionsCapacity + s.EvictionsBytes + s.EvictionsCost
+	if c.sink != nil {
+		s.EvictionDrops += c.sink.drops.Load()
+	}
 	s.EntriesCount = uint64(c.entryCount.Load())
-	s.Hits = s.GetCalls - s.Misses
+	s.Hits = 0
+	if s.GetCalls > s.Misses {
+		s.Hits = s.GetCalls - s.Misses
+	}
 	s.MaxEntries = uint64(c.maxEntries)
+	s.BytesSize = uint64(c.bytes.Load())
+	s.MaxBytes = uint64(c.maxBytes)
+	s.TotalCost = uint64(c.cost.Load())
+	s.MaxCost = uint64(c.maxCost)
+	s.AtCapacity = s.EntriesCount >= s.MaxEntries
+	s.EvictionPressure = 0
+	if recentSetCalls > 0 {
+		s.EvictionPressure = float64(recentEvictions) / float64(recentSetCalls)
+	}
+}
+
+// Stats returns a fresh snapshot of the cache stats.
+func (c *Cache[K, V]) Stats() Stats {
+	var s Stats
+	c.UpdateStats(&s)
+
+	return s
+}
+
+// savedStats are the stats counters persisted with [WithSavedStats].
+//
+// The fields are exported for encoding, and their JSON names match the ones
+// of [Stats.MarshalJSON]. Saved is false if the counters were not saved, so
+// that a cache saved with zero counters still restores them.
+type savedStats struct {
+	Saved             bool   `json:"saved"`
+	GetCalls          uint64 `json:"get_calls"`
+	SetCalls          uint64 `json:"set_calls"`
+	Misses            uint64 `json:"misses"`
+	Deletes           uint64 `json:"deletes"`
+	EvictionsCapacity uint64 `json:"evictions_capacity"`
+	EvictionsBytes    uint64 `json:"evictions_bytes"`
+	EvictionsCost     uint64 `json:"evictions_cost"`
+	Expirations       uint64 `json:"expirations"`
+	EvictionDrops     uint64 `json:"eviction_drops"`
+}
+
+// savedStatsOf returns the counters of s for saving.
+func savedStatsOf(s Stats) savedStats {
+	return savedStats{
+		Saved:             true,
+		GetCalls:          s.GetCalls,
+		SetCalls:          s.SetCalls,
+		Misses:            s.Misses,
+		Deletes:           s.Deletes,
+		EvictionsCapacity: s.EvictionsCapacity,
+		EvictionsBytes:    s.EvictionsBytes,
+		EvictionsCost:     s.EvictionsCost,
+		Expirations:       s.Expirations,
+		EvictionDrops:     s.EvictionDrops,
+	}
+}
+
+// addTo adds the saved counters to s.
+func (ss *savedStats) addTo(s *Stats) {
+	s.GetCalls += ss.GetCalls
+	s.SetCalls += ss.SetCalls
+	s.Misses += ss.Misses
+	s.Deletes += ss.Deletes
+	s.EvictionsCapacity += ss.EvictionsCapacity
+	s.EvictionsBytes += ss.EvictionsBytes
+	s.EvictionsCost += ss.EvictionsCost
+	s.Expirations += ss.Expirations
+	s.EvictionDrops += ss.EvictionDrops
+}
+
+// restoreStats replaces the stats counters of c with saved. It is called
+// after loading the entries of c, so that the Set calls storing them are not
+// counted on top of the saved ones.
+func (c *Cache[K, V]) restoreStats(saved savedStats) {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.resetStatsLocked()
+		s.mu.Unlock()
+	}
+	if c.sink != nil {
+		c.sink.drops.Store(0)
+	}
+	c.restored.Store(&saved)
+}
+
+// ShardStat represents the stats of a single shard.
+//
+// See [Cache.ShardStats].
+type ShardStat struct {
+	// EntriesCount is the current number of entries in the shard.
+	EntriesCount uint64
+
+	// GetCalls is the number of Get calls for keys in the shard.
+	GetCalls uint64
+
+	// SetCalls is the number of Set calls for keys in the shard.
+	SetCalls uint64
+
+	// Misses is the number of cache misses for keys in the shard.
+	Misses uint64
+
+	// Deletes is the number of Delete calls for keys in the shard.
+	Deletes uint64
+
+	// Evictions is the number of entries evicted from the shard.
+	Evictions uint64
+
+	// Expirations is the number of entries removed from the shard after their
+	// TTL elapsed.
+	Expirations uint64
+}
+
+// ShardStats returns a snapshot of the stats of every shard, indexed by shard.
+//
+// Unlike [Cache.UpdateStats], it reveals how keys and load are distributed
+// across shards, which helps diagnosing hot shards caused by a skewed key
+// distribution. Each shard is locked briefly in turn, so the shards are not
+// captured at the same instant.
+func (c *Cache[K, V]) ShardStats() []ShardStat {
+	now := c.now()
+	stats := make([]ShardStat, shardsCount)
+	for i := range c.shards {
+		ss := c.shards[i].stats(now)
+		stats[i] = ShardStat{
+			EntriesCount: uint64(ss.entries),
+			GetCalls:     ss.getCalls,
+			SetCalls:     ss.setCalls,
+			Misses:       ss.misses,
+			Deletes:      ss.deletes,
+			Evictions:    ss.totalEvictions(),
+			Expirations:  ss.expirations,
+		}
+	}
+
+	return stats
+}
+
+// LoadFactor returns the lowest, highest and average occupancy of the shards,
+// each relative to an even share of maxEntries. Balanced keys keep min and
+// max close to avg, while a max many times avg means that keys concentrate
+// in a few shards, e.g. because of a poor [WithHashSeed] or a skewed key set.
+//
+// It is cheaper than [Cache.ShardStats], since it only reads the entry count
+// of each shard. Each shard is locked briefly in turn, so the shards are not
+// captured at the same instant.
+func (c *Cache[K, V]) LoadFactor() (minLoad, maxLoad, avgLoad float64) {
+	share := float64(c.maxEntries) / shardsCount
+	minCount, maxCount, total := math.MaxInt, 0, 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		n := s.entryCount
+		s.mu.Unlock()
+
+		minCount = min(minCount, n)
+		maxCount = max(maxCount, n)
+		total += n
+	}
+
+	return float64(minCount) / share, float64(maxCount) / share, float64(total) / float64(c.maxEntries)
+}
+
+// HitRatio returns the fraction of Get calls that were hits.
+//
+// Returns 0 if there were no Get calls.
+func (s Stats) HitRatio() float64 {
+	if s.GetCalls == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.GetCalls)
+}
+
+// EvictionRate returns the number of evictions per Set call.
+//
+// Returns 0 if there were no Set calls.
+func (s Stats) EvictionRate() float64 {
+	if s.SetCalls == 0 {
+		return 0
+	}
+
+	return float64(s.Evictions) / float64(s.SetCalls)
+}
+
+// Sub returns the change of the counters of s since prev, e.g. to compute
+// rates over a scrape interval.
+//
+// Counters that decreased, because the cache was reset in between, are
+// clamped to zero. The other fields, such as EntriesCount, MaxEntries and
+// EvictionPressure, describe the current state and are copied from s.
+func (s Stats) Sub(prev Stats) Stats {
+	d := s
+	d.GetCalls = subCounter(s.GetCalls, prev.GetCalls)
+	d.SetCalls = subCounter(s.SetCalls, prev.SetCalls)
+	d.Misses = subCounter(s.Misses, prev.Misses)
+	d.Hits = subCounter(s.Hits, prev.Hits)
+	d.Deletes = subCounter(s.Deletes, prev.Deletes)
+	d.Evictions = subCounter(s.Evictions, prev.Evictions)
+	d.EvictionsCapacity = subCounter(s.EvictionsCapacity, prev.EvictionsCapacity)
+	d.EvictionsBytes = subCounter(s.EvictionsBytes, prev.EvictionsBytes)
+	d.EvictionsCost = subCounter(s.EvictionsCost, prev.EvictionsCost)
+	d.Expirations = subCounter(s.Expirations, prev.Expirations)
+	d.EvictionDrops = subCounter(s.EvictionDrops, prev.EvictionDrops)
+
+	return d
+}
+
+// subCounter returns cur - prev, or 0 if the counter was reset.
+func subCounter(cur, prev uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+
+	return cur - prev
+}
+
+// MarshalJSON implements [json.Marshaler].
+//
+// It encodes the fields of s under their json tags, and adds the derived
+// metrics [Stats.HitRatio] and [Stats.EvictionRate] as hit_ratio and
+// eviction_rate. They are computed while marshaling rather than stored in
+// s, so that [Cache.UpdateStats] can sum the counters of several caches
+// without summing their ratios. Unmarshaling ignores them.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	// stats has the fields of Stats but not its methods, so that encoding
+	// it does not call MarshalJSON again.
+	type stats Stats
+
+	return json.Marshal(struct {
+		stats
+		HitRatio     float64 `json:"hit_ratio"`
+		EvictionRate float64 `json:"eviction_rate"`
+	}{stats(s), s.HitRatio(), s.EvictionRate()})
 }
 
 // Reset resets s, so it may be re-used again in [Cache.UpdateStats].
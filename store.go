@@ -0,0 +1,50 @@
+package fastcache
+
+// Store is a minimal cache interface shared by many cache libraries, so
+// that code can switch between implementations through a single adapter.
+//
+// [Cache.AsStore] returns a Store backed by a cache.
+type Store[K comparable, V any] interface {
+	// Get returns the value for k and whether it was found.
+	Get(k K) (V, bool)
+
+	// Set stores v for k.
+	Set(k K, v V)
+
+	// Delete removes the value for k, if any.
+	Delete(k K)
+
+	// Len returns the number of stored entries.
+	Len() int
+}
+
+// AsStore returns a [Store] backed by c.
+//
+// Store.Set has no error result, so a value that [Cache.Set] fails to store,
+// e.g. because it is larger than [WithMaxBytes] allows or the cache is full
+// under [WithStrictCapacity], is dropped as if it had been evicted at once.
+// Use c directly to observe such errors.
+func (c *Cache[K, V]) AsStore() Store[K, V] {
+	return cacheStore[K, V]{c: c}
+}
+
+// cacheStore adapts a [Cache] to [Store].
+type cacheStore[K comparable, V any] struct {
+	c *Cache[K, V]
+}
+
+func (s cacheStore[K, V]) Get(k K) (V, bool) {
+	return s.c.Get(k)
+}
+
+func (s cacheStore[K, V]) Set(k K, v V) {
+	_ = s.c.Set(k, v)
+}
+
+func (s cacheStore[K, V]) Delete(k K) {
+	s.c.Delete(k)
+}
+
+func (s cacheStore[K, V]) Len() int {
+	return s.c.Len()
+}
@@ -0,0 +1,39 @@
+package fastcache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCacheAsStore(t *testing.T) {
+	c, err := New[string, string](100, WithMaxBytes(64), WithSizeOf(func(k, v string) int64 {
+		return int64(len(k) + len(v))
+	}))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	var s Store[string, string] = c.AsStore()
+	s.Set("a", "1")
+	if v, ok := s.Get("a"); !ok || v != "1" {
+		t.Fatalf("unexpected value; got (%q, %t); want (%q, true)", v, ok, "1")
+	}
+	if n := s.Len(); n != 1 {
+		t.Fatalf("unexpected len; got %d; want 1", n)
+	}
+
+	// A value that cannot be stored is dropped.
+	s.Set("b", strings.Repeat("b", 100))
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("oversized value was stored")
+	}
+
+	s.Delete("a")
+	if n := s.Len(); n != 0 {
+		t.Fatalf("unexpected len after Delete; got %d; want 0", n)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Delete through the store did not remove the entry from the cache")
+	}
+}
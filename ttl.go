@@ -0,0 +1,196 @@
+package fastcache
+
+import (
+	"runtime"
+	"sync"
+	"time"
+	"weak"
+)
+
+// Clock provides the current time to a [Cache]. See [WithClock].
+type Clock interface {
+	Now() time.Time
+}
+
+// now returns the current time in Unix nanoseconds.
+func (c *Cache[K, V]) now() int64 {
+	if c.clock != nil {
+		return c.clock.Now().UnixNano()
+	}
+
+	return time.Now().UnixNano()
+}
+
+// expiry is the expiration of an entry.
+type expiry struct {
+	at  int64 // expiration time in Unix nanoseconds; 0 if none
+	ttl int64 // TTL in nanoseconds, kept for Touch; 0 if none
+}
+
+// expiryAfter returns the expiration of an entry stored now for ttl.
+func (c *Cache[K, V]) expiryAfter(ttl time.Duration) expiry {
+	return expiry{at: c.now() + int64(ttl), ttl: int64(ttl)}
+}
+
+// expired reports whether e has a TTL that has elapsed.
+func (c *Cache[K, V]) expired(e *entry[K, V]) bool {
+	return e.expireAt != 0 && c.now() >= e.expireAt
+}
+
+// GetWithExpiry returns the value for the given key and the time it expires.
+//
+// The returned time is zero if the entry was not stored with
+// [Cache.SetWithTTL]. Like [Cache.Get], GetWithExpiry counts as a Get call in
+// [Stats], and an expired entry is a miss. This allows refreshing entries
+// before they expire.
+//
+// Returns the zero value, a zero time and false if the key is not found.
+func (c *Cache[K, V]) GetWithExpiry(k K) (V, time.Time, bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	v, expireAt, ok := c.shards[idx].get(c, h, k)
+	if !ok || expireAt == 0 {
+		return v, time.Time{}, ok
+	}
+
+	return v, time.Unix(0, expireAt), true
+}
+
+// SetTTL sets the TTL of an existing entry to ttl from now, and reports
+// whether the key was present.
+//
+// Unlike [Cache.SetWithTTL], SetTTL keeps the value and never inserts a
+// missing key, so a TTL can be attached once the value has been validated.
+// It replaces any previous expiration, and ttl becomes the TTL that
+// [Cache.Touch] renews. SetTTL does not count as a Get or Set call in
+// [Stats], nor does it promote the entry under [PolicyLRU].
+//
+// SetTTL returns false without changing the entry if ttl is not positive.
+func (c *Cache[K, V]) SetTTL(k K, ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].setExpiry(c, h, k, func(*entry[K, V]) expiry {
+		return c.expiryAfter(ttl)
+	})
+}
+
+// GetAndTouch returns the value for the given key and, if it is found, sets
+// its TTL to ttl from now, for sliding expiration.
+//
+// The value is read and the expiration updated under one lock, so a
+// concurrent expiry cannot slip in between as with [Cache.Get] followed by
+// [Cache.SetTTL]. Like Get, GetAndTouch counts as a Get call in [Stats],
+// promotes the entry under [PolicyLRU], and treats expired entries as misses.
+// ttl becomes the TTL that [Cache.Touch] renews. If ttl is not positive, the
+// expiration is left unchanged.
+//
+// Returns the zero value and false if the key is not found.
+func (c *Cache[K, V]) GetAndTouch(k K, ttl time.Duration) (V, bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].getAndTouch(c, h, k, ttl)
+}
+
+// GetStale returns the value for the given key even if it has expired, as
+// long as it expired less than maxStale ago, e.g. to serve stale data while
+// the origin is down. fresh reports whether the entry has not expired yet.
+//
+// An expired entry returned by GetStale is kept, so that it can be served
+// again until maxStale has elapsed, and is not promoted under [PolicyLRU].
+// An entry that expired maxStale or longer ago is removed as by [Cache.Get].
+// Expired entries purged by the janitor started with [WithJanitor] cannot be
+// served stale.
+// GetStale counts as a Get call in [Stats], and only a fresh entry counts as
+// a hit.
+//
+// Returns the zero value, false and false if the key is not found.
+func (c *Cache[K, V]) GetStale(k K, maxStale time.Duration) (v V, fresh, ok bool) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].getWithin(c, h, k, maxStale)
+}
+
+// Touch renews the TTL of an existing entry, so that it expires after its
+// full TTL from now, and reports whether the key was present.
+//
+// The TTL is the one the entry was last stored with by [Cache.SetWithTTL] or
+// [Cache.SetTTL]. Entries without a TTL are left unchanged, but Touch still
+// reports true for them. This suits sessions that must stay alive while in
+// use. Like [Cache.SetTTL], Touch does not count in [Stats] and does not
+// promote the entry under [PolicyLRU].
+func (c *Cache[K, V]) Touch(k K) bool {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].setExpiry(c, h, k, func(e *entry[K, V]) expiry {
+		if e.ttl == 0 {
+			return expiry{at: e.expireAt}
+		}
+
+		return c.expiryAfter(time.Duration(e.ttl))
+	})
+}
+
+// janitor periodically purges expired entries.
+type janitor struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (j *janitor) close() {
+	j.stopOnce.Do(func() {
+		close(j.stop)
+	})
+}
+
+// Stop stops the janitor started by [WithJanitor], if any.
+//
+// The cache remains usable; expired entries are then only removed on access.
+func (c *Cache[K, V]) Stop() {
+	if c.janitor != nil {
+		c.janitor.close()
+	}
+}
+
+func (c *Cache[K, V]) startJanitor(interval time.Duration) {
+	j := &janitor{stop: make(chan struct{})}
+	c.janitor = j
+
+	// The goroutine only holds a weak pointer, so an unreachable cache can
+	// be collected, which in turn stops the janitor.
+	wp := weak.Make(c)
+	runtime.AddCleanup(c, (*janitor).close, j)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				c := wp.Value()
+				if c == nil {
+					return
+				}
+				c.purgeExpired()
+			}
+		}
+	}()
+}
+
+// purgeExpired removes expired entries, locking one shard at a time.
+func (c *Cache[K, V]) purgeExpired() {
+	for i := range c.shards {
+		c.shards[i].purgeExpired(c)
+	}
+}
@@ -0,0 +1,527 @@
+package fastcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// expireKey makes the entry for k expire immediately.
+func expireKey[K comparable, V any](c *Cache[K, V], k K) {
+	h := c.hasher(k)
+	s := &c.shards[c.shardIndexFromHash(h)]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pos := findEntry(s.entries[h], k); pos >= 0 {
+		s.setExpiryLocked(&s.entries[h][pos], expiry{at: 1})
+	}
+}
+
+func TestCacheSetWithTTL(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.SetWithTTL("key", "value", 0); !errors.Is(err, ErrInvalidTTL) {
+		t.Fatalf("SetWithTTL returned error %v; want %v", err, ErrInvalidTTL)
+	}
+
+	if err := c.SetWithTTL("key", "value", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if v, ok := c.Get("key"); !ok || v != "value" {
+		t.Fatalf("unexpected value before expiry; got (%q, %t); want (%q, true)", v, ok, "value")
+	}
+
+	expireKey(c, "key")
+	if _, ok := c.Peek("key"); ok {
+		t.Fatal("Peek returned an expired entry")
+	}
+	for range c.All() {
+		t.Fatal("All yielded an expired entry")
+	}
+	if _, ok := c.Get("key"); ok {
+		t.Fatal("Get returned an expired entry")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expired entry was not removed on access; len=%d", c.Len())
+	}
+
+	var s Stats
+	c.UpdateStats(&s)
+	if s.Expirations != 1 || s.Misses != 1 {
+		t.Fatalf("unexpected stats after expiry; got Expirations=%d, Misses=%d; want 1, 1", s.Expirations, s.Misses)
+	}
+
+	// Set clears the TTL of an existing entry
+	if err := c.SetWithTTL("key", "value", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if err := c.Set("key", "value2"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	h := c.hasher("key")
+	if n := c.shards[c.shardIndexFromHash(h)].expiring; n != 0 {
+		t.Fatalf("unexpected expiring count after Set; got %d; want 0", n)
+	}
+
+	// Expired entries are replaced like missing ones
+	if err := c.SetWithTTL("other", "old", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	expireKey(c, "other")
+	stored, err := c.SetIfAbsent("other", "new")
+	if err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	if !stored {
+		t.Fatal("SetIfAbsent did not replace an expired entry")
+	}
+}
+
+func TestCacheGetWithExpiry(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if _, exp, ok := c.GetWithExpiry("missing"); ok || !exp.IsZero() {
+		t.Fatalf("unexpected GetWithExpiry result for missing key; got (%s, %t); want (zero, false)", exp, ok)
+	}
+
+	if err := c.Set("plain", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if v, exp, ok := c.GetWithExpiry("plain"); !ok || v != "value" || !exp.IsZero() {
+		t.Fatalf("unexpected GetWithExpiry result without TTL; got (%q, %s, %t); want (%q, zero, true)", v, exp, ok, "value")
+	}
+
+	before := time.Now()
+	if err := c.SetWithTTL("ttl", "value", time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	after := time.Now()
+
+	v, exp, ok := c.GetWithExpiry("ttl")
+	if !ok || v != "value" {
+		t.Fatalf("unexpected GetWithExpiry result; got (%q, %t); want (%q, true)", v, ok, "value")
+	}
+	if exp.Before(before.Add(time.Hour)) || exp.After(after.Add(time.Hour)) {
+		t.Fatalf("unexpected expiry %s; want within [%s, %s]", exp, before.Add(time.Hour), after.Add(time.Hour))
+	}
+
+	expireKey(c, "ttl")
+	if _, _, ok := c.GetWithExpiry("ttl"); ok {
+		t.Fatal("GetWithExpiry returned an expired entry")
+	}
+
+	if s := c.Stats(); s.GetCalls != 4 || s.Misses != 2 {
+		t.Fatalf("unexpected stats; got GetCalls=%d, Misses=%d; want 4, 2", s.GetCalls, s.Misses)
+	}
+}
+
+func TestCacheJanitor(t *testing.T) {
+	c, err := New[string, int](1000, WithJanitor(time.Millisecond))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	const itemsCount = 100
+	for i := range itemsCount {
+		if err := c.SetWithTTL(fmt.Sprintf("key %d", i), i, time.Millisecond); err != nil {
+			t.Fatalf("SetWithTTL error: %s", err)
+		}
+	}
+	if err := c.Set("forever", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Len() > 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not purge expired entries; len=%d", c.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if s := c.Stats(); s.Expirations != itemsCount {
+		t.Fatalf("unexpected expirations; got %d; want %d", s.Expirations, itemsCount)
+	}
+	if !c.Has("forever") {
+		t.Fatal("janitor removed an entry without TTL")
+	}
+}
+
+func TestCacheJanitorStopsWhenCollected(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	func() {
+		c, err := New[string, int](10, WithJanitor(time.Millisecond))
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+		if err := c.Set("key", 1); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor goroutine leaked; goroutines=%d; want %d", runtime.NumGoroutine(), before)
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// fakeClock is a [Clock] that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+func TestCacheWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.SetWithTTL("key", 1, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if err := c.SetNegative("negative", time.Minute); err != nil {
+		t.Fatalf("SetNegative error: %s", err)
+	}
+
+	if _, exp, ok := c.GetWithExpiry("key"); !ok || !exp.Equal(time.Unix(1060, 0)) {
+		t.Fatalf("unexpected GetWithExpiry result; got (%s, %t); want (%s, true)", exp, ok, time.Unix(1060, 0))
+	}
+
+	clock.Advance(time.Minute - time.Nanosecond)
+	if !c.Has("key") {
+		t.Fatal("entry expired before its TTL elapsed on the clock")
+	}
+	if _, _, negative := c.GetNegative("negative"); !negative {
+		t.Fatal("negative entry expired before its TTL elapsed on the clock")
+	}
+
+	clock.Advance(time.Nanosecond)
+	if c.Has("key") {
+		t.Fatal("entry did not expire once its TTL elapsed on the clock")
+	}
+	if _, _, negative := c.GetNegative("negative"); negative {
+		t.Fatal("negative entry did not expire once its TTL elapsed on the clock")
+	}
+}
+
+func TestCacheSetTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.SetTTL("missing", time.Minute) {
+		t.Fatal("SetTTL reported success for a missing key")
+	}
+	if c.Has("missing") {
+		t.Fatal("SetTTL inserted a missing key")
+	}
+
+	if err := c.Set("key", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if c.SetTTL("key", 0) {
+		t.Fatal("SetTTL reported success for a non-positive ttl")
+	}
+	if !c.SetTTL("key", time.Minute) {
+		t.Fatal("SetTTL reported failure for an existing key")
+	}
+	if v, exp, ok := c.GetWithExpiry("key"); !ok || v != 1 || !exp.Equal(time.Unix(1060, 0)) {
+		t.Fatalf("unexpected GetWithExpiry result; got (%d, %s, %t); want (1, %s, true)", v, exp, ok, time.Unix(1060, 0))
+	}
+
+	// SetTTL replaces an earlier expiration, also with a shorter one
+	clock.Advance(30 * time.Second)
+	if !c.SetTTL("key", 10*time.Second) {
+		t.Fatal("SetTTL reported failure for an existing key")
+	}
+	if _, exp, _ := c.GetWithExpiry("key"); !exp.Equal(time.Unix(1040, 0)) {
+		t.Fatalf("unexpected expiry after SetTTL; got %s; want %s", exp, time.Unix(1040, 0))
+	}
+
+	clock.Advance(10 * time.Second)
+	if c.Has("key") {
+		t.Fatal("entry did not expire after the TTL set with SetTTL")
+	}
+	if c.SetTTL("key", time.Minute) {
+		t.Fatal("SetTTL reported success for an expired key")
+	}
+}
+
+func TestCacheTouch(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if c.Touch("missing") {
+		t.Fatal("Touch reported success for a missing key")
+	}
+
+	if err := c.SetWithTTL("session", 1, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+
+	// Keep the session alive past its original TTL
+	for range 3 {
+		clock.Advance(45 * time.Second)
+		if !c.Touch("session") {
+			t.Fatal("Touch reported failure for a live session")
+		}
+	}
+	if _, exp, ok := c.GetWithExpiry("session"); !ok || !exp.Equal(time.Unix(1195, 0)) {
+		t.Fatalf("unexpected GetWithExpiry result; got (%s, %t); want (%s, true)", exp, ok, time.Unix(1195, 0))
+	}
+
+	clock.Advance(time.Minute)
+	if c.Touch("session") {
+		t.Fatal("Touch reported success for an expired session")
+	}
+
+	// Entries without a TTL are left unchanged
+	if err := c.Set("key", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if !c.Touch("key") {
+		t.Fatal("Touch reported failure for an existing key")
+	}
+	if _, exp, _ := c.GetWithExpiry("key"); !exp.IsZero() {
+		t.Fatalf("Touch added an expiration; got %s; want zero", exp)
+	}
+
+	// Touch renews the TTL set with SetTTL
+	c.SetTTL("key", 10*time.Second)
+	clock.Advance(5 * time.Second)
+	c.Touch("key")
+	if _, exp, _ := c.GetWithExpiry("key"); !exp.Equal(clock.Now().Add(10 * time.Second)) {
+		t.Fatalf("unexpected expiry after Touch; got %s; want %s", exp, clock.Now().Add(10*time.Second))
+	}
+}
+
+func TestCacheGetAndTouch(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if _, ok := c.GetAndTouch("missing", time.Minute); ok {
+		t.Fatal("GetAndTouch found a missing key")
+	}
+
+	if err := c.SetWithTTL("session", 1, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+
+	// Every read slides the expiration past the original TTL.
+	for range 4 {
+		clock.Advance(20 * time.Second)
+		if v, ok := c.GetAndTouch("session", 30*time.Second); !ok || v != 1 {
+			t.Fatalf("unexpected GetAndTouch result; got (%d, %t); want (1, true)", v, ok)
+		}
+	}
+	if _, exp, ok := c.GetWithExpiry("session"); !ok || !exp.Equal(clock.Now().Add(30*time.Second)) {
+		t.Fatalf("unexpected expiry; got (%s, %t); want (%s, true)", exp, ok, clock.Now().Add(30*time.Second))
+	}
+
+	// The new TTL is the one Touch renews.
+	clock.Advance(10 * time.Second)
+	c.Touch("session")
+	if _, exp, _ := c.GetWithExpiry("session"); !exp.Equal(clock.Now().Add(30 * time.Second)) {
+		t.Fatalf("unexpected expiry after Touch; got %s; want %s", exp, clock.Now().Add(30*time.Second))
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok := c.GetAndTouch("session", time.Minute); ok {
+		t.Fatal("GetAndTouch found an expired key")
+	}
+
+	s := c.Stats()
+	if s.GetCalls != 8 || s.Misses != 2 {
+		t.Fatalf("unexpected stats; got GetCalls=%d, Misses=%d; want 8, 2", s.GetCalls, s.Misses)
+	}
+}
+
+func TestCacheJanitorWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock), WithJanitor(time.Millisecond))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.SetWithTTL("key", 1, time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+
+	// The janitor must not purge the entry while the clock stands still.
+	time.Sleep(10 * time.Millisecond)
+	if c.Len() != 1 {
+		t.Fatalf("janitor purged an entry before its TTL elapsed on the clock; len=%d", c.Len())
+	}
+
+	clock.Advance(time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not purge the expired entry; len=%d", c.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCacheGetStale(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if _, fresh, ok := c.GetStale("missing", time.Minute); fresh || ok {
+		t.Fatalf("unexpected GetStale result for a missing key; got (%t, %t); want (false, false)", fresh, ok)
+	}
+
+	if err := c.SetWithTTL("k", 1, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if v, fresh, ok := c.GetStale("k", time.Minute); v != 1 || !fresh || !ok {
+		t.Fatalf("unexpected GetStale result before expiry; got (%d, %t, %t); want (1, true, true)", v, fresh, ok)
+	}
+
+	// An expired entry is served, and kept, until maxStale has elapsed.
+	clock.Advance(90 * time.Second)
+	for range 2 {
+		if v, fresh, ok := c.GetStale("k", time.Minute); v != 1 || fresh || !ok {
+			t.Fatalf("unexpected GetStale result within maxStale; got (%d, %t, %t); want (1, false, true)", v, fresh, ok)
+		}
+	}
+	if _, ok := c.Peek("k"); ok {
+		t.Fatal("Peek returned an expired entry")
+	}
+
+	clock.Advance(30 * time.Second)
+	if v, fresh, ok := c.GetStale("k", time.Minute); v != 0 || fresh || ok {
+		t.Fatalf("unexpected GetStale result past maxStale; got (%d, %t, %t); want (0, false, false)", v, fresh, ok)
+	}
+	if n := c.Len(); n != 0 {
+		t.Fatalf("entry past maxStale was not removed; got len %d; want 0", n)
+	}
+
+	s := c.Stats()
+	if s.GetCalls != 5 || s.Hits != 1 || s.Expirations != 1 {
+		t.Fatalf("unexpected stats; got GetCalls=%d, Hits=%d, Expirations=%d; want 5, 1, 1", s.GetCalls, s.Hits, s.Expirations)
+	}
+}
+
+func TestSaveLoadKeepsTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	c, err := New[string, int](100, WithClock(clock))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("permanent", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if err := c.SetWithTTL("expiring", 2, 2*time.Hour); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	if err := c.SetWithTTL("expired", 3, time.Minute); err != nil {
+		t.Fatalf("SetWithTTL error: %s", err)
+	}
+	clock.Advance(time.Hour)
+
+	formats := []struct {
+		name string
+		save func(c *Cache[string, int], w *bytes.Buffer) error
+		load func(r *bytes.Buffer) (*Cache[string, int], error)
+	}{
+		{
+			name: "binary",
+			save: func(c *Cache[string, int], w *bytes.Buffer) error { return c.SaveTo(w) },
+			load: func(r *bytes.Buffer) (*Cache[string, int], error) { return LoadFrom[string, int](r) },
+		},
+		{
+			name: "json",
+			save: func(c *Cache[string, int], w *bytes.Buffer) error { return c.SaveToJSON(w) },
+			load: func(r *bytes.Buffer) (*Cache[string, int], error) { return LoadFromJSON[string, int](r) },
+		},
+	}
+	for _, f := range formats {
+		t.Run(f.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := f.save(c, &buf); err != nil {
+				t.Fatalf("save error: %s", err)
+			}
+			c2, err := f.load(&buf)
+			if err != nil {
+				t.Fatalf("load error: %s", err)
+			}
+			defer c2.Reset()
+
+			if c2.Len() != 2 || c2.Has("expired") {
+				t.Fatalf("unexpected entries after load; got %d entries, expired=%t; want 2, false", c2.Len(), c2.Has("expired"))
+			}
+			if _, exp, ok := c2.GetWithExpiry("permanent"); !ok || !exp.IsZero() {
+				t.Fatalf("unexpected expiry of permanent entry; got (%s, %t); want (zero, true)", exp, ok)
+			}
+
+			// The entry keeps the hour it had left, not the full TTL.
+			_, exp, ok := c2.GetWithExpiry("expiring")
+			if left := time.Until(exp); !ok || left <= 59*time.Minute || left > time.Hour {
+				t.Fatalf("unexpected expiry of expiring entry; got %s left, ok=%t; want about 1h", left, ok)
+			}
+
+			// Touch extends by the TTL the entry was stored with.
+			if !c2.Touch("expiring") {
+				t.Fatal("Touch did not find the expiring entry")
+			}
+			_, exp, _ = c2.GetWithExpiry("expiring")
+			if left := time.Until(exp); left <= time.Hour {
+				t.Fatalf("Touch did not extend by the original TTL; got %s left; want about 2h", left)
+			}
+		})
+	}
+}
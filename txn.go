@@ -0,0 +1,152 @@
+package fastcache
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Txn gives access to a fixed set of keys while their shards are locked; see
+// [Cache.WithLock].
+type Txn[K comparable, V any] struct {
+	c    *Cache[K, V]
+	keys map[K]uint64 // declared keys and their hashes
+}
+
+// WithLock locks the shards owning keys, calls fn with a [Txn] for them and
+// releases the locks when fn returns. The reads and writes fn makes through
+// the Txn are therefore atomic with respect to every other cache operation,
+// e.g. to move a value from one key to another.
+//
+// The Txn only gives access to the keys passed to WithLock: its methods
+// panic for any other key, and it must not be used after fn returns. fn
+// must not call any method of c, which would deadlock. The shards are locked
+// in ascending order, so concurrent WithLock calls cannot deadlock each
+// other, and inserts and evictions are blocked until fn returns, so keep fn
+// short.
+//
+// New entries stored by fn are not made room for while the shards are
+// locked. Once fn returns, the oldest entries are evicted until the cache is
+// back within its limits, which may evict entries stored by fn in a small
+// cache. Under [WithStrictCapacity], [Txn.Set] returns [ErrCacheFull]
+// instead.
+func (c *Cache[K, V]) WithLock(keys []K, fn func(txn *Txn[K, V])) {
+	txn := &Txn[K, V]{c: c, keys: make(map[K]uint64, len(keys))}
+	idxs := make([]int, 0, len(keys))
+	for _, k := range keys {
+		h := c.hasher(k)
+		txn.keys[k] = h
+		idxs = append(idxs, c.shardIndexFromHash(h)%len(c.stripes))
+	}
+	// Shards may share a lock stripe, so the stripes are locked rather
+	// than the shards.
+	slices.Sort(idxs)
+	idxs = slices.Compact(idxs)
+
+	c.orderMu.Lock()
+	defer c.unlockOrder()
+
+	c.compactOrderLocked()
+	for _, idx := range idxs {
+		c.stripes[idx].Lock()
+	}
+	defer func() {
+		txn.c = nil
+		for _, idx := range idxs {
+			c.stripes[idx].Unlock()
+		}
+
+		if !c.strict {
+			for c.entryCount.Load() > int64(c.maxEntries) {
+				if !c.evictOldestLocked(evictCapacity) {
+					break
+				}
+			}
+		}
+		c.evictOverLimitsLocked()
+	}()
+
+	fn(txn)
+}
+
+// shard returns the hash of k and its locked shard. It panics if k was not
+// passed to WithLock or if fn has returned.
+func (t *Txn[K, V]) shard(k K) (uint64, int) {
+	if t.c == nil {
+		panic("fastcache: Txn used after WithLock returned")
+	}
+	h, ok := t.keys[k]
+	if !ok {
+		panic(fmt.Sprintf("fastcache: key %v was not passed to WithLock", k))
+	}
+
+	return h, t.c.shardIndexFromHash(h)
+}
+
+// Get returns the value for k, like [Cache.Get].
+func (t *Txn[K, V]) Get(k K) (V, bool) {
+	h, idx := t.shard(k)
+	c := t.c
+	s := &c.shards[idx]
+
+	if !c.noStats {
+		s.getCalls++
+	}
+	s.sampleLocked(c, k)
+	bucket, pos := s.lookupLocked(c, h, k)
+	if pos >= 0 {
+		c.touchLocked(bucket[pos].node)
+
+		return bucket[pos].Value, true
+	}
+	if !c.noStats {
+		s.misses++
+	}
+
+	var zero V
+
+	return zero, false
+}
+
+// Set stores (k, v), like [Cache.Set].
+//
+// Set returns an error if the entry exceeds the byte limit, or if k is new
+// and the cache is full under [WithStrictCapacity].
+func (t *Txn[K, V]) Set(k K, v V) error {
+	h, idx := t.shard(k)
+	c := t.c
+	s := &c.shards[idx]
+
+	if !c.noStats {
+		s.setCalls++
+	}
+	bucket, pos := s.lookupLocked(c, h, k)
+	if pos >= 0 {
+		if err := s.replaceLocked(c, &bucket[pos], v, expiry{}, 1); err != nil {
+			return err
+		}
+		c.touchLocked(bucket[pos].node)
+
+		return nil
+	}
+
+	size := c.entrySize(k, v)
+	if err := c.checkEntry(size, 1); err != nil {
+		return err
+	}
+	if _, full := c.limitReachedBy(size, 1); full && c.strict {
+		return c.errCacheFull()
+	}
+	bucket = s.dropNegativeLocked(c, h, bucket, k)
+	_, err := c.handleInsert(opSet, idx, h, k, v, size, expiry{}, 1, s, bucket)
+
+	return err
+}
+
+// Delete removes k, like [Cache.Delete], and reports whether it was
+// present.
+func (t *Txn[K, V]) Delete(k K) (deleted bool) {
+	h, idx := t.shard(k)
+	_, deleted = t.c.shards[idx].deleteLocked(t.c, h, k)
+
+	return deleted
+}
@@ -0,0 +1,150 @@
+package fastcache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCacheWithLock(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	if err := c.Set("a", 10); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+
+	// Concurrent transfers between a and b must keep their sum.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			from, to := "a", "b"
+			if i%2 == 1 {
+				from, to = to, from
+			}
+			for range 100 {
+				c.WithLock([]string{to, from}, func(txn *Txn[string, int]) {
+					v, ok := txn.Get(from)
+					if !ok || v == 0 {
+						return
+					}
+					w, _ := txn.Get(to)
+					if err := txn.Set(to, w+1); err != nil {
+						t.Errorf("Set error: %s", err)
+					}
+					if err := txn.Set(from, v-1); err != nil {
+						t.Errorf("Set error: %s", err)
+					}
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	a, _ := c.Get("a")
+	b, _ := c.Get("b")
+	if a+b != 10 {
+		t.Fatalf("unexpected sum; got %d+%d; want 10", a, b)
+	}
+
+	c.WithLock([]string{"a", "b"}, func(txn *Txn[string, int]) {
+		txn.Delete("a")
+		if _, ok := txn.Get("a"); ok {
+			t.Fatal("Get found a deleted key")
+		}
+	})
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get found a key deleted by WithLock")
+	}
+}
+
+func TestCacheWithLockSharedStripe(t *testing.T) {
+	c, err := New[int, int](100, WithLockStripes(1))
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	keys := []int{1, 2, 3, 4}
+	c.WithLock(keys, func(txn *Txn[int, int]) {
+		for _, k := range keys {
+			if err := txn.Set(k, k); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+	})
+	if n := c.Len(); n != len(keys) {
+		t.Fatalf("unexpected Len; got %d; want %d", n, len(keys))
+	}
+}
+
+func TestCacheWithLockUndeclaredKey(t *testing.T) {
+	c, err := New[string, int](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Get of an undeclared key did not panic")
+			}
+		}()
+		c.WithLock([]string{"a"}, func(txn *Txn[string, int]) {
+			txn.Get("b")
+		})
+	}()
+
+	// The locks are released after a panic.
+	if err := c.Set("a", 1); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+}
+
+func TestCacheWithLockEvictsAfterReturn(t *testing.T) {
+	c, err := New[int, int](2)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	c.WithLock([]int{1, 2, 3}, func(txn *Txn[int, int]) {
+		for k := 1; k <= 3; k++ {
+			if err := txn.Set(k, k); err != nil {
+				t.Fatalf("Set error: %s", err)
+			}
+		}
+	})
+	if n := c.Len(); n != 2 {
+		t.Fatalf("unexpected Len; got %d; want 2", n)
+	}
+	if _, ok := c.Get(1); ok {
+		t.Fatal("the oldest key was not evicted")
+	}
+}
+
+func TestCacheWithLockStrictCapacity(t *testing.T) {
+	c, err := New[int, int](1, WithStrictCapacity())
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	c.WithLock([]int{1, 2}, func(txn *Txn[int, int]) {
+		if err := txn.Set(1, 1); err != nil {
+			t.Fatalf("Set error: %s", err)
+		}
+		if err := txn.Set(2, 2); !errors.Is(err, ErrCacheFull) {
+			t.Fatalf("unexpected error; got %v; want %v", err, ErrCacheFull)
+		}
+	})
+	if n := c.Len(); n != 1 {
+		t.Fatalf("unexpected Len; got %d; want 1", n)
+	}
+}
@@ -0,0 +1,86 @@
+package fastcache
+
+import (
+	"context"
+	"slices"
+)
+
+// WaitFor returns the value for the given key, waiting until it is stored if
+// it is missing.
+//
+// WaitFor returns as soon as any operation inserts the key, such as
+// [Cache.Set], [Cache.GetOrSet] or [Cache.SetIfAbsent]. Like [Cache.Peek], it
+// does not count as a Get call in [Stats] and does not promote the key under
+// [PolicyLRU].
+//
+// WaitFor returns ctx.Err() if ctx is done before the key is stored.
+func (c *Cache[K, V]) WaitFor(ctx context.Context, k K) (V, error) {
+	h := c.hasher(k)
+	idx := c.shardIndexFromHash(h)
+
+	return c.shards[idx].waitFor(ctx, c, h, k)
+}
+
+func (s *shard[K, V]) waitFor(ctx context.Context, c *Cache[K, V], hash uint64, k K) (V, error) {
+	s.mu.Lock()
+	if bucket, pos := s.lookupLocked(c, hash, k); pos >= 0 {
+		v := bucket[pos].Value
+		s.mu.Unlock()
+
+		return v, nil
+	}
+
+	// The channel is buffered, so that notifyLocked never blocks.
+	ch := make(chan V, 1)
+	if s.waiters == nil {
+		s.waiters = make(map[K][]chan V)
+	}
+	s.waiters[k] = append(s.waiters[k], ch)
+	s.mu.Unlock()
+
+	select {
+	case v := <-ch:
+		return v, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	s.removeWaiterLocked(k, ch)
+	s.mu.Unlock()
+
+	// The key may have been stored while the waiter was being removed.
+	select {
+	case v := <-ch:
+		return v, nil
+	default:
+		var zero V
+
+		return zero, ctx.Err()
+	}
+}
+
+// notifyLocked sends v to the waiters for k. s.mu must be held.
+func (s *shard[K, V]) notifyLocked(k K, v V) {
+	chans, ok := s.waiters[k]
+	if !ok {
+		return
+	}
+
+	for _, ch := range chans {
+		ch <- v
+	}
+	delete(s.waiters, k)
+}
+
+// removeWaiterLocked unregisters ch from the waiters for k. s.mu must be
+// held.
+func (s *shard[K, V]) removeWaiterLocked(k K, ch chan V) {
+	chans := slices.DeleteFunc(s.waiters[k], func(c chan V) bool {
+		return c == ch
+	})
+	if len(chans) == 0 {
+		delete(s.waiters, k)
+	} else {
+		s.waiters[k] = chans
+	}
+}
@@ -0,0 +1,94 @@
+package fastcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheWaitFor(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	// Existing keys are returned immediately
+	if err := c.Set("present", "value"); err != nil {
+		t.Fatalf("Set error: %s", err)
+	}
+	if v, err := c.WaitFor(context.Background(), "present"); err != nil || v != "value" {
+		t.Fatalf("unexpected WaitFor result; got (%q, %v); want (%q, nil)", v, err, "value")
+	}
+
+	const waitersCount = 5
+	var wg sync.WaitGroup
+	results := make(chan string, waitersCount)
+	for range waitersCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.WaitFor(context.Background(), "key")
+			if err != nil {
+				t.Errorf("WaitFor error: %s", err)
+
+				return
+			}
+			results <- v
+		}()
+	}
+
+	// Wait until all waiters are registered
+	h := c.hasher("key")
+	s := &c.shards[c.shardIndexFromHash(h)]
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.waiters["key"])
+		s.mu.Unlock()
+		if n == waitersCount {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("waiters were not registered; got %d; want %d", n, waitersCount)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := c.SetIfAbsent("key", "value"); err != nil {
+		t.Fatalf("SetIfAbsent error: %s", err)
+	}
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != "value" {
+			t.Fatalf("unexpected value from WaitFor; got %q; want %q", v, "value")
+		}
+	}
+	if len(s.waiters) != 0 {
+		t.Fatalf("waiters were not removed after notification; got %d keys", len(s.waiters))
+	}
+}
+
+func TestCacheWaitForCancel(t *testing.T) {
+	c, err := New[string, string](100)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer c.Reset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.WaitFor(ctx, "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitFor returned error %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	h := c.hasher("key")
+	if s := &c.shards[c.shardIndexFromHash(h)]; len(s.waiters) != 0 {
+		t.Fatalf("waiter was not removed after cancellation; got %d keys", len(s.waiters))
+	}
+}
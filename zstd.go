@@ -0,0 +1,43 @@
+//go:build fastcache_zstd
+
+package fastcache
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	compressionCodecs[CompressionZstd] = zstdGobCodec{}
+}
+
+// zstdGobCodec serializes entries using [gob] and compresses the stream with
+// zstd.
+type zstdGobCodec struct{}
+
+func (c zstdGobCodec) NewEncoder(w io.Writer) Encoder {
+	return newGobEncoder(c, w, nil)
+}
+
+func (zstdGobCodec) NewDecoder(r io.Reader) Decoder {
+	// A synchronous decoder does not start goroutines, so it needs no Close.
+	zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return errDecoder{err}
+	}
+
+	return gob.NewDecoder(zr)
+}
+
+func (zstdGobCodec) compression() Compression {
+	return CompressionZstd
+}
+
+func (zstdGobCodec) compressWriter(w io.Writer) io.WriteCloser {
+	// NewWriter only fails on invalid options.
+	zw, _ := zstd.NewWriter(w)
+
+	return zw
+}